@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"gateway/internal/accrual"
+	"gateway/internal/billing"
 	"gateway/internal/config"
 	httpx "gateway/internal/http"
+	"gateway/internal/money"
 	"gateway/internal/outbox"
+	"gateway/internal/payout"
 	"gateway/internal/repo"
+	"gateway/internal/subscription"
+	"gateway/internal/sweeper"
 	"log"
 	"net/http"
 	"os"
@@ -36,7 +42,36 @@ func main() {
 	worker.BatchSize = 20
 	go worker.Run(ctx)
 
-	router := httpx.NewRouter(dbPool)
+	// Start intent sweeper (expires stale pending intents)
+	sw := sweeper.NewSweeper(dbPool)
+	sw.PollInterval = cfg.IntentSweepInterval
+	go sw.Run(ctx)
+
+	// Start daily interest accrual (no-op unless ACCRUAL_DAILY_RATE_BPS > 0)
+	acc := accrual.NewEngine(dbPool, money.RateBPS(cfg.AccrualDailyRateBPS))
+	acc.PollInterval = cfg.AccrualInterval
+	go acc.Run(ctx)
+
+	// Start billing job (closes cycles and writes monthly statements)
+	bj := billing.NewJob(dbPool)
+	bj.PollInterval = cfg.BillingInterval
+	bj.Delinquency.LateFee = money.Cents(cfg.LateFeeCents)
+	bj.EventsWebhookURL = cfg.AccountEventsWebhookURL
+	go bj.Run(ctx)
+
+	// Start merchant payouts (batches payable balances after holdback and reserve)
+	pj := payout.NewJob(dbPool)
+	pj.PollInterval = cfg.PayoutInterval
+	pj.Policy = cfg.Payout
+	go pj.Run(ctx)
+
+	// Start subscription billing (opens periods, collects them, retries per dunning)
+	subj := subscription.NewJob(dbPool)
+	subj.PollInterval = cfg.SubscriptionInterval
+	subj.Dunning = cfg.Dunning
+	go subj.Run(ctx)
+
+	router := httpx.NewRouter(dbPool, cfg)
 
 	server := &http.Server{
 		Addr:              cfg.Addr(),
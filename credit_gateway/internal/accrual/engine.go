@@ -0,0 +1,102 @@
+package accrual
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Engine accrues daily interest on outstanding principal. Each completed UTC day is
+// accrued once per account; a run after downtime catches up every missed day.
+type Engine struct {
+	DB *pgxpool.Pool
+
+	// DailyRateBPS is charged on principal per day (0 = accrual disabled).
+	DailyRateBPS money.RateBPS
+	PollInterval time.Duration
+	BatchSize    int
+
+	now func() time.Time
+}
+
+func NewEngine(db *pgxpool.Pool, dailyRate money.RateBPS) *Engine {
+	return &Engine{
+		DB:           db,
+		DailyRateBPS: dailyRate,
+		PollInterval: time.Hour,
+		BatchSize:    100,
+		now:          time.Now,
+	}
+}
+
+func (e *Engine) Run(ctx context.Context) {
+	if e.DailyRateBPS <= 0 {
+		return
+	}
+
+	// catch up right away instead of waiting a full interval after a restart
+	if err := e.RunOnce(ctx); err != nil {
+		log.Printf("accrual: %v", err)
+	}
+
+	t := time.NewTicker(e.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := e.RunOnce(ctx); err != nil {
+				log.Printf("accrual: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce accrues every account through yesterday (UTC), the last complete day. One
+// failing account is logged and does not hold up the others; it is retried next run and
+// counted in the returned error.
+func (e *Engine) RunOnce(ctx context.Context) error {
+	if e.DailyRateBPS <= 0 {
+		return nil
+	}
+	y, m, d := e.now().UTC().AddDate(0, 0, -1).Date()
+	through := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	var (
+		after    uuid.UUID
+		failed   int
+		firstErr error
+	)
+	for {
+		ids, err := repo.ListAccountsDueForAccrual(ctx, e.DB, through, after, e.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := repo.AccrueInterest(ctx, e.DB, id, through, e.DailyRateBPS); err != nil {
+				log.Printf("accrual %s: %v", id, err)
+				if failed == 0 {
+					firstErr = err
+				}
+				failed++
+			}
+			after = id
+		}
+		if len(ids) < e.BatchSize {
+			break
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d accounts failed, first: %w", failed, firstErr)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/repo"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job closes billing cycles: for every account whose statement day has passed since its
+// last statement it generates the missing statements in order, so downtime is caught up.
+// It then re-evaluates the account's delinquency against the statements now on file.
+type Job struct {
+	DB          *pgxpool.Pool
+	Terms       domain.BillingTerms
+	Delinquency domain.DelinquencyPolicy
+	// EventsWebhookURL receives delinquency transitions through the outbox ("" = not published).
+	EventsWebhookURL string
+
+	PollInterval time.Duration
+	BatchSize    int
+
+	now func() time.Time
+}
+
+func NewJob(db *pgxpool.Pool) *Job {
+	return &Job{
+		DB:           db,
+		Terms:        domain.DefaultBillingTerms(),
+		Delinquency:  domain.DefaultDelinquencyPolicy(),
+		PollInterval: time.Hour,
+		BatchSize:    100,
+		now:          time.Now,
+	}
+}
+
+func (j *Job) Run(ctx context.Context) {
+	if err := j.RunOnce(ctx); err != nil {
+		log.Printf("billing: %v", err)
+	}
+
+	t := time.NewTicker(j.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := j.RunOnce(ctx); err != nil {
+				log.Printf("billing: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce generates every statement due up to and including today (UTC), then moves each
+// account along (or back out of) its delinquency states.
+func (j *Job) RunOnce(ctx context.Context) error {
+	today := j.now().UTC()
+
+	after := uuid.Nil
+	for {
+		states, err := repo.ListAccountBillingStates(ctx, j.DB, after, j.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			for closeOn := domain.NextStatementDate(s.OpenedOn, s.StatementDay); !closeOn.After(today); closeOn = domain.NextStatementDate(closeOn, s.StatementDay) {
+				if _, err := repo.GenerateStatement(ctx, j.DB, s.AccountID, closeOn, j.Terms); err != nil {
+					return err
+				}
+			}
+			if _, err := repo.EvaluateDelinquency(ctx, j.DB, s.AccountID, today, j.Delinquency, j.EventsWebhookURL); err != nil {
+				return err
+			}
+			after = s.AccountID
+		}
+		if len(states) < j.BatchSize {
+			return nil
+		}
+	}
+}
@@ -3,6 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
 )
 
 type Config struct {
@@ -13,6 +18,35 @@ type Config struct {
 	DBUser        string
 	DBPass        string
 	WebhookSecret string
+
+	// PaymentIntentTTL is how long a pending intent stays confirmable (0 = never expires).
+	PaymentIntentTTL time.Duration
+	// IntentSweepInterval is how often the sweeper expires stale intents.
+	IntentSweepInterval time.Duration
+	// HoldTTL is how long an authorization hold reserves credit before auto-release (0 = never).
+	HoldTTL time.Duration
+	// QuoteTTL is how long a quote can lock the confirm price.
+	QuoteTTL time.Duration
+	// AccrualDailyRateBPS is the daily interest on outstanding principal (0 = no accrual).
+	AccrualDailyRateBPS int64
+	// AccrualInterval is how often the accrual engine looks for un-accrued days.
+	AccrualInterval time.Duration
+	// BillingInterval is how often the billing job looks for cycles to close.
+	BillingInterval time.Duration
+	// LateFeeCents is posted as a penalty when a statement's minimum payment is missed.
+	LateFeeCents int64
+	// AccountEventsWebhookURL receives account events such as delinquency transitions ("" = none).
+	AccountEventsWebhookURL string
+	// ReviewPricing is the interest an approved review is charged at (flagged or approval).
+	ReviewPricing domain.ReviewPricing
+	// PayoutInterval is how often merchant balances are batched into payouts.
+	PayoutInterval time.Duration
+	// Payout holds the holdback period, rolling reserve and minimum payout.
+	Payout domain.PayoutPolicy
+	// SubscriptionInterval is how often due subscriptions are billed.
+	SubscriptionInterval time.Duration
+	// Dunning is when a subscription period that failed to collect is retried.
+	Dunning domain.DunningPolicy
 }
 
 func Load() (*Config, error) {
@@ -45,6 +79,71 @@ func Load() (*Config, error) {
 	if webhookSecret == "" {
 		webhookSecret = "supersecret_1cent"
 	}
+	intentTTL, err := ttlEnv("PAYMENT_INTENT_TTL", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	sweepInterval, err := intervalEnv("INTENT_SWEEP_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	holdTTL, err := ttlEnv("HOLD_TTL", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	quoteTTL, err := intervalEnv("QUOTE_TTL", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	accrualRate, err := intEnv("ACCRUAL_DAILY_RATE_BPS", 0)
+	if err != nil {
+		return nil, err
+	}
+	accrualInterval, err := intervalEnv("ACCRUAL_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	billingInterval, err := intervalEnv("BILLING_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	lateFee, err := intEnv("LATE_FEE_CENTS", domain.LateFeeCents)
+	if err != nil {
+		return nil, err
+	}
+	reviewPricing := domain.ReviewPricingFlagged
+	if v := os.Getenv("REVIEW_PRICING"); v != "" {
+		if reviewPricing, err = domain.ParseReviewPricing(v); err != nil {
+			return nil, fmt.Errorf("invalid REVIEW_PRICING: %w", err)
+		}
+	}
+	payoutInterval, err := intervalEnv("PAYOUT_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	payout := domain.DefaultPayoutPolicy()
+	if payout.Holdback, err = durationEnv("PAYOUT_HOLDBACK", payout.Holdback); err != nil {
+		return nil, err
+	}
+	reserveBPS, err := intEnv("PAYOUT_RESERVE_BPS", int64(payout.ReserveBPS))
+	if err != nil {
+		return nil, err
+	}
+	minPayout, err := intEnv("PAYOUT_MIN_CENTS", int64(payout.MinPayout))
+	if err != nil {
+		return nil, err
+	}
+	payout.ReserveBPS, payout.MinPayout = money.RateBPS(reserveBPS), money.Cents(minPayout)
+	subscriptionInterval, err := intervalEnv("SUBSCRIPTION_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	dunning := domain.DefaultDunningPolicy()
+	if v := os.Getenv("DUNNING_SCHEDULE"); v != "" {
+		if dunning, err = domain.ParseDunningSchedule(v); err != nil {
+			return nil, fmt.Errorf("invalid DUNNING_SCHEDULE: %w", err)
+		}
+	}
 	return &Config{
 		HTTPPort:      port,
 		DBHost:        dbHost,
@@ -53,9 +152,75 @@ func Load() (*Config, error) {
 		DBUser:        dbUser,
 		DBPass:        dbPass,
 		WebhookSecret: webhookSecret,
+
+		PaymentIntentTTL:    intentTTL,
+		IntentSweepInterval: sweepInterval,
+		HoldTTL:             holdTTL,
+		QuoteTTL:            quoteTTL,
+		AccrualDailyRateBPS: accrualRate,
+		AccrualInterval:     accrualInterval,
+		BillingInterval:     billingInterval,
+		LateFeeCents:        lateFee,
+
+		AccountEventsWebhookURL: os.Getenv("ACCOUNT_EVENTS_WEBHOOK_URL"),
+		ReviewPricing:           reviewPricing,
+		PayoutInterval:          payoutInterval,
+		Payout:                  payout,
+		SubscriptionInterval:    subscriptionInterval,
+		Dunning:                 dunning,
 	}, nil
 }
 
+// durationEnv parses a Go duration (e.g. "15m") from env, falling back to def when unset.
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// ttlEnv is durationEnv for expiry TTLs: 0 means never expires, negative values are rejected.
+func ttlEnv(key string, def time.Duration) (time.Duration, error) {
+	d, err := durationEnv(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative, got %s", key, d)
+	}
+	return d, nil
+}
+
+// intervalEnv is durationEnv for job poll intervals and QUOTE_TTL, which must be positive.
+func intervalEnv(key string, def time.Duration) (time.Duration, error) {
+	d, err := durationEnv(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive, got %s", key, d)
+	}
+	return d, nil
+}
+
+// intEnv parses a non-negative integer from env, falling back to def when unset.
+func intEnv(key string, def int64) (int64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, v)
+	}
+	return n, nil
+}
+
 func (c *Config) Addr() string {
 	return fmt.Sprintf(":%s", c.HTTPPort)
 }
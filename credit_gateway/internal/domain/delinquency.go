@@ -0,0 +1,77 @@
+package domain
+
+import "gateway/internal/money"
+
+// DelinquencyStatus only moves forward (current -> late -> delinquent -> charged_off)
+// until a payment cures it back to current; charged_off is final.
+type DelinquencyStatus string
+
+const (
+	Current    DelinquencyStatus = "current"
+	Late       DelinquencyStatus = "late"
+	Delinquent DelinquencyStatus = "delinquent"
+	ChargedOff DelinquencyStatus = "charged_off"
+)
+
+var delinquencyOrder = []DelinquencyStatus{Current, Late, Delinquent, ChargedOff}
+
+func (s DelinquencyStatus) rank() int {
+	for i, o := range delinquencyOrder {
+		if o == s {
+			return i
+		}
+	}
+	return 0
+}
+
+// LateFeeCents is the default fee for missing a statement's minimum payment.
+const LateFeeCents = 1000 // $10.00
+
+type DelinquencyPolicy struct {
+	// days past the due date at which each status starts
+	LateAfterDays       int
+	DelinquentAfterDays int
+	ChargeOffAfterDays  int
+	// LateFee: posted as a penalty once per missed statement (0 = no fee)
+	LateFee money.Cents
+	// LockAt: the account is locked on reaching this status
+	LockAt DelinquencyStatus
+}
+
+func DefaultDelinquencyPolicy() DelinquencyPolicy {
+	return DelinquencyPolicy{
+		LateAfterDays:       1,
+		DelinquentAfterDays: 30,
+		ChargeOffAfterDays:  180,
+		LateFee:             LateFeeCents,
+		LockAt:              Delinquent,
+	}
+}
+
+// StatusFor is the status an account daysPastDue days past its due date should be in.
+func (p DelinquencyPolicy) StatusFor(daysPastDue int) DelinquencyStatus {
+	switch {
+	case daysPastDue >= p.ChargeOffAfterDays:
+		return ChargedOff
+	case daysPastDue >= p.DelinquentAfterDays:
+		return Delinquent
+	case daysPastDue >= p.LateAfterDays:
+		return Late
+	default:
+		return Current
+	}
+}
+
+// Locks reports whether an account in s must be locked.
+func (p DelinquencyPolicy) Locks(s DelinquencyStatus) bool {
+	return p.LockAt != "" && s.rank() >= p.LockAt.rank()
+}
+
+// Advance lists every status passed through moving forward from `from` to `to`
+// (excluding from). Nothing is returned unless to is further along.
+func Advance(from, to DelinquencyStatus) []DelinquencyStatus {
+	if to.rank() <= from.rank() {
+		return nil
+	}
+	return append([]DelinquencyStatus(nil), delinquencyOrder[from.rank()+1:to.rank()+1]...)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDelinquencyPolicy_StatusFor(t *testing.T) {
+	p := DefaultDelinquencyPolicy()
+
+	cases := map[int]DelinquencyStatus{
+		0:   Current,
+		1:   Late,
+		29:  Late,
+		30:  Delinquent,
+		179: Delinquent,
+		180: ChargedOff,
+	}
+	for days, want := range cases {
+		if got := p.StatusFor(days); got != want {
+			t.Errorf("StatusFor(%d) = %s, want %s", days, got, want)
+		}
+	}
+
+	if p.Locks(Late) || !p.Locks(Delinquent) || !p.Locks(ChargedOff) {
+		t.Fatal("default policy should lock from delinquent on")
+	}
+}
+
+func TestAdvance_StepsThroughEveryStatus(t *testing.T) {
+	if got := Advance(Current, ChargedOff); !slices.Equal(got, []DelinquencyStatus{Late, Delinquent, ChargedOff}) {
+		t.Fatalf("Advance(current, charged_off) = %v", got)
+	}
+	if got := Advance(Late, Late); got != nil {
+		t.Fatalf("Advance(late, late) = %v", got)
+	}
+	if got := Advance(Delinquent, Late); got != nil {
+		t.Fatalf("Advance(delinquent, late) = %v", got)
+	}
+}
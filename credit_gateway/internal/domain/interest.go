@@ -1,43 +1,120 @@
 package domain
 
 import (
+	"time"
+
 	"gateway/internal/money"
 )
 
 // InterestPolicy defines the stupid rules.
 type InterestPolicy struct {
+	// Version: interest_policies.version the rules were loaded from (0 = not persisted)
+	Version int64
 	// BaseRateBPS: start at 100% => 10,000 bps
 	BaseRateBPS money.RateBPS
 	// StepBPSPerAttempt: +0.1% per attempt => 10 bps
 	StepBPSPerAttempt money.RateBPS
 	// InvalidAmountPenaltyMultiplier: invalid amount fee = multiplier * current interest
 	// InvalidAmountPenaltyMultiplier int64
+	// InvalidAmountFineCents: flat fine for amounts outside 1..10 cents
+	InvalidAmountFineCents money.Cents
+	// MaxRateBPS: ceiling for RateBPS (0 = uncapped)
+	MaxRateBPS money.RateBPS
+	// AttemptDecayInterval / AttemptDecayPerInterval: every full interval without an
+	// attempt forgives AttemptDecayPerInterval attempts (either 0 = no decay)
+	AttemptDecayInterval    time.Duration
+	AttemptDecayPerInterval int64
+	// AttemptsForgivenPerRepayment: attempts forgiven by each repayment (0 = none)
+	AttemptsForgivenPerRepayment int64
+	// Rounding: applied to interest and every percentage-based fee (zero value = floor)
+	Rounding money.RoundingMode
 }
 
 const InvalidAmountFineCents = 1000 // $10.00
 
 func DefaultPolicy() InterestPolicy {
 	return InterestPolicy{
+		Version:           1,
 		BaseRateBPS:       10000,
 		StepBPSPerAttempt: 100, //1%
 		// InvalidAmountPenaltyMultiplier: 10,
+		InvalidAmountFineCents: InvalidAmountFineCents,
 	}
 }
 
-// RateBPS = 100% + (attempt_count * 0.1%)
-func (p InterestPolicy) RateBPS(attemptCount int64) money.RateBPS {
+// RateBPS = min(100% + (attempt_count * 0.1%), MaxRateBPS).
+// Uncapped policies return money.ErrOverflow instead of wrapping.
+func (p InterestPolicy) RateBPS(attemptCount int64) (money.RateBPS, error) {
 	if attemptCount < 0 {
 		attemptCount = 0
 	}
 
-	return p.BaseRateBPS + money.RateBPS(attemptCount)*p.StepBPSPerAttempt
+	if p.MaxRateBPS > 0 {
+		if p.BaseRateBPS >= p.MaxRateBPS {
+			return p.MaxRateBPS, nil
+		}
+		// checked before multiplying so huge attempt counts cannot overflow
+		if p.StepBPSPerAttempt > 0 && attemptCount > int64((p.MaxRateBPS-p.BaseRateBPS)/p.StepBPSPerAttempt) {
+			return p.MaxRateBPS, nil
+		}
+	}
+
+	step, err := money.Mul(p.StepBPSPerAttempt, attemptCount)
+	if err != nil {
+		return 0, err
+	}
+	return money.Add(p.BaseRateBPS, step)
+}
+
+// DecayedAttempts is attemptCount after idle time without attempts, never below 0.
+func (p InterestPolicy) DecayedAttempts(attemptCount int64, idle time.Duration) int64 {
+	if attemptCount <= 0 {
+		return 0
+	}
+	if p.AttemptDecayInterval <= 0 || p.AttemptDecayPerInterval <= 0 || idle < p.AttemptDecayInterval {
+		return attemptCount
+	}
+
+	periods := int64(idle / p.AttemptDecayInterval)
+	if periods > attemptCount/p.AttemptDecayPerInterval {
+		return 0
+	}
+	return attemptCount - periods*p.AttemptDecayPerInterval
+}
+
+// NextAttempt is the attempt count a confirm at now is priced at: the stored count
+// decayed since lastAttemptAt (nil = never attempted), plus one.
+func (p InterestPolicy) NextAttempt(attemptCount int64, lastAttemptAt *time.Time, now time.Time) int64 {
+	if lastAttemptAt != nil {
+		attemptCount = p.DecayedAttempts(attemptCount, now.Sub(*lastAttemptAt))
+	}
+	return attemptCount + 1
+}
+
+// AfterRepayment is attemptCount once a repayment has been booked.
+func (p InterestPolicy) AfterRepayment(attemptCount int64) int64 {
+	if p.AttemptsForgivenPerRepayment <= 0 {
+		return attemptCount
+	}
+	if attemptCount <= p.AttemptsForgivenPerRepayment {
+		return 0
+	}
+	return attemptCount - p.AttemptsForgivenPerRepayment
 }
 
-// InterestDue = floor(spent_cents * rate_bps / 10000)
-func (p InterestPolicy) InterestDue(spent money.Cents, attemptCount int64) money.Cents {
+// InterestDue = round(spent_cents * rate_bps / 10000), rounded per p.Rounding
+func (p InterestPolicy) InterestDue(spent money.Cents, attemptCount int64) (money.Cents, error) {
+
+	rate, err := p.RateBPS(attemptCount)
+	if err != nil {
+		return 0, err
+	}
+	return p.FeeAt(spent, rate)
+}
 
-	rate := p.RateBPS(attemptCount)
-	return money.Cents(int64(spent) * (int64(rate)) / int64(money.BPSDenominator))
+// FeeAt applies a percentage to amount with the policy's rounding mode.
+func (p InterestPolicy) FeeAt(amount money.Cents, rate money.RateBPS) (money.Cents, error) {
+	return money.MulBPS(amount, rate, p.Rounding)
 }
 
 // InvalidAmountFee = multiplier * InterestDue
@@ -1,7 +1,11 @@
 package domain
 
 import (
+	"errors"
+	"math"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"gateway/internal/money"
 )
@@ -10,37 +14,204 @@ func TestInterestPolicy_FloorRules(t *testing.T) {
 	p := DefaultPolicy()
 
 	t.Run("attempt=0 amount=10 => 100% => interest=10", func(t *testing.T) {
-		got := p.InterestDue(money.Cents(10), 0)
+		got := mustInterest(t, p, money.Cents(10), 0)
 		if got != money.Cents(10) {
 			t.Fatalf("interest = %d, want %d", got, 10)
 		}
 	})
 
 	t.Run("attempt=10 amount=10 => 110% => interest=11", func(t *testing.T) {
-		got := p.InterestDue(money.Cents(10), 10)
+		got := mustInterest(t, p, money.Cents(10), 10)
 		if got != money.Cents(11) {
 			t.Fatalf("interest = %d, want %d", got, 11)
 		}
 	})
 
 	t.Run("attempt=10 amount=1 => 110% => floor(1.1)=1", func(t *testing.T) {
-		got := p.InterestDue(money.Cents(1), 10)
+		got := mustInterest(t, p, money.Cents(1), 10)
 		if got != money.Cents(1) {
 			t.Fatalf("interest = %d, want %d", got, 1)
 		}
 	})
 
 	t.Run("attempt=1 amount=10 => 101% => floor(10.1)=10", func(t *testing.T) {
-		got := p.InterestDue(money.Cents(10), 1)
+		got := mustInterest(t, p, money.Cents(10), 1)
 		if got != money.Cents(10) {
 			t.Fatalf("interest = %d, want %d", got, 10)
 		}
 	})
 
 	t.Run("attempt=100 amount=10 => 200% => interest=20", func(t *testing.T) {
-		got := p.InterestDue(money.Cents(10), 100)
+		got := mustInterest(t, p, money.Cents(10), 100)
 		if got != money.Cents(20) {
 			t.Fatalf("interest = %d, want %d", got, 20)
 		}
 	})
 }
+
+func TestInterestPolicy_MaxRateCapsRate(t *testing.T) {
+	p := DefaultPolicy()
+	p.MaxRateBPS = 15000
+
+	if got := mustRate(t, p, 10); got != 11000 {
+		t.Fatalf("rate below cap = %d, want 11000", got)
+	}
+	if got := mustRate(t, p, 50); got != 15000 {
+		t.Fatalf("rate at cap = %d, want 15000", got)
+	}
+	if got := mustRate(t, p, 1<<62); got != 15000 {
+		t.Fatalf("rate for huge attempt count = %d, want 15000", got)
+	}
+	if got := mustInterest(t, p, money.Cents(10), 1000); got != money.Cents(15) {
+		t.Fatalf("capped interest = %d, want 15", got)
+	}
+}
+
+func TestInterestPolicy_OverflowIsAnError(t *testing.T) {
+	p := DefaultPolicy() // uncapped
+
+	if _, err := p.RateBPS(math.MaxInt64 / 10); !errors.Is(err, money.ErrOverflow) {
+		t.Fatalf("RateBPS overflow err = %v", err)
+	}
+	if _, err := p.InterestDue(money.Cents(math.MaxInt64/2), 200); !errors.Is(err, money.ErrOverflow) {
+		t.Fatalf("InterestDue overflow err = %v", err)
+	}
+}
+
+func TestInterestPolicy_Decay(t *testing.T) {
+	p := DefaultPolicy()
+	p.AttemptDecayInterval = 24 * time.Hour
+	p.AttemptDecayPerInterval = 10
+	p.AttemptsForgivenPerRepayment = 5
+
+	if got := p.DecayedAttempts(100, 23*time.Hour); got != 100 {
+		t.Fatalf("decay before one interval = %d, want 100", got)
+	}
+	if got := p.DecayedAttempts(100, 72*time.Hour+time.Minute); got != 70 {
+		t.Fatalf("decay after 3 intervals = %d, want 70", got)
+	}
+	if got := p.DecayedAttempts(100, 365*24*time.Hour); got != 0 {
+		t.Fatalf("decay after a year = %d, want 0", got)
+	}
+
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	last := now.Add(-48 * time.Hour)
+	if got := p.NextAttempt(30, &last, now); got != 11 {
+		t.Fatalf("next attempt = %d, want 11", got)
+	}
+	if got := p.NextAttempt(30, nil, now); got != 31 {
+		t.Fatalf("next attempt without history = %d, want 31", got)
+	}
+
+	if got := p.AfterRepayment(12); got != 7 {
+		t.Fatalf("after repayment = %d, want 7", got)
+	}
+	if got := p.AfterRepayment(3); got != 0 {
+		t.Fatalf("after repayment = %d, want 0", got)
+	}
+}
+
+// quickPolicy builds a policy from small generated values so uncapped rates cannot overflow.
+func quickPolicy(base, step, maxRate uint16) InterestPolicy {
+	return InterestPolicy{
+		BaseRateBPS:       money.RateBPS(base),
+		StepBPSPerAttempt: money.RateBPS(step),
+		MaxRateBPS:        money.RateBPS(maxRate),
+	}
+}
+
+func TestInterestPolicy_PropertyRateMonotonicInAttempts(t *testing.T) {
+	f := func(base, step, maxRate uint16, a, b uint32) bool {
+		p := quickPolicy(base, step, maxRate)
+		lo, hi := int64(a), int64(b)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		rlo, err1 := p.RateBPS(lo)
+		rhi, err2 := p.RateBPS(hi)
+		ilo, err3 := p.InterestDue(money.Cents(10), lo)
+		ihi, err4 := p.InterestDue(money.Cents(10), hi)
+		if err := errors.Join(err1, err2, err3, err4); err != nil {
+			return false
+		}
+		return rlo <= rhi && ilo <= ihi
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterestPolicy_PropertyRateNeverExceedsCap(t *testing.T) {
+	f := func(base, step uint16, maxRate uint16, attempt int64) bool {
+		if maxRate == 0 {
+			maxRate = 1
+		}
+		p := quickPolicy(base, step, maxRate)
+		rate, err := p.RateBPS(attempt)
+		return err == nil && rate <= p.MaxRateBPS
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterestPolicy_PropertyDecayNeverIncreasesAttempts(t *testing.T) {
+	f := func(perInterval uint8, attempt uint32, idleA, idleB uint32) bool {
+		p := InterestPolicy{
+			AttemptDecayInterval:    time.Hour,
+			AttemptDecayPerInterval: int64(perInterval),
+		}
+		short, long := time.Duration(idleA)*time.Minute, time.Duration(idleB)*time.Minute
+		if short > long {
+			short, long = long, short
+		}
+		n := int64(attempt)
+		ds, dl := p.DecayedAttempts(n, short), p.DecayedAttempts(n, long)
+		return ds <= n && dl <= ds && dl >= 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterestPolicy_RoundingModes(t *testing.T) {
+	// 5 cents at 110% = 5.5 cents; 5 cents at 130% = 6.5 cents
+	cases := []struct {
+		mode    money.RoundingMode
+		attempt int64
+		want    money.Cents
+	}{
+		{money.RoundFloor, 10, 5},
+		{money.RoundCeil, 10, 6},
+		{money.RoundHalfUp, 10, 6},
+		{money.RoundHalfEven, 10, 6},
+		{money.RoundHalfUp, 30, 7},
+		{money.RoundHalfEven, 30, 6},
+	}
+	for _, c := range cases {
+		p := DefaultPolicy()
+		p.StepBPSPerAttempt = 100
+		p.Rounding = c.mode
+		if got := mustInterest(t, p, money.Cents(5), c.attempt); got != c.want {
+			t.Errorf("%s attempt=%d: interest = %d, want %d", c.mode, c.attempt, got, c.want)
+		}
+	}
+}
+
+func mustInterest(t *testing.T, p InterestPolicy, spent money.Cents, attemptCount int64) money.Cents {
+	t.Helper()
+	got, err := p.InterestDue(spent, attemptCount)
+	if err != nil {
+		t.Fatalf("InterestDue(%d, %d): %v", spent, attemptCount, err)
+	}
+	return got
+}
+
+func mustRate(t *testing.T, p InterestPolicy, attemptCount int64) money.RateBPS {
+	t.Helper()
+	got, err := p.RateBPS(attemptCount)
+	if err != nil {
+		t.Fatalf("RateBPS(%d): %v", attemptCount, err)
+	}
+	return got
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+
+	"gateway/internal/money"
+)
+
+var (
+	ErrInvalidLineItem = errors.New("line items need a description, a positive quantity and non-negative unit price and tax")
+	ErrEmptyInvoice    = errors.New("invoice total must be positive")
+)
+
+// LineItem is one invoiced good or service. TaxBPS is the tax rate on the line.
+type LineItem struct {
+	Description string
+	Quantity    int64
+	UnitCents   money.Cents
+	TaxBPS      money.RateBPS
+}
+
+type LineTotals struct {
+	Subtotal money.Cents // Quantity × UnitCents
+	Tax      money.Cents
+	Total    money.Cents
+}
+
+type InvoiceTotals struct {
+	Lines    []LineTotals
+	Subtotal money.Cents
+	Tax      money.Cents
+	Total    money.Cents
+}
+
+// Totals prices the line. Tax is rounded half-up on each line, so the invoice tax is
+// the sum of the line taxes.
+func (l LineItem) Totals() (LineTotals, error) {
+	if l.Description == "" || l.Quantity <= 0 || l.UnitCents < 0 || l.TaxBPS < 0 {
+		return LineTotals{}, ErrInvalidLineItem
+	}
+	sub, err := money.Mul(l.UnitCents, l.Quantity)
+	if err != nil {
+		return LineTotals{}, err
+	}
+	tax, err := money.MulBPS(sub, l.TaxBPS, money.RoundHalfUp)
+	if err != nil {
+		return LineTotals{}, err
+	}
+	total, err := money.Add(sub, tax)
+	if err != nil {
+		return LineTotals{}, err
+	}
+	return LineTotals{Subtotal: sub, Tax: tax, Total: total}, nil
+}
+
+// PriceInvoice totals the line items; the total must be positive.
+func PriceInvoice(items []LineItem) (InvoiceTotals, error) {
+	var t InvoiceTotals
+	if len(items) == 0 {
+		return t, ErrEmptyInvoice
+	}
+	for _, item := range items {
+		lt, err := item.Totals()
+		if err != nil {
+			return InvoiceTotals{}, err
+		}
+		if t.Subtotal, err = money.Add(t.Subtotal, lt.Subtotal); err != nil {
+			return InvoiceTotals{}, err
+		}
+		if t.Tax, err = money.Add(t.Tax, lt.Tax); err != nil {
+			return InvoiceTotals{}, err
+		}
+		if t.Total, err = money.Add(t.Total, lt.Total); err != nil {
+			return InvoiceTotals{}, err
+		}
+		t.Lines = append(t.Lines, lt)
+	}
+	if t.Total <= 0 {
+		return InvoiceTotals{}, ErrEmptyInvoice
+	}
+	return t, nil
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPriceInvoice(t *testing.T) {
+	totals, err := PriceInvoice([]LineItem{
+		{Description: "coffee", Quantity: 3, UnitCents: 250, TaxBPS: 825}, // 750 + 61.875
+		{Description: "bagel", Quantity: 1, UnitCents: 199},               // untaxed
+	})
+	if err != nil {
+		t.Fatalf("PriceInvoice: %v", err)
+	}
+	if totals.Lines[0].Subtotal != 750 || totals.Lines[0].Tax != 62 || totals.Lines[0].Total != 812 {
+		t.Errorf("line 0 = %+v, want 750 + 62 = 812", totals.Lines[0])
+	}
+	if totals.Subtotal != 949 || totals.Tax != 62 || totals.Total != 1011 {
+		t.Errorf("totals = %+v, want 949 + 62 = 1011", totals)
+	}
+
+	if _, err := PriceInvoice([]LineItem{{Description: "x", Quantity: 0, UnitCents: 1}}); !errors.Is(err, ErrInvalidLineItem) {
+		t.Errorf("zero quantity err = %v, want ErrInvalidLineItem", err)
+	}
+	if _, err := PriceInvoice([]LineItem{{Description: "free", Quantity: 1}}); !errors.Is(err, ErrEmptyInvoice) {
+		t.Errorf("zero total err = %v, want ErrEmptyInvoice", err)
+	}
+	if _, err := PriceInvoice(nil); !errors.Is(err, ErrEmptyInvoice) {
+		t.Errorf("no lines err = %v, want ErrEmptyInvoice", err)
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "gateway/internal/money"
+
+// FeeSchedule is what the gateway keeps from each installment paid to a merchant:
+// Flat + RateBPS of the installment, at least MinFee, never more than the installment.
+// The zero schedule charges nothing.
+type FeeSchedule struct {
+	Flat     money.Cents
+	RateBPS  money.RateBPS
+	MinFee   money.Cents
+	Rounding money.RoundingMode
+}
+
+// Fee returns the merchant fee on one installment of gross.
+func (s FeeSchedule) Fee(gross money.Cents) (money.Cents, error) {
+	if gross <= 0 {
+		return 0, nil
+	}
+	pct, err := money.MulBPS(gross, s.RateBPS, s.Rounding)
+	if err != nil {
+		return 0, err
+	}
+	fee, err := money.Add(s.Flat, pct)
+	if err != nil {
+		return 0, err
+	}
+	return min(max(fee, s.MinFee), gross), nil
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+
+	"gateway/internal/money"
+)
+
+func TestFeeSchedule_Fee(t *testing.T) {
+	s := FeeSchedule{Flat: 1, RateBPS: 290, MinFee: 2, Rounding: money.RoundHalfUp}
+
+	cases := map[money.Cents]money.Cents{
+		0:     0,
+		1:     1,   // capped at the installment
+		10:    2,   // 1 + 0.29 -> 1, raised to the minimum
+		1000:  30,  // 1 + 29
+		10050: 292, // 1 + 291.45 -> 291
+	}
+	for gross, want := range cases {
+		got, err := s.Fee(gross)
+		if err != nil {
+			t.Fatalf("Fee(%d): %v", gross, err)
+		}
+		if got != want {
+			t.Errorf("Fee(%d) = %d, want %d", gross, got, want)
+		}
+	}
+
+	if fee, err := (FeeSchedule{}).Fee(1000); err != nil || fee != 0 {
+		t.Fatalf("zero schedule fee = %d, %v", fee, err)
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+
+	"gateway/internal/money"
+)
+
+var ErrInvalidSplit = errors.New("payer shares must be positive and add up to the target")
+
+// PayerShare is one payer's part of a merchant request: a fixed amount, or a weight for
+// a proportional share of what the fixed amounts leave.
+type PayerShare struct {
+	Fixed  *money.Cents
+	Weight int64
+}
+
+// SplitTarget turns shares into amounts that add up to target exactly. Weighted shares
+// split the remainder with money.Allocate, so no cent is lost; without weighted shares
+// the fixed amounts must add up to target on their own.
+func SplitTarget(target money.Cents, shares []PayerShare) ([]money.Cents, error) {
+	if target <= 0 || len(shares) == 0 {
+		return nil, ErrInvalidSplit
+	}
+
+	out := make([]money.Cents, len(shares))
+	rest := target
+	var (
+		weights  []int64
+		weighted []int
+	)
+	for i, s := range shares {
+		switch {
+		case s.Fixed != nil && s.Weight == 0:
+			if *s.Fixed <= 0 {
+				return nil, ErrInvalidSplit
+			}
+			out[i] = *s.Fixed
+			rest -= *s.Fixed
+		case s.Fixed == nil && s.Weight > 0:
+			weights = append(weights, s.Weight)
+			weighted = append(weighted, i)
+		default:
+			return nil, ErrInvalidSplit
+		}
+	}
+	if rest < 0 || (len(weights) == 0 && rest != 0) {
+		return nil, ErrInvalidSplit
+	}
+	if len(weights) == 0 {
+		return out, nil
+	}
+
+	parts, err := money.Allocate(rest, weights)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range weighted {
+		if parts[j] <= 0 {
+			return nil, ErrInvalidSplit
+		}
+		out[i] = parts[j]
+	}
+	return out, nil
+}
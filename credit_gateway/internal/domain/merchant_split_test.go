@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"gateway/internal/money"
+)
+
+func TestSplitTarget(t *testing.T) {
+	fixed := func(c money.Cents) PayerShare { return PayerShare{Fixed: &c} }
+	weight := func(w int64) PayerShare { return PayerShare{Weight: w} }
+
+	cases := []struct {
+		name   string
+		target money.Cents
+		shares []PayerShare
+		want   []money.Cents
+	}{
+		{"fixed shares", 100, []PayerShare{fixed(60), fixed(40)}, []money.Cents{60, 40}},
+		{"equal weights keep every cent", 100, []PayerShare{weight(1), weight(1), weight(1)}, []money.Cents{34, 33, 33}},
+		{"weights split what fixed leaves", 100, []PayerShare{fixed(10), weight(1), weight(2)}, []money.Cents{10, 30, 60}},
+	}
+	for _, tc := range cases {
+		got, err := SplitTarget(tc.target, tc.shares)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: SplitTarget = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	invalid := [][]PayerShare{
+		{fixed(60), fixed(30)},  // short of the target
+		{fixed(120), weight(1)}, // fixed beyond the target
+		{fixed(100), weight(1)}, // nothing left for the weighted payer
+		{{}},                    // neither fixed nor weighted
+	}
+	for i, shares := range invalid {
+		if _, err := SplitTarget(100, shares); !errors.Is(err, ErrInvalidSplit) {
+			t.Errorf("invalid case %d: err = %v, want ErrInvalidSplit", i, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Metadata limits: enough for a client's own ids, not a document store.
+const (
+	MaxMetadataKeys     = 20
+	MaxMetadataKeyLen   = 40
+	MaxMetadataValueLen = 500
+)
+
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// Metadata is client-supplied key/value data stored with an object and echoed back on
+// reads and webhooks. The gateway never interprets it.
+type Metadata map[string]string
+
+// Validate enforces the size limits; a nil map is valid (no metadata).
+func (m Metadata) Validate() error {
+	if len(m) > MaxMetadataKeys {
+		return fmt.Errorf("%w: at most %d keys", ErrInvalidMetadata, MaxMetadataKeys)
+	}
+	for k, v := range m {
+		if k == "" || len(k) > MaxMetadataKeyLen {
+			return fmt.Errorf("%w: keys must be 1-%d bytes", ErrInvalidMetadata, MaxMetadataKeyLen)
+		}
+		if len(v) > MaxMetadataValueLen {
+			return fmt.Errorf("%w: value of %q is over %d bytes", ErrInvalidMetadata, k, MaxMetadataValueLen)
+		}
+	}
+	return nil
+}
+
+// OrEmpty returns m, or an empty map for nil so it stores and renders as {}.
+func (m Metadata) OrEmpty() Metadata {
+	if m == nil {
+		return Metadata{}
+	}
+	return m
+}
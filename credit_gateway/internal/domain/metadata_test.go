@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetadataValidate(t *testing.T) {
+	tooMany := Metadata{}
+	for i := 0; i <= MaxMetadataKeys; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+	cases := []struct {
+		name string
+		m    Metadata
+		ok   bool
+	}{
+		{"nil", nil, true},
+		{"order id", Metadata{"order_id": "ord_123"}, true},
+		{"empty key", Metadata{"": "v"}, false},
+		{"long key", Metadata{strings.Repeat("k", MaxMetadataKeyLen+1): "v"}, false},
+		{"long value", Metadata{"k": strings.Repeat("v", MaxMetadataValueLen+1)}, false},
+		{"too many keys", tooMany, false},
+	}
+	for _, c := range cases {
+		err := c.m.Validate()
+		if c.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if !c.ok && !errors.Is(err, ErrInvalidMetadata) {
+			t.Errorf("%s: err = %v, want ErrInvalidMetadata", c.name, err)
+		}
+	}
+}
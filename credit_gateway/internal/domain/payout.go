@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"gateway/internal/money"
+)
+
+// PayoutPolicy decides how much of a merchant's payable balance is paid out. Credits
+// younger than Holdback are not paid yet, and at least ReserveBPS of the balance is kept
+// as a rolling reserve (held-back credits count toward it).
+type PayoutPolicy struct {
+	Holdback   time.Duration
+	ReserveBPS money.RateBPS
+	// MinPayout: smaller amounts wait for the next run
+	MinPayout money.Cents
+}
+
+func DefaultPayoutPolicy() PayoutPolicy {
+	return PayoutPolicy{
+		Holdback:   48 * time.Hour,
+		ReserveBPS: 1000, // 10%
+		MinPayout:  100,  // $1.00
+	}
+}
+
+// Payout splits balance into what is paid now and what stays back. immature is the part
+// of balance credited within the holdback period.
+func (p PayoutPolicy) Payout(balance, immature money.Cents) (payout, retained money.Cents, err error) {
+	if balance <= 0 {
+		return 0, balance, nil
+	}
+	reserve, err := money.MulBPS(balance, p.ReserveBPS, money.RoundCeil)
+	if err != nil {
+		return 0, 0, err
+	}
+	retained = max(reserve, min(max(immature, 0), balance))
+	payout = balance - retained
+	if payout < p.MinPayout || payout <= 0 {
+		return 0, balance, nil
+	}
+	return payout, retained, nil
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"gateway/internal/money"
+)
+
+func TestPayoutPolicy_Payout(t *testing.T) {
+	p := PayoutPolicy{ReserveBPS: 1000, MinPayout: 100}
+
+	cases := []struct {
+		name                 string
+		balance, immature    money.Cents
+		wantPayout, wantKept money.Cents
+	}{
+		{"reserve rounds up", 1001, 0, 900, 101},
+		{"holdback beats reserve", 1000, 400, 600, 400},
+		{"reserve beats holdback", 1000, 50, 900, 100},
+		{"below minimum waits", 100, 0, 0, 100},
+		{"all immature", 1000, 1000, 0, 1000},
+		{"negative balance", -50, 0, 0, -50},
+	}
+	for _, tc := range cases {
+		payout, kept, err := p.Payout(tc.balance, tc.immature)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if payout != tc.wantPayout || kept != tc.wantKept {
+			t.Errorf("%s: Payout(%d, %d) = %d, %d; want %d, %d", tc.name, tc.balance, tc.immature, payout, kept, tc.wantPayout, tc.wantKept)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"time"
+
+	"gateway/internal/money"
+)
+
+// Promotion is a time-boxed rate for one account, e.g. 0% for the first N attempts
+// or until a date. When both limits are set, both must hold.
+type Promotion struct {
+	ID      int64
+	RateBPS money.RateBPS
+	// MaxAttemptCount: applies while attempt_count <= MaxAttemptCount (nil = no attempt limit)
+	MaxAttemptCount *int64
+	StartsAt        time.Time
+	// EndsAt: applies strictly before EndsAt (nil = no end date)
+	EndsAt *time.Time
+}
+
+func (p Promotion) Applies(attemptCount int64, now time.Time) bool {
+	if now.Before(p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	if p.MaxAttemptCount != nil && attemptCount > *p.MaxAttemptCount {
+		return false
+	}
+	return true
+}
+
+// Charge is the interest priced for one confirm and what produced it.
+type Charge struct {
+	RateBPS     money.RateBPS
+	Interest    money.Cents
+	PromotionID *int64
+}
+
+// Price uses the promotion rate when promo applies, the policy rate otherwise. Either is
+// capped at MaxRateBPS.
+func (p InterestPolicy) Price(spent money.Cents, attemptCount int64, promo *Promotion, now time.Time) (Charge, error) {
+	if promo != nil && promo.Applies(attemptCount, now) {
+		rate := promo.RateBPS
+		if p.MaxRateBPS > 0 && rate > p.MaxRateBPS {
+			rate = p.MaxRateBPS
+		}
+		interest, err := p.FeeAt(spent, rate)
+		if err != nil {
+			return Charge{}, err
+		}
+		id := promo.ID
+		return Charge{
+			RateBPS:     rate,
+			Interest:    interest,
+			PromotionID: &id,
+		}, nil
+	}
+
+	rate, err := p.RateBPS(attemptCount)
+	if err != nil {
+		return Charge{}, err
+	}
+	interest, err := p.FeeAt(spent, rate)
+	if err != nil {
+		return Charge{}, err
+	}
+	return Charge{
+		RateBPS:  rate,
+		Interest: interest,
+	}, nil
+}
+
+// WithOverride replaces the base rate and/or step for a single account.
+func (p InterestPolicy) WithOverride(baseRateBPS, stepBPSPerAttempt *money.RateBPS) InterestPolicy {
+	if baseRateBPS != nil {
+		p.BaseRateBPS = *baseRateBPS
+	}
+	if stepBPSPerAttempt != nil {
+		p.StepBPSPerAttempt = *stepBPSPerAttempt
+	}
+	return p
+}
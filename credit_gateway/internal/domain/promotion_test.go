@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"gateway/internal/money"
+)
+
+func TestPromotion_Price(t *testing.T) {
+	p := DefaultPolicy()
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	three := int64(3)
+	ends := now.Add(24 * time.Hour)
+
+	t.Run("first 3 attempts at 0% => attempt=3 free, attempt=4 policy rate", func(t *testing.T) {
+		promo := &Promotion{ID: 7, RateBPS: 0, MaxAttemptCount: &three, StartsAt: now.Add(-time.Hour)}
+
+		c := mustPrice(t, p, money.Cents(10), 3, promo, now)
+		if c.Interest != 0 || c.RateBPS != 0 || c.PromotionID == nil || *c.PromotionID != 7 {
+			t.Fatalf("charge=%+v, want 0 interest from promotion 7", c)
+		}
+
+		c = mustPrice(t, p, money.Cents(10), 4, promo, now)
+		if c.Interest != 10 || c.RateBPS != 10400 || c.PromotionID != nil {
+			t.Fatalf("charge=%+v, want policy rate 10400 interest 10", c)
+		}
+	})
+
+	t.Run("until a date => ends_at is exclusive", func(t *testing.T) {
+		promo := &Promotion{ID: 8, RateBPS: 5000, StartsAt: now.Add(-time.Hour), EndsAt: &ends}
+
+		if c := mustPrice(t, p, money.Cents(10), 100, promo, now); c.Interest != 5 {
+			t.Fatalf("interest=%d want 5 (50%%)", c.Interest)
+		}
+		if c := mustPrice(t, p, money.Cents(10), 100, promo, ends); c.PromotionID != nil {
+			t.Fatalf("promotion applied at ends_at")
+		}
+	})
+
+	t.Run("promotion rate is capped at max_rate_bps", func(t *testing.T) {
+		capped := p
+		capped.MaxRateBPS = 10500
+		promo := &Promotion{ID: 9, RateBPS: 20000, StartsAt: now.Add(-time.Hour)}
+
+		c := mustPrice(t, capped, money.Cents(100), 1, promo, now)
+		if c.RateBPS != 10500 || c.Interest != 105 || c.PromotionID == nil {
+			t.Fatalf("charge=%+v, want capped rate 10500 interest 105", c)
+		}
+	})
+
+	t.Run("override lowers the step", func(t *testing.T) {
+		step := money.RateBPS(10)
+		vip := p.WithOverride(nil, &step)
+		if got := mustInterest(t, vip, money.Cents(100), 100); got != 110 {
+			t.Fatalf("interest=%d want 110", got)
+		}
+	})
+}
+
+func mustPrice(t *testing.T, p InterestPolicy, spent money.Cents, attemptCount int64, promo *Promotion, now time.Time) Charge {
+	t.Helper()
+	c, err := p.Price(spent, attemptCount, promo, now)
+	if err != nil {
+		t.Fatalf("Price: %v", err)
+	}
+	return c
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "fmt"
+
+// ReviewPricing picks the interest an approved review is charged at.
+type ReviewPricing string
+
+const (
+	// ReviewPricingFlagged charges what the confirm was priced at when it was flagged.
+	ReviewPricingFlagged ReviewPricing = "flagged"
+	// ReviewPricingApproval re-prices with the policy, override and promotion in effect at approval.
+	ReviewPricingApproval ReviewPricing = "approval"
+)
+
+func ParseReviewPricing(s string) (ReviewPricing, error) {
+	switch p := ReviewPricing(s); p {
+	case ReviewPricingFlagged, ReviewPricingApproval:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown review pricing %q", s)
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"gateway/internal/money"
+)
+
+var (
+	ErrSpendingLimitExceeded = errors.New("spending limit exceeded")
+	ErrMerchantBlocked       = errors.New("merchant blocked by spending controls")
+)
+
+// Spending limit names reported by SpendingLimitError.
+const (
+	LimitDaily         = "daily"
+	LimitMerchantDaily = "merchant_daily"
+	LimitMaxAttempts   = "max_attempts"
+)
+
+// SpendingLimitError says which limit a payment would break; it matches ErrSpendingLimitExceeded.
+type SpendingLimitError struct {
+	Limit     string
+	Max       int64
+	Attempted int64 // what the limit would reach with this payment
+}
+
+func (e *SpendingLimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded: %d > %d", e.Limit, e.Attempted, e.Max)
+}
+
+func (e *SpendingLimitError) Is(target error) bool { return target == ErrSpendingLimitExceeded }
+
+type MerchantAccess string
+
+const (
+	MerchantAllow MerchantAccess = "allow"
+	MerchantBlock MerchantAccess = "block"
+)
+
+// MerchantRule allows or blocks one merchant, optionally with its own daily cap.
+type MerchantRule struct {
+	MerchantID string
+	Access     MerchantAccess
+	DailyLimit *money.Cents // overrides SpendingControls.MerchantDailyLimit
+}
+
+// SpendingControls are limits an account owner puts on their own spending; nil = no limit.
+// Once any merchant is allowed explicitly, only allowed merchants can be paid. Merchant
+// rules and caps only apply to merchant payments.
+type SpendingControls struct {
+	DailyLimit         *money.Cents
+	MerchantDailyLimit *money.Cents
+	MaxAttempts        *int64
+	Merchants          []MerchantRule
+}
+
+// Spend is a payment about to be charged, with what was already spent today (UTC).
+type Spend struct {
+	MerchantID           string // "" = not a merchant payment
+	Amount               money.Cents
+	SpentToday           money.Cents
+	SpentTodayAtMerchant money.Cents
+	Attempt              int64 // attempt number this payment would be
+}
+
+// Check returns ErrMerchantBlocked or a *SpendingLimitError if s breaks the controls.
+func (c SpendingControls) Check(s Spend) error {
+	if c.MaxAttempts != nil && s.Attempt > *c.MaxAttempts {
+		return &SpendingLimitError{Limit: LimitMaxAttempts, Max: *c.MaxAttempts, Attempted: s.Attempt}
+	}
+
+	merchantLimit := c.MerchantDailyLimit
+	if s.MerchantID != "" {
+		rule, ok := c.rule(s.MerchantID)
+		switch {
+		case ok && rule.Access == MerchantBlock:
+			return ErrMerchantBlocked
+		case !ok && c.hasAllowlist():
+			return ErrMerchantBlocked
+		}
+		if ok && rule.DailyLimit != nil {
+			merchantLimit = rule.DailyLimit
+		}
+	}
+
+	if c.DailyLimit != nil {
+		if err := checkCap(LimitDaily, *c.DailyLimit, s.SpentToday, s.Amount); err != nil {
+			return err
+		}
+	}
+	if s.MerchantID != "" && merchantLimit != nil {
+		if err := checkCap(LimitMerchantDaily, *merchantLimit, s.SpentTodayAtMerchant, s.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c SpendingControls) rule(merchantID string) (MerchantRule, bool) {
+	for _, r := range c.Merchants {
+		if r.MerchantID == merchantID {
+			return r, true
+		}
+	}
+	return MerchantRule{}, false
+}
+
+func (c SpendingControls) hasAllowlist() bool {
+	for _, r := range c.Merchants {
+		if r.Access == MerchantAllow {
+			return true
+		}
+	}
+	return false
+}
+
+func checkCap(limit string, max, spent, amount money.Cents) error {
+	total, err := money.Add(spent, amount)
+	if err != nil || total > max {
+		return &SpendingLimitError{Limit: limit, Max: int64(max), Attempted: int64(total)}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"gateway/internal/money"
+)
+
+func cents(c money.Cents) *money.Cents { return &c }
+
+func TestSpendingControls_Check(t *testing.T) {
+	maxAttempts := int64(3)
+	c := SpendingControls{
+		DailyLimit:         cents(100),
+		MerchantDailyLimit: cents(30),
+		MaxAttempts:        &maxAttempts,
+		Merchants: []MerchantRule{
+			{MerchantID: "coffee", Access: MerchantAllow, DailyLimit: cents(50)},
+			{MerchantID: "books", Access: MerchantAllow},
+			{MerchantID: "casino", Access: MerchantBlock},
+		},
+	}
+
+	cases := []struct {
+		name  string
+		spend Spend
+		want  error
+		limit string
+	}{
+		{"direct within limits", Spend{Amount: 10, SpentToday: 90, Attempt: 1}, nil, ""},
+		{"daily cap", Spend{Amount: 10, SpentToday: 91, Attempt: 1}, ErrSpendingLimitExceeded, LimitDaily},
+		{"max attempts", Spend{Amount: 1, Attempt: 4}, ErrSpendingLimitExceeded, LimitMaxAttempts},
+		{"blocked merchant", Spend{MerchantID: "casino", Amount: 1, Attempt: 1}, ErrMerchantBlocked, ""},
+		{"not on allowlist", Spend{MerchantID: "bar", Amount: 1, Attempt: 1}, ErrMerchantBlocked, ""},
+		{"merchant own cap", Spend{MerchantID: "coffee", Amount: 10, SpentTodayAtMerchant: 40, SpentToday: 40, Attempt: 1}, nil, ""},
+		{"merchant default cap", Spend{MerchantID: "books", Amount: 10, SpentTodayAtMerchant: 25, SpentToday: 25, Attempt: 1}, ErrSpendingLimitExceeded, LimitMerchantDaily},
+	}
+	for _, tc := range cases {
+		err := c.Check(tc.spend)
+		if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+			t.Errorf("%s: Check = %v, want %v", tc.name, err, tc.want)
+			continue
+		}
+		var le *SpendingLimitError
+		if tc.limit != "" && (!errors.As(err, &le) || le.Limit != tc.limit) {
+			t.Errorf("%s: Check = %v, want %s limit", tc.name, err, tc.limit)
+		}
+	}
+
+	if err := (SpendingControls{}).Check(Spend{MerchantID: "any", Amount: 10, Attempt: 99}); err != nil {
+		t.Fatalf("no controls: Check = %v", err)
+	}
+}
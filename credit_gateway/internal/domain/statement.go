@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"gateway/internal/money"
+)
+
+// BillingTerms decides what a statement asks the account holder to pay, and by when.
+type BillingTerms struct {
+	// MinPaymentBPS: minimum payment as a share of the closing balance
+	MinPaymentBPS money.RateBPS
+	// MinPaymentFloor: the minimum payment is never below this (unless the balance is)
+	MinPaymentFloor money.Cents
+	// DueAfterDays: days between the statement date and the payment due date
+	DueAfterDays int
+	Rounding     money.RoundingMode
+}
+
+func DefaultBillingTerms() BillingTerms {
+	return BillingTerms{
+		MinPaymentBPS:   1000, // 10%
+		MinPaymentFloor: 100,  // $1.00
+		DueAfterDays:    21,
+		Rounding:        money.RoundCeil,
+	}
+}
+
+// MinimumPayment = min(closing, max(floor, closing * MinPaymentBPS)); nothing is due on a
+// zero or credit balance.
+func (t BillingTerms) MinimumPayment(closing money.Cents) (money.Cents, error) {
+	if closing <= 0 {
+		return 0, nil
+	}
+	share, err := money.MulBPS(closing, t.MinPaymentBPS, t.Rounding)
+	if err != nil {
+		return 0, err
+	}
+	due := max(share, t.MinPaymentFloor)
+	return min(due, closing), nil
+}
+
+func (t BillingTerms) DueDate(statementDate time.Time) time.Time {
+	return statementDate.AddDate(0, 0, t.DueAfterDays)
+}
+
+// MaxStatementDay keeps every cycle closing on a day that exists in every month.
+const MaxStatementDay = 28
+
+// NextStatementDate is the first date strictly after `after` (UTC, date only) whose day of
+// month is statementDay.
+func NextStatementDate(after time.Time, statementDay int) time.Time {
+	y, m, d := after.UTC().Date()
+	if d < statementDay {
+		return time.Date(y, m, statementDay, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m+1, statementDay, 0, 0, 0, 0, time.UTC)
+}
+
+// StatementPeriod is the "YYYY-MM" label of the cycle closing on statementDate.
+func StatementPeriod(statementDate time.Time) string {
+	return statementDate.UTC().Format("2006-01")
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"gateway/internal/money"
+)
+
+func TestNextStatementDate(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		after time.Time
+		sday  int
+		want  time.Time
+	}{
+		{day(2026, 1, 3), 15, day(2026, 1, 15)},
+		{day(2026, 1, 15), 15, day(2026, 2, 15)},
+		{day(2026, 12, 20), 1, day(2027, 1, 1)},
+		{day(2026, 1, 31).Add(23 * time.Hour), 28, day(2026, 2, 28)},
+	}
+	for _, c := range cases {
+		if got := NextStatementDate(c.after, c.sday); !got.Equal(c.want) {
+			t.Errorf("NextStatementDate(%s, %d) = %s, want %s", c.after, c.sday, got, c.want)
+		}
+	}
+}
+
+func TestBillingTerms_MinimumPayment(t *testing.T) {
+	terms := DefaultBillingTerms()
+
+	cases := []struct {
+		closing money.Cents
+		want    money.Cents
+	}{
+		{0, 0},
+		{-50, 0},
+		{40, 40},    // below the floor => everything
+		{500, 100},  // 10% = 50 => floor
+		{5005, 501}, // 10% = 500.5 => ceil
+		{100000, 10000},
+	}
+	for _, c := range cases {
+		got, err := terms.MinimumPayment(c.closing)
+		if err != nil || got != c.want {
+			t.Errorf("MinimumPayment(%d) = %d, %v; want %d", c.closing, got, err, c.want)
+		}
+	}
+}
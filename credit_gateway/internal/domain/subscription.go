@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BillingInterval is how often a subscription bills its payer.
+type BillingInterval string
+
+const (
+	IntervalDay   BillingInterval = "day"
+	IntervalWeek  BillingInterval = "week"
+	IntervalMonth BillingInterval = "month"
+)
+
+func ParseBillingInterval(s string) (BillingInterval, error) {
+	switch i := BillingInterval(s); i {
+	case IntervalDay, IntervalWeek, IntervalMonth:
+		return i, nil
+	}
+	return "", fmt.Errorf("unknown billing interval %q", s)
+}
+
+// PeriodStart is when period n (0-based) of a subscription anchored at start begins.
+// Monthly periods keep start's day of month, clamped to the last day of shorter months,
+// so a subscription started on Jan 31 bills on Feb 28 and then Mar 31.
+func (i BillingInterval) PeriodStart(start time.Time, n int) time.Time {
+	switch i {
+	case IntervalDay:
+		return start.AddDate(0, 0, n)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7*n)
+	}
+	y, m, d := start.Date()
+	first := time.Date(y, m+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(d, last)-1)
+}
+
+// DunningPolicy schedules retries of a period that failed to collect: retry k waits
+// RetryAfter[k-1] after the failure before it. Once retries run out the subscription is
+// canceled.
+type DunningPolicy struct {
+	RetryAfter []time.Duration
+}
+
+func DefaultDunningPolicy() DunningPolicy {
+	return DunningPolicy{RetryAfter: []time.Duration{24 * time.Hour, 3 * 24 * time.Hour, 7 * 24 * time.Hour}}
+}
+
+// ParseDunningSchedule reads a comma-separated list of durations, e.g. "24h,72h,168h".
+func ParseDunningSchedule(s string) (DunningPolicy, error) {
+	var p DunningPolicy
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return DunningPolicy{}, err
+		}
+		if d <= 0 {
+			return DunningPolicy{}, errors.New("dunning retry delays must be positive")
+		}
+		p.RetryAfter = append(p.RetryAfter, d)
+	}
+	return p, nil
+}
+
+// NextRetry returns when to retry after the failures-th failed collection of a period,
+// or false when no retry is left.
+func (p DunningPolicy) NextRetry(failures int, failedAt time.Time) (time.Time, bool) {
+	if failures < 1 || failures > len(p.RetryAfter) {
+		return time.Time{}, false
+	}
+	return failedAt.Add(p.RetryAfter[failures-1]), true
+}
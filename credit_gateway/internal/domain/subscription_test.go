@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingInterval_PeriodStart(t *testing.T) {
+	start := time.Date(2026, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		interval BillingInterval
+		n        int
+		want     time.Time
+	}{
+		{IntervalDay, 1, time.Date(2026, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{IntervalWeek, 2, time.Date(2026, 2, 14, 9, 30, 0, 0, time.UTC)},
+		{IntervalMonth, 1, time.Date(2026, 2, 28, 9, 30, 0, 0, time.UTC)},
+		{IntervalMonth, 2, time.Date(2026, 3, 31, 9, 30, 0, 0, time.UTC)},
+		{IntervalMonth, 12, time.Date(2027, 1, 31, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		if got := tc.interval.PeriodStart(start, tc.n); !got.Equal(tc.want) {
+			t.Errorf("%s.PeriodStart(%d) = %v, want %v", tc.interval, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestDunningPolicy_NextRetry(t *testing.T) {
+	p, err := ParseDunningSchedule("24h, 72h")
+	if err != nil {
+		t.Fatalf("ParseDunningSchedule: %v", err)
+	}
+	failedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if at, ok := p.NextRetry(1, failedAt); !ok || !at.Equal(failedAt.Add(24*time.Hour)) {
+		t.Errorf("NextRetry(1) = %v, %v", at, ok)
+	}
+	if at, ok := p.NextRetry(2, failedAt); !ok || !at.Equal(failedAt.Add(72*time.Hour)) {
+		t.Errorf("NextRetry(2) = %v, %v", at, ok)
+	}
+	if _, ok := p.NextRetry(3, failedAt); ok {
+		t.Error("NextRetry(3) should be exhausted")
+	}
+	if _, err := ParseDunningSchedule("24h,-1h"); err == nil {
+		t.Error("negative delay should be rejected")
+	}
+}
@@ -0,0 +1,160 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type setInterestOverrideReq struct {
+	BaseRateBPS       *int64 `json:"base_rate_bps"`
+	StepBPSPerAttempt *int64 `json:"step_bps_per_attempt"`
+}
+
+type createPromotionReq struct {
+	RateBPS         int64      `json:"rate_bps"`
+	MaxAttemptCount *int64     `json:"max_attempt_count"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+}
+
+func promotionJSON(p domain.Promotion) map[string]any {
+	return map[string]any{
+		"id":                p.ID,
+		"rate_bps":          p.RateBPS,
+		"max_attempt_count": p.MaxAttemptCount,
+		"starts_at":         p.StartsAt,
+		"ends_at":           p.EndsAt,
+	}
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// SetOverride replaces the base rate and/or step for one account (both null removes the override).
+func (h *InterestPoliciesHandler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	var req setInterestOverrideReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	if (req.BaseRateBPS != nil && *req.BaseRateBPS < 0) || (req.StepBPSPerAttempt != nil && *req.StepBPSPerAttempt < 0) {
+		writeParamError(w, "rates", "rates must be >= 0")
+		return
+	}
+
+	o, err := repo.SetInterestOverride(r.Context(), h.DB, repo.InterestOverride{
+		AccountID:         accountID,
+		BaseRateBPS:       req.BaseRateBPS,
+		StepBPSPerAttempt: req.StepBPSPerAttempt,
+	})
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			writeNotFound(w, "id", "account not found")
+			return
+		}
+		WriteErrorCode(w, CodeInternal, "failed to set interest override", "")
+		return
+	}
+
+	p, err := repo.LoadAccountPolicy(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load interest policy", "")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"account_id":           accountID.String(),
+		"base_rate_bps":        o.BaseRateBPS,
+		"step_bps_per_attempt": o.StepBPSPerAttempt,
+		"effective_policy": map[string]any{
+			"version":              p.Version,
+			"base_rate_bps":        p.BaseRateBPS,
+			"step_bps_per_attempt": p.StepBPSPerAttempt,
+		},
+	})
+}
+
+func (h *InterestPoliciesHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	var req createPromotionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	if req.RateBPS < 0 {
+		writeParamError(w, "rate_bps", "rate_bps must be >= 0")
+		return
+	}
+	if req.MaxAttemptCount == nil && req.EndsAt == nil {
+		writeParamError(w, "max_attempt_count", "promotion needs max_attempt_count or ends_at")
+		return
+	}
+	if req.MaxAttemptCount != nil && *req.MaxAttemptCount < 0 {
+		writeParamError(w, "max_attempt_count", "max_attempt_count must be >= 0")
+		return
+	}
+
+	p := domain.Promotion{
+		RateBPS:         money.RateBPS(req.RateBPS),
+		MaxAttemptCount: req.MaxAttemptCount,
+		EndsAt:          req.EndsAt,
+	}
+	if req.StartsAt != nil {
+		p.StartsAt = *req.StartsAt
+	}
+
+	created, err := repo.CreatePromotion(r.Context(), h.DB, accountID, p)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			writeNotFound(w, "id", "account not found")
+			return
+		}
+		WriteErrorCode(w, CodeInternal, "failed to create promotion", "")
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, promotionJSON(*created))
+}
+
+func (h *InterestPoliciesHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	promos, err := repo.ListPromotions(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list promotions", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(promos))
+	for _, p := range promos {
+		out = append(out, promotionJSON(p))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
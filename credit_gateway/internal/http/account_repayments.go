@@ -0,0 +1,108 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type createRepaymentRequest struct {
+	AmountCents *int64  `json:"amount_cents"`
+	Amount      *string `json:"amount"`
+}
+
+// Repay books a repayment against the account's outstanding balance.
+func (h *AccountsHandler) Repay(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	var req createRepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	a, err := repo.GetAccountByID(r.Context(), h.DB, accountID.String())
+	if err != nil {
+		writeLookupError(w, err, "id", "account not found")
+		return
+	}
+	cents, err := resolveAmountCents(req.Amount, req.AmountCents, a.Currency)
+	if err != nil {
+		if errors.Is(err, errAmountAndCents) {
+			writeParamError(w, "amount", err.Error())
+			return
+		}
+		writeParamError(w, "amount", "invalid amount: use a plain decimal like \"0.10\"")
+		return
+	}
+
+	rp, err := repo.RecordRepayment(r.Context(), h.DB, accountID, cents)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "account not found")
+		default:
+			writeRepoError(w, err, "failed to record repayment")
+		}
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{
+		"account_id":        rp.AccountID,
+		"amount_cents":      rp.AmountCents,
+		"amount_formatted":  formatted(rp.AmountCents, rp.Currency),
+		"balance_cents":     rp.BalanceCents,
+		"balance_formatted": formatted(rp.BalanceCents, rp.Currency),
+		"attempt_count":     rp.AttemptCount,
+		"currency":          rp.Currency,
+		"created_at":        rp.CreatedAt,
+	})
+}
+
+// Delinquency shows the account's delinquency status and every transition it went through.
+func (h *AccountsHandler) Delinquency(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	a, err := repo.GetAccountByID(r.Context(), h.DB, accountID.String())
+	if err != nil {
+		writeLookupError(w, err, "id", "account not found")
+		return
+	}
+
+	ts, err := repo.ListDelinquencyTransitions(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list delinquency transitions", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(ts))
+	for _, t := range ts {
+		out = append(out, map[string]any{
+			"id":            t.ID,
+			"statement_id":  t.StatementID,
+			"from_status":   t.From,
+			"to_status":     t.To,
+			"days_past_due": t.DaysPastDue,
+			"created_at":    t.CreatedAt,
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"status":         a.DelinquencyStatus,
+		"past_due_since": dateOrNil(a.PastDueSince),
+		"transitions":    out,
+	})
+}
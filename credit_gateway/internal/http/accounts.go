@@ -2,10 +2,12 @@ package httpx
 
 import (
 	"net/http"
+	"time"
 
 	"gateway/internal/repo"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,22 +18,72 @@ type AccountsHandler struct {
 func (h *AccountsHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		WriteError(w, http.StatusBadRequest, "missing account id")
+		writeParamError(w, "id", "missing account id")
+		return
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		writeParamError(w, "id", "invalid account id")
 		return
 	}
 
 	a, err := repo.GetAccountByID(r.Context(), h.DB, id)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "account not found")
+		writeLookupError(w, err, "id", "account not found")
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, map[string]any{
 		"id":                 a.ID,
+		"currency":           a.Currency,
 		"credit_limit_cents": a.CreditLimitCents,
 		"balance_cents":      a.BalanceCents,
-		"available_cents":    a.CreditLimitCents - a.BalanceCents,
-		"attempt_count":      a.AttemptCount,
-		"spent_cents":        a.SpentCents,
+		"held_cents":         a.HeldCents,
+		"available_cents":    a.CreditLimitCents - a.BalanceCents - a.HeldCents,
+
+		"credit_limit_formatted": formatted(a.CreditLimitCents, a.Currency),
+		"balance_formatted":      formatted(a.BalanceCents, a.Currency),
+		"available_formatted":    formatted(a.CreditLimitCents-a.BalanceCents-a.HeldCents, a.Currency),
+
+		"attempt_count":   a.AttemptCount,
+		"last_attempt_at": a.LastAttemptAt,
+		"spent_cents":     a.SpentCents,
+
+		"interest_policy_version": a.InterestPolicyVersion,
+
+		"delinquency_status": a.DelinquencyStatus,
+		"past_due_since":     dateOrNil(a.PastDueSince),
 	})
 }
+
+// Accruals lists the daily interest accrued on an account.
+func (h *AccountsHandler) Accruals(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	a, err := repo.GetAccountByID(r.Context(), h.DB, accountID.String())
+	if err != nil {
+		writeLookupError(w, err, "id", "account not found")
+		return
+	}
+
+	accruals, err := repo.ListInterestAccruals(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list accruals", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(accruals))
+	for _, ac := range accruals {
+		out = append(out, map[string]any{
+			"date":               ac.AccrualDate.Format(time.DateOnly),
+			"principal_cents":    ac.PrincipalCents,
+			"rate_bps":           ac.RateBPS,
+			"interest_cents":     ac.InterestCents,
+			"interest_formatted": formatted(ac.InterestCents, a.Currency),
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
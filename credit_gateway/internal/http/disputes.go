@@ -0,0 +1,199 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DisputesHandler serves payer disputes of merchant payments.
+type DisputesHandler struct {
+	DB *pgxpool.Pool
+}
+
+type openDisputeRequest struct {
+	MerchantRequestID int64      `json:"merchant_request_id"`
+	PaymentIntentID   *uuid.UUID `json:"payment_intent_id"`
+	AmountCents       int64      `json:"amount_cents"`
+	Reason            string     `json:"reason"`
+}
+
+type disputeEvidenceRequest struct {
+	SubmittedBy string `json:"submitted_by"`
+	Description string `json:"description"`
+}
+
+type resolveDisputeRequest struct {
+	Outcome    string `json:"outcome"`
+	ResolvedBy string `json:"resolved_by"`
+}
+
+func disputeJSON(d repo.Dispute) map[string]any {
+	out := map[string]any{
+		"id":                  d.ID,
+		"merchant_request_id": d.MerchantRequestID,
+		"payment_intent_id":   d.PaymentIntentID,
+		"account_id":          d.AccountID,
+		"merchant_id":         d.MerchantID,
+		"status":              d.Status,
+		"reason":              d.Reason,
+		"amount_cents":        d.AmountCents,
+		"amount_formatted":    formatted(d.AmountCents, d.Currency),
+		"credited_cents":      d.CreditedCents,
+		"currency":            d.Currency,
+		"resolved_by":         d.ResolvedBy,
+		"resolved_at":         d.ResolvedAt,
+		"created_at":          d.CreatedAt,
+		"updated_at":          d.UpdatedAt,
+	}
+	if d.Evidence != nil {
+		evidence := make([]map[string]any, 0, len(d.Evidence))
+		for _, e := range d.Evidence {
+			evidence = append(evidence, map[string]any{
+				"id":           e.ID,
+				"submitted_by": e.SubmittedBy,
+				"description":  e.Description,
+				"created_at":   e.CreatedAt,
+			})
+		}
+		out["evidence"] = evidence
+	}
+	return out
+}
+
+// Open disputes a merchant request or one of its pay intents.
+func (h *DisputesHandler) Open(w http.ResponseWriter, r *http.Request) {
+	var req openDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	d, err := repo.OpenDispute(r.Context(), h.DB, repo.OpenDisputeInput{
+		MerchantRequestID: req.MerchantRequestID,
+		PaymentIntentID:   req.PaymentIntentID,
+		AmountCents:       req.AmountCents,
+		Reason:            req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			param := "merchant_request_id"
+			if req.PaymentIntentID != nil {
+				param = "payment_intent_id"
+			}
+			writeNotFound(w, param, "merchant payment not found")
+		default:
+			writeRepoError(w, err, "failed to open dispute")
+		}
+		return
+	}
+	WriteJSON(w, http.StatusCreated, disputeJSON(*d))
+}
+
+func (h *DisputesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := disputeID(w, r)
+	if !ok {
+		return
+	}
+	d, err := repo.GetDispute(r.Context(), h.DB, id)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, disputeJSON(*d))
+}
+
+// ListForMerchantRequest returns the disputes of a merchant request.
+func (h *DisputesHandler) ListForMerchantRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeParamError(w, "id", "invalid id")
+		return
+	}
+	disputes, err := repo.ListMerchantRequestDisputes(r.Context(), h.DB, id)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list disputes", "")
+		return
+	}
+	out := make([]map[string]any, 0, len(disputes))
+	for _, d := range disputes {
+		out = append(out, disputeJSON(d))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+func (h *DisputesHandler) RequestEvidence(w http.ResponseWriter, r *http.Request) {
+	id, ok := disputeID(w, r)
+	if !ok {
+		return
+	}
+	d, err := repo.RequestDisputeEvidence(r.Context(), h.DB, id)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, disputeJSON(*d))
+}
+
+func (h *DisputesHandler) SubmitEvidence(w http.ResponseWriter, r *http.Request) {
+	id, ok := disputeID(w, r)
+	if !ok {
+		return
+	}
+	var req disputeEvidenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	d, err := repo.SubmitDisputeEvidence(r.Context(), h.DB, id, req.SubmittedBy, req.Description)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, disputeJSON(*d))
+}
+
+func (h *DisputesHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	id, ok := disputeID(w, r)
+	if !ok {
+		return
+	}
+	var req resolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	d, err := repo.ResolveDispute(r.Context(), h.DB, id, req.Outcome, req.ResolvedBy)
+	if err != nil {
+		writeDisputeError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, disputeJSON(*d))
+}
+
+func disputeID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeParamError(w, "id", "invalid dispute id")
+		return 0, false
+	}
+	return id, true
+}
+
+func writeDisputeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeNotFound(w, "id", "dispute not found")
+	default:
+		writeRepoError(w, err, "failed to update dispute")
+	}
+}
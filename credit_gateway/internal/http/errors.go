@@ -0,0 +1,324 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+	"gateway/internal/risk"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrorCode is the stable, machine-readable part of an error response. Clients branch on
+// codes; messages are for humans and may change.
+type ErrorCode string
+
+// Generic codes, used when no more specific one applies.
+const (
+	CodeInvalidRequest   ErrorCode = "invalid_request"
+	CodeInvalidJSON      ErrorCode = "invalid_json"
+	CodeForbidden        ErrorCode = "forbidden"
+	CodeNotFound         ErrorCode = "not_found"
+	CodeResourceMissing  ErrorCode = "resource_missing"
+	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
+	CodeConflict         ErrorCode = "conflict"
+	CodeGone             ErrorCode = "gone"
+	CodeUnprocessable    ErrorCode = "unprocessable"
+	CodeInternal         ErrorCode = "internal_error"
+	CodeUnavailable      ErrorCode = "unavailable"
+)
+
+// Business codes.
+const (
+	CodeInsufficientCredit         ErrorCode = "insufficient_credit"
+	CodeAmountOverLimit            ErrorCode = "amount_over_limit"
+	CodeAccountLocked              ErrorCode = "account_locked"
+	CodeCurrencyMismatch           ErrorCode = "currency_mismatch"
+	CodePaymentIntentExpired       ErrorCode = "payment_intent_expired"
+	CodePaymentIntentNotPending    ErrorCode = "payment_intent_not_pending"
+	CodePaymentIntentNotCancelable ErrorCode = "payment_intent_not_cancelable"
+	CodePaymentIntentNotAuthorized ErrorCode = "payment_intent_not_authorized"
+	CodeHoldExpired                ErrorCode = "hold_expired"
+	CodeQuoteNotFound              ErrorCode = "quote_not_found"
+	CodeQuoteExpired               ErrorCode = "quote_expired"
+	CodeQuoteStale                 ErrorCode = "quote_stale"
+	CodeSpendingLimitExceeded      ErrorCode = "spending_limit_exceeded"
+	CodeMerchantBlocked            ErrorCode = "merchant_blocked"
+	CodeRiskDeclined               ErrorCode = "risk_declined"
+	CodeStepUpRequired             ErrorCode = "step_up_required"
+	CodeRiskDecisionNotStepUp      ErrorCode = "risk_decision_not_step_up"
+	CodeReviewNotPending           ErrorCode = "review_not_pending"
+	CodeDuplicateReference         ErrorCode = "duplicate_reference"
+	CodeMerchantRequestNotPayable  ErrorCode = "merchant_request_not_payable"
+	CodeNotMerchantRequestPayer    ErrorCode = "not_merchant_request_payer"
+	CodeInstallmentExceedsShare    ErrorCode = "installment_exceeds_share"
+	CodeRepaymentExceedsBalance    ErrorCode = "repayment_exceeds_balance"
+	CodeStatementPeriodNotOpen     ErrorCode = "statement_period_not_open"
+	CodeDisputeExceedsPaid         ErrorCode = "dispute_exceeds_paid"
+	CodePaymentNotDisputable       ErrorCode = "payment_not_disputable"
+	CodeDisputeNotOpen             ErrorCode = "dispute_not_open"
+	CodeDisputeClosed              ErrorCode = "dispute_closed"
+	CodeSubscriptionTransition     ErrorCode = "subscription_transition_not_allowed"
+	CodeSubscriptionPeriod         ErrorCode = "subscription_period"
+)
+
+type errorDef struct {
+	Status      int
+	Description string
+}
+
+// errorCatalog is every code the API returns; GET /v1/errors serves it.
+var errorCatalog = map[ErrorCode]errorDef{
+	CodeInvalidRequest:   {http.StatusBadRequest, "A parameter is missing or invalid; param names it."},
+	CodeInvalidJSON:      {http.StatusBadRequest, "The request body is not valid JSON."},
+	CodeForbidden:        {http.StatusForbidden, "The request is not allowed."},
+	CodeNotFound:         {http.StatusNotFound, "No such route."},
+	CodeResourceMissing:  {http.StatusNotFound, "The resource does not exist; param names the id that matched nothing."},
+	CodeMethodNotAllowed: {http.StatusMethodNotAllowed, "The route does not accept this method."},
+	CodeConflict:         {http.StatusConflict, "The resource's state does not allow the request."},
+	CodeGone:             {http.StatusGone, "The resource is no longer usable."},
+	CodeUnprocessable:    {http.StatusUnprocessableEntity, "The request is well-formed but cannot be applied."},
+	CodeInternal:         {http.StatusInternalServerError, "The gateway failed; retrying may help."},
+	CodeUnavailable:      {http.StatusServiceUnavailable, "A dependency is down; retry later."},
+
+	CodeInsufficientCredit:         {http.StatusPaymentRequired, "The account's available credit does not cover the payment."},
+	CodeAmountOverLimit:            {http.StatusForbidden, "Payments over 10 cents are refused, and the account is fined."},
+	CodeAccountLocked:              {http.StatusForbidden, "The account is locked and cannot pay."},
+	CodeCurrencyMismatch:           {http.StatusUnprocessableEntity, "The currency does not match the account's currency."},
+	CodePaymentIntentExpired:       {http.StatusGone, "The payment intent expired before it was confirmed."},
+	CodePaymentIntentNotPending:    {http.StatusConflict, "The payment intent is no longer pending."},
+	CodePaymentIntentNotCancelable: {http.StatusConflict, "Only pending payment intents can be canceled."},
+	CodePaymentIntentNotAuthorized: {http.StatusConflict, "The payment intent has no active authorization."},
+	CodeHoldExpired:                {http.StatusGone, "The authorization hold expired before capture."},
+	CodeQuoteNotFound:              {http.StatusNotFound, "No such quote for this payment intent."},
+	CodeQuoteExpired:               {http.StatusGone, "The quote expired; request a new one."},
+	CodeQuoteStale:                 {http.StatusConflict, "The account's attempt count moved since the quote; request a new one."},
+	CodeSpendingLimitExceeded:      {http.StatusUnprocessableEntity, "The payment would exceed one of the account's spending limits."},
+	CodeMerchantBlocked:            {http.StatusForbidden, "The account's spending controls block this merchant."},
+	CodeRiskDeclined:               {http.StatusForbidden, "The risk rules declined the payment."},
+	CodeStepUpRequired:             {http.StatusPreconditionRequired, "Complete the step-up, then confirm again."},
+	CodeRiskDecisionNotStepUp:      {http.StatusConflict, "The risk decision does not require a step-up."},
+	CodeReviewNotPending:           {http.StatusConflict, "The review was already decided."},
+	CodeDuplicateReference:         {http.StatusConflict, "The merchant already used this merchant_request_reference."},
+	CodeMerchantRequestNotPayable:  {http.StatusConflict, "The merchant request is not pending."},
+	CodeNotMerchantRequestPayer:    {http.StatusConflict, "The account is not a payer of the merchant request."},
+	CodeInstallmentExceedsShare:    {http.StatusConflict, "The pay intent is more than the payer still owes; create a new one."},
+	CodeRepaymentExceedsBalance:    {http.StatusUnprocessableEntity, "The repayment is more than the outstanding balance."},
+	CodeStatementPeriodNotOpen:     {http.StatusConflict, "The statement date is not after the previous statement."},
+	CodeDisputeExceedsPaid:         {http.StatusConflict, "The dispute is more than the undisputed amount paid."},
+	CodePaymentNotDisputable:       {http.StatusConflict, "The payment intent was not paid to the merchant."},
+	CodeDisputeNotOpen:             {http.StatusConflict, "The dispute is not open."},
+	CodeDisputeClosed:              {http.StatusConflict, "The dispute was already resolved."},
+	CodeSubscriptionTransition:     {http.StatusConflict, "The subscription's status does not allow this."},
+	CodeSubscriptionPeriod:         {http.StatusConflict, "The merchant request is a subscription period; the gateway collects it."},
+}
+
+// sentinelErrors maps repo and domain errors to codes in one place, so every handler
+// answers them the same. An empty message keeps err.Error(); param names the offending
+// request field for invalid_request.
+var sentinelErrors = []struct {
+	err     error
+	code    ErrorCode
+	param   string
+	message string
+}{
+	{repo.ErrInsufficientCredit, CodeInsufficientCredit, "", ""},
+	{repo.ErrMoreThan10Cents, CodeAmountOverLimit, "", "payment over 10 cents refused; the account was fined 10 dollars"},
+	{repo.ErrAccountLocked, CodeAccountLocked, "", ""},
+	{money.ErrCurrencyMismatch, CodeCurrencyMismatch, "currency", "currency does not match the account's currency"},
+	{repo.ErrPaymentIntentExpired, CodePaymentIntentExpired, "", ""},
+	{repo.ErrPaymentIntentNotPending, CodePaymentIntentNotPending, "", ""},
+	{repo.ErrPaymentIntentNotCancelable, CodePaymentIntentNotCancelable, "", ""},
+	{repo.ErrPaymentIntentNotAuthorized, CodePaymentIntentNotAuthorized, "", ""},
+	{repo.ErrHoldExpired, CodeHoldExpired, "", ""},
+	{repo.ErrQuoteNotFound, CodeQuoteNotFound, "quote_id", "quote not found for this payment intent"},
+	{repo.ErrQuoteExpired, CodeQuoteExpired, "quote_id", ""},
+	{repo.ErrQuoteStale, CodeQuoteStale, "quote_id", "quote stale: attempt_count moved, request a new quote"},
+	{repo.ErrMerchantBlocked, CodeMerchantBlocked, "", ""},
+	{repo.ErrRiskDecisionNotStepUp, CodeRiskDecisionNotStepUp, "", ""},
+	{repo.ErrReviewNotPending, CodeReviewNotPending, "", ""},
+	{repo.ErrDuplicateMerchantRequest, CodeDuplicateReference, "merchant_request_reference", ""},
+	{repo.ErrMerchantRequestNotPayable, CodeMerchantRequestNotPayable, "", ""},
+	{repo.ErrNotMerchantRequestPayer, CodeNotMerchantRequestPayer, "payer_account_id", ""},
+	{repo.ErrInstallmentExceedsShare, CodeInstallmentExceedsShare, "", ""},
+	{repo.ErrRepaymentExceedsBalance, CodeRepaymentExceedsBalance, "amount_cents", ""},
+	{repo.ErrStatementPeriodNotOpen, CodeStatementPeriodNotOpen, "", ""},
+	{repo.ErrDisputeExceedsPaid, CodeDisputeExceedsPaid, "amount_cents", ""},
+	{repo.ErrPaymentNotDisputable, CodePaymentNotDisputable, "payment_intent_id", ""},
+	{repo.ErrDisputeNotOpen, CodeDisputeNotOpen, "", ""},
+	{repo.ErrDisputeClosed, CodeDisputeClosed, "", ""},
+	{repo.ErrSubscriptionTransition, CodeSubscriptionTransition, "", ""},
+	{repo.ErrSubscriptionPeriod, CodeSubscriptionPeriod, "", ""},
+	{repo.ErrInterestPolicyNotFound, CodeResourceMissing, "version", ""},
+
+	{repo.ErrPayerRequired, CodeInvalidRequest, "payer_account_id", ""},
+	{repo.ErrDuplicatePayer, CodeInvalidRequest, "payers", ""},
+	{repo.ErrInvalidFeeSchedule, CodeInvalidRequest, "", ""},
+	{repo.ErrInvalidSpendingControl, CodeInvalidRequest, "", ""},
+	{repo.ErrInvalidStatementDay, CodeInvalidRequest, "statement_day", ""},
+	{repo.ErrInvalidSubscription, CodeInvalidRequest, "", ""},
+	{repo.ErrInvalidRepaymentAmount, CodeInvalidRequest, "amount_cents", ""},
+	{repo.ErrReviewerRequired, CodeInvalidRequest, "reviewer", ""},
+	{repo.ErrReviewReasonRequired, CodeInvalidRequest, "reason", ""},
+	{repo.ErrDisputeTargetRequired, CodeInvalidRequest, "merchant_request_id", ""},
+	{repo.ErrDisputeNeedsPayIntent, CodeInvalidRequest, "payment_intent_id", ""},
+	{repo.ErrDisputeReasonRequired, CodeInvalidRequest, "reason", ""},
+	{repo.ErrInvalidDisputeAmount, CodeInvalidRequest, "amount_cents", ""},
+	{repo.ErrInvalidEvidence, CodeInvalidRequest, "", ""},
+	{repo.ErrInvalidDisputeOutcome, CodeInvalidRequest, "outcome", ""},
+	{repo.ErrResolverRequired, CodeInvalidRequest, "resolved_by", ""},
+	{domain.ErrInvalidSplit, CodeInvalidRequest, "payers", ""},
+	{domain.ErrInvalidMetadata, CodeInvalidRequest, "metadata", ""},
+	{domain.ErrInvalidLineItem, CodeInvalidRequest, "line_items", ""},
+	{domain.ErrEmptyInvoice, CodeInvalidRequest, "line_items", ""},
+	{money.ErrOverflow, CodeInvalidRequest, "", "amount too large"},
+	{risk.ErrUnknownRuleKind, CodeInvalidRequest, "kind", ""},
+	{errAmountAndCents, CodeInvalidRequest, "amount", ""},
+}
+
+// APIError is the body of every error response, under "error".
+type APIError struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Param     string    `json:"param,omitempty"`
+	DocURL    string    `json:"doc_url"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// errorDocURL is where a code is documented: the catalog entry served by the gateway.
+func errorDocURL(code ErrorCode) string {
+	return "/v1/errors/" + string(code)
+}
+
+// WriteErrorCode answers with code, at the status the catalog gives it.
+func WriteErrorCode(w http.ResponseWriter, code ErrorCode, msg, param string) {
+	writeAPIError(w, errorCatalog[code].Status, code, msg, param, nil)
+}
+
+// writeParamError rejects a missing or invalid request parameter.
+func writeParamError(w http.ResponseWriter, param, msg string) {
+	WriteErrorCode(w, CodeInvalidRequest, msg, param)
+}
+
+// writeNotFound answers resource_missing; param names the id that matched nothing.
+func writeNotFound(w http.ResponseWriter, param, msg string) {
+	WriteErrorCode(w, CodeResourceMissing, msg, param)
+}
+
+// writeLookupError answers a failed load: resource_missing when there is no such row,
+// internal_error for anything else (pool exhausted, timeout, ...).
+func writeLookupError(w http.ResponseWriter, err error, param, notFound string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeNotFound(w, param, notFound)
+		return
+	}
+	WriteErrorCode(w, CodeInternal, "lookup failed", "")
+}
+
+// writeKnownError answers err if it is a cataloged sentinel (or a spending control or
+// risk refusal, which carry details) and reports whether it did.
+func writeKnownError(w http.ResponseWriter, err error) bool {
+	var (
+		le *domain.SpendingLimitError
+		de *repo.RiskDecisionError
+	)
+	switch {
+	case errors.As(err, &le):
+		writeAPIError(w, http.StatusUnprocessableEntity, CodeSpendingLimitExceeded, "spending limit exceeded", "", map[string]any{
+			"limit":     le.Limit,
+			"max":       le.Max,
+			"attempted": le.Attempted,
+		})
+		return true
+	case errors.As(err, &de) && (de.Action == risk.Decline || de.Action == risk.StepUp):
+		code, msg := CodeRiskDeclined, "declined by risk rules"
+		if de.Action == risk.StepUp {
+			code, msg = CodeStepUpRequired, "step-up required: complete it, then confirm again"
+		}
+		writeAPIError(w, errorCatalog[code].Status, code, msg, "", map[string]any{
+			"decision_id": de.DecisionID,
+			"hits":        de.Hits,
+		})
+		return true
+	}
+	for _, s := range sentinelErrors {
+		if errors.Is(err, s.err) {
+			msg := s.message
+			if msg == "" {
+				msg = err.Error()
+			}
+			WriteErrorCode(w, s.code, msg, s.param)
+			return true
+		}
+	}
+	return false
+}
+
+// writeRepoError answers a cataloged err, or internal_error with fallback for anything else.
+func writeRepoError(w http.ResponseWriter, err error, fallback string) {
+	if !writeKnownError(w, err) {
+		WriteErrorCode(w, CodeInternal, fallback, "")
+	}
+}
+
+// writeAPIError writes the error; extra fields (e.g. a risk decision id) go next to it.
+func writeAPIError(w http.ResponseWriter, status int, code ErrorCode, msg, param string, extra map[string]any) {
+	e := APIError{
+		Code:      code,
+		Message:   msg,
+		Param:     param,
+		DocURL:    errorDocURL(code),
+		RequestID: w.Header().Get(requestIDHeader),
+	}
+	if extra == nil {
+		WriteJSON(w, status, ErrorResponse{Error: e})
+		return
+	}
+	body := map[string]any{"error": e}
+	for k, v := range extra {
+		body[k] = v
+	}
+	WriteJSON(w, status, body)
+}
+
+func errorCodeJSON(code ErrorCode) map[string]any {
+	def := errorCatalog[code]
+	return map[string]any{
+		"code":        code,
+		"status":      def.Status,
+		"description": def.Description,
+	}
+}
+
+// ListErrorCodes serves the catalog, sorted by code.
+func ListErrorCodes(w http.ResponseWriter, r *http.Request) {
+	codes := make([]ErrorCode, 0, len(errorCatalog))
+	for code := range errorCatalog {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	out := make([]map[string]any, 0, len(codes))
+	for _, code := range codes {
+		out = append(out, errorCodeJSON(code))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+// GetErrorCode documents one code; it is what doc_url points at.
+func GetErrorCode(w http.ResponseWriter, r *http.Request) {
+	code := ErrorCode(chi.URLParam(r, "code"))
+	if _, ok := errorCatalog[code]; !ok {
+		writeNotFound(w, "code", "unknown error code")
+		return
+	}
+	WriteJSON(w, http.StatusOK, errorCodeJSON(code))
+}
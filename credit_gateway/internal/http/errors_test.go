@@ -0,0 +1,158 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gateway/internal/domain"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
+)
+
+type errorBody struct {
+	Error APIError `json:"error"`
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) errorBody {
+	t.Helper()
+	var body errorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return body
+}
+
+func TestWriteKnownError_SentinelsUseCatalog(t *testing.T) {
+	for _, s := range sentinelErrors {
+		def, ok := errorCatalog[s.code]
+		if !ok {
+			t.Fatalf("%v maps to %q, which is not in the catalog", s.err, s.code)
+		}
+
+		rec := httptest.NewRecorder()
+		// handlers often see the sentinel wrapped
+		if !writeKnownError(rec, fmt.Errorf("confirm: %w", s.err)) {
+			t.Fatalf("%v not recognized", s.err)
+		}
+		body := decodeError(t, rec)
+		if rec.Code != def.Status || body.Error.Code != s.code || body.Error.Param != s.param {
+			t.Errorf("%v => %d %q param=%q, want %d %q param=%q",
+				s.err, rec.Code, body.Error.Code, body.Error.Param, def.Status, s.code, s.param)
+		}
+		if body.Error.DocURL != "/v1/errors/"+string(s.code) {
+			t.Errorf("%v doc_url = %q", s.err, body.Error.DocURL)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	if writeKnownError(rec, errors.New("boom")) {
+		t.Fatalf("unknown error was written")
+	}
+	writeRepoError(rec, errors.New("boom"), "failed")
+	if body := decodeError(t, rec); rec.Code != http.StatusInternalServerError || body.Error.Code != CodeInternal {
+		t.Fatalf("repo error => %d %q, want 500 internal_error", rec.Code, body.Error.Code)
+	}
+}
+
+func TestWriteKnownError_SpendingLimitCarriesDetails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeKnownError(rec, fmt.Errorf("%w: %w", repo.ErrSpendingLimitExceeded,
+		&domain.SpendingLimitError{Limit: "daily", Max: 50, Attempted: 60}))
+
+	var body struct {
+		Error     APIError `json:"error"`
+		Limit     string   `json:"limit"`
+		Max       int64    `json:"max"`
+		Attempted int64    `json:"attempted"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusUnprocessableEntity || body.Error.Code != CodeSpendingLimitExceeded ||
+		body.Limit != "daily" || body.Max != 50 || body.Attempted != 60 {
+		t.Fatalf("spending limit => %d %+v", rec.Code, body)
+	}
+}
+
+func TestWriteLookupError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeLookupError(rec, fmt.Errorf("load: %w", pgx.ErrNoRows), "account_id", "account not found")
+	if body := decodeError(t, rec); rec.Code != http.StatusNotFound ||
+		body.Error.Code != CodeResourceMissing || body.Error.Param != "account_id" {
+		t.Fatalf("missing row => %d %+v", rec.Code, body.Error)
+	}
+
+	rec = httptest.NewRecorder()
+	writeLookupError(rec, errors.New("timeout: context deadline exceeded"), "account_id", "account not found")
+	if body := decodeError(t, rec); rec.Code != http.StatusInternalServerError || body.Error.Code != CodeInternal {
+		t.Fatalf("lookup failure => %d %+v", rec.Code, body.Error)
+	}
+}
+
+func TestWriteConfirmError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeConfirmError(rec, fmt.Errorf("lock intent: %w", pgx.ErrNoRows), "confirm failed")
+	if body := decodeError(t, rec); rec.Code != http.StatusNotFound ||
+		body.Error.Code != CodeResourceMissing || body.Error.Param != "id" {
+		t.Fatalf("missing intent => %d %+v", rec.Code, body.Error)
+	}
+
+	rec = httptest.NewRecorder()
+	writeConfirmError(rec, &repo.ReviewRequiredError{ReviewID: 7}, "confirm failed")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("review => %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	writeConfirmError(rec, repo.ErrInsufficientCredit, "confirm failed")
+	if body := decodeError(t, rec); rec.Code != http.StatusPaymentRequired || body.Error.Code != CodeInsufficientCredit {
+		t.Fatalf("insufficient credit => %d %+v", rec.Code, body.Error)
+	}
+}
+
+func TestErrorResponses_ThroughRouter(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(RequestIDHeader)
+	r.Get("/v1/errors/{code}", GetErrorCode)
+	r.Get("/fails", func(w http.ResponseWriter, r *http.Request) {
+		writeParamError(w, "amount", "invalid amount")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fails", nil))
+	body := decodeError(t, rec)
+	if rec.Code != http.StatusBadRequest || body.Error.Code != CodeInvalidRequest || body.Error.Param != "amount" {
+		t.Fatalf("param error => %d %+v", rec.Code, body.Error)
+	}
+	if body.Error.RequestID == "" || body.Error.RequestID != rec.Header().Get("X-Request-Id") {
+		t.Fatalf("request_id = %q, header = %q", body.Error.RequestID, rec.Header().Get("X-Request-Id"))
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/errors/insufficient_credit", nil))
+	var def struct {
+		Code   ErrorCode `json:"code"`
+		Status int       `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &def); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusOK || def.Code != CodeInsufficientCredit || def.Status != http.StatusPaymentRequired {
+		t.Fatalf("GET insufficient_credit => %d %+v", rec.Code, def)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/errors/no_such_code", nil))
+	if body := decodeError(t, rec); rec.Code != http.StatusNotFound ||
+		body.Error.Code != CodeResourceMissing || body.Error.Param != "code" {
+		t.Fatalf("GET unknown code => %d %+v", rec.Code, body.Error)
+	}
+}
@@ -0,0 +1,159 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InterestPoliciesHandler struct {
+	DB *pgxpool.Pool
+}
+
+type createInterestPolicyReq struct {
+	BaseRateBPS            int64      `json:"base_rate_bps"`
+	StepBPSPerAttempt      int64      `json:"step_bps_per_attempt"`
+	InvalidAmountFineCents int64      `json:"invalid_amount_fine_cents"`
+	EffectiveFrom          *time.Time `json:"effective_from"`
+
+	MaxRateBPS                   int64 `json:"max_rate_bps"`
+	AttemptDecayIntervalSeconds  int64 `json:"attempt_decay_interval_seconds"`
+	AttemptDecayPerInterval      int64 `json:"attempt_decay_per_interval"`
+	AttemptsForgivenPerRepayment int64 `json:"attempts_forgiven_per_repayment"`
+
+	// RoundingMode: floor (default), ceil, half_up or half_even
+	RoundingMode string `json:"rounding_mode"`
+}
+
+type assignInterestPolicyReq struct {
+	Version *int64 `json:"version"`
+}
+
+func interestPolicyJSON(v repo.InterestPolicyVersion) map[string]any {
+	return map[string]any{
+		"version":                   v.Policy.Version,
+		"base_rate_bps":             v.Policy.BaseRateBPS,
+		"step_bps_per_attempt":      v.Policy.StepBPSPerAttempt,
+		"invalid_amount_fine_cents": v.Policy.InvalidAmountFineCents,
+		"max_rate_bps":              v.Policy.MaxRateBPS,
+
+		"attempt_decay_interval_seconds":  int64(v.Policy.AttemptDecayInterval / time.Second),
+		"attempt_decay_per_interval":      v.Policy.AttemptDecayPerInterval,
+		"attempts_forgiven_per_repayment": v.Policy.AttemptsForgivenPerRepayment,
+		"rounding_mode":                   v.Policy.Rounding.String(),
+
+		"effective_from": v.EffectiveFrom,
+		"created_at":     v.CreatedAt,
+	}
+}
+
+func (h *InterestPoliciesHandler) List(w http.ResponseWriter, r *http.Request) {
+	versions, err := repo.ListInterestPolicies(r.Context(), h.DB)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list interest policies", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(versions))
+	for _, v := range versions {
+		out = append(out, interestPolicyJSON(v))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+func (h *InterestPoliciesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createInterestPolicyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	if req.BaseRateBPS < 0 || req.StepBPSPerAttempt < 0 || req.InvalidAmountFineCents < 0 {
+		writeParamError(w, "rates", "rates and fine must be >= 0")
+		return
+	}
+	if req.MaxRateBPS < 0 || req.AttemptDecayIntervalSeconds < 0 || req.AttemptDecayPerInterval < 0 || req.AttemptsForgivenPerRepayment < 0 {
+		writeParamError(w, "cap", "cap and decay settings must be >= 0")
+		return
+	}
+
+	rounding := money.RoundFloor
+	if req.RoundingMode != "" {
+		m, err := money.ParseRoundingMode(req.RoundingMode)
+		if err != nil {
+			writeParamError(w, "rounding_mode", "rounding_mode must be floor, ceil, half_up or half_even")
+			return
+		}
+		rounding = m
+	}
+
+	effectiveFrom := time.Now().UTC()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	v, err := repo.CreateInterestPolicy(r.Context(), h.DB, domain.InterestPolicy{
+		BaseRateBPS:            money.RateBPS(req.BaseRateBPS),
+		StepBPSPerAttempt:      money.RateBPS(req.StepBPSPerAttempt),
+		InvalidAmountFineCents: money.Cents(req.InvalidAmountFineCents),
+		MaxRateBPS:             money.RateBPS(req.MaxRateBPS),
+
+		AttemptDecayInterval:         time.Duration(req.AttemptDecayIntervalSeconds) * time.Second,
+		AttemptDecayPerInterval:      req.AttemptDecayPerInterval,
+		AttemptsForgivenPerRepayment: req.AttemptsForgivenPerRepayment,
+		Rounding:                     rounding,
+	}, effectiveFrom)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to create interest policy", "")
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, interestPolicyJSON(*v))
+}
+
+// AssignToAccount pins an account to a policy version ({"version": null} unpins it).
+func (h *InterestPoliciesHandler) AssignToAccount(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	var req assignInterestPolicyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	if err := repo.AssignInterestPolicy(r.Context(), h.DB, accountID, req.Version); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "account not found")
+		default:
+			writeRepoError(w, err, "failed to assign interest policy")
+		}
+		return
+	}
+
+	p, err := repo.LoadAccountPolicy(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load interest policy", "")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"account_id":               accountID.String(),
+		"interest_policy_version":  req.Version,
+		"effective_policy_version": p.Version,
+	})
+}
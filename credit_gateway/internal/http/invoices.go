@@ -0,0 +1,195 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// InvoicesHandler serves itemized invoices; each one is collected through the merchant
+// request it opens.
+type InvoicesHandler struct {
+	DB *pgxpool.Pool
+}
+
+type createInvoiceReq struct {
+	MerchantID              string                    `json:"merchant_id"`
+	MerchantRequestRefrence *string                   `json:"merchant_request_reference"`
+	Currency                string                    `json:"currency"` // optional, defaults to the payer's currency
+	WebhookURL              *string                   `json:"webhook_url"`
+	PayerAccountID          string                    `json:"payer_account_id"`
+	Payers                  []merchantRequestPayerReq `json:"payers"` // splits the total, as for merchant requests
+	Memo                    *string                   `json:"memo"`
+	Metadata                domain.Metadata           `json:"metadata"` // stored on the merchant request
+	LineItems               []invoiceLineItemReq      `json:"line_items"`
+}
+
+type invoiceLineItemReq struct {
+	Description string  `json:"description"`
+	Quantity    int64   `json:"quantity"`
+	UnitCents   *int64  `json:"unit_cents"`
+	UnitAmount  *string `json:"unit_amount"` // decimal alternative to unit_cents, e.g. "2.50"
+	TaxBPS      int64   `json:"tax_bps"`
+}
+
+func invoiceJSON(inv *repo.Invoice) map[string]any {
+	if inv == nil {
+		return nil
+	}
+	lines := make([]map[string]any, 0, len(inv.LineItems))
+	for _, l := range inv.LineItems {
+		lines = append(lines, map[string]any{
+			"description":     l.Description,
+			"quantity":        l.Quantity,
+			"unit_cents":      l.UnitCents,
+			"unit_formatted":  formatted(l.UnitCents, inv.Currency),
+			"tax_bps":         l.TaxBPS,
+			"subtotal_cents":  l.SubtotalCents,
+			"tax_cents":       l.TaxCents,
+			"total_cents":     l.TotalCents,
+			"total_formatted": formatted(l.TotalCents, inv.Currency),
+		})
+	}
+	return map[string]any{
+		"id":                  inv.ID,
+		"merchant_request_id": inv.MerchantRequestID,
+		"merchant_id":         inv.MerchantID,
+		"currency":            inv.Currency,
+		"memo":                inv.Memo,
+		"subtotal_cents":      inv.SubtotalCents,
+		"tax_cents":           inv.TaxCents,
+		"total_cents":         inv.TotalCents,
+		"total_formatted":     formatted(inv.TotalCents, inv.Currency),
+		"line_items":          lines,
+		"created_at":          inv.CreatedAt,
+	}
+}
+
+// Create prices the line items and opens a merchant request for the invoice total.
+func (h *InvoicesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createInvoiceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	if req.MerchantID == "" {
+		writeParamError(w, "merchant_id", "missing merchant_id")
+		return
+	}
+	if len(req.LineItems) == 0 {
+		writeParamError(w, "line_items", "missing line_items")
+		return
+	}
+	firstPayer, ok := firstPayerAccount(w, req.PayerAccountID, req.Payers)
+	if !ok {
+		return
+	}
+
+	var currency money.Currency
+	if req.Currency != "" {
+		c, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			writeParamError(w, "currency", "unsupported currency")
+			return
+		}
+		currency = c
+	}
+
+	// decimal unit amounts need the currency's minor units
+	parseIn := currency
+	for _, l := range req.LineItems {
+		if l.UnitAmount != nil && parseIn == "" {
+			a, err := repo.GetAccountByID(r.Context(), h.DB, firstPayer)
+			if err != nil {
+				writeLookupError(w, err, "payer_account_id", "payer account not found")
+				return
+			}
+			parseIn = a.Currency
+			break
+		}
+	}
+
+	items := make([]domain.LineItem, len(req.LineItems))
+	for i, l := range req.LineItems {
+		unitCents, err := resolveAmountCents(l.UnitAmount, l.UnitCents, parseIn)
+		if err != nil {
+			if errors.Is(err, errAmountAndCents) {
+				writeParamError(w, "unit_amount", "pass either unit_amount or unit_cents, not both")
+				return
+			}
+			writeParamError(w, "unit_amount", "invalid unit_amount: use a plain decimal like \"2.50\"")
+			return
+		}
+		items[i] = domain.LineItem{
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitCents:   money.Cents(unitCents),
+			TaxBPS:      money.RateBPS(l.TaxBPS),
+		}
+	}
+	totals, err := domain.PriceInvoice(items)
+	if err != nil {
+		writeRepoError(w, err, "failed to price invoice")
+		return
+	}
+
+	payers, ok := splitPayers(w, firstPayer, req.Payers, int64(totals.Total))
+	if !ok {
+		return
+	}
+
+	inv, mr, err := repo.CreateInvoice(r.Context(), h.DB, repo.CreateInvoiceInput{
+		MerchantID:               req.MerchantID,
+		MerchantRequestReference: req.MerchantRequestRefrence,
+		Payers:                   payers,
+		Currency:                 currency,
+		WebhookURL:               req.WebhookURL,
+		Memo:                     req.Memo,
+		Metadata:                 req.Metadata,
+		LineItems:                items,
+	})
+	if err != nil {
+		writeCreateMerchantRequestError(w, err)
+		return
+	}
+
+	out := invoiceJSON(inv)
+	out["merchant_request"] = map[string]any{
+		"id":                         mr.ID,
+		"merchant_request_reference": mr.MerchantRequestReference,
+		"target_cents":               mr.TargetCents,
+		"target_formatted":           formatted(mr.TargetCents, mr.Currency),
+		"status":                     mr.Status,
+		"payers":                     merchantRequestPayersJSON(mr),
+		"metadata":                   mr.Metadata,
+		"webhook_url":                mr.WebhookURL,
+	}
+	WriteJSON(w, http.StatusCreated, out)
+}
+
+func (h *InvoicesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeParamError(w, "id", "invalid invoice id")
+		return
+	}
+	inv, err := repo.GetInvoice(r.Context(), h.DB, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "id", "invoice not found")
+			return
+		}
+		WriteErrorCode(w, CodeInternal, "failed to load invoice", "")
+		return
+	}
+	WriteJSON(w, http.StatusOK, invoiceJSON(inv))
+}
@@ -2,44 +2,173 @@ package httpx
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"gateway/internal/domain"
+	"gateway/internal/money"
 	"gateway/internal/repo"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type MerchantRequestsHandler struct {
-	DB *pgxpool.Pool
+	DB        *pgxpool.Pool
+	IntentTTL time.Duration
 }
 
 type createMerchantRequestReq struct {
 	MerchantID              string  `json:"merchant_id"`
 	MerchantRequestRefrence *string `json:"merchant_request_reference"`
-	TargetCents             int64   `json:"target_cents"`
+	TargetCents             *int64  `json:"target_cents"`
+	Target                  *string `json:"target"`   // decimal alternative to target_cents, e.g. "1.00"
+	Currency                string  `json:"currency"` // optional, defaults to the payer's currency
 	WebhookURL              *string `json:"webhook_url"`
 	PayerAccountID          string  `json:"payer_account_id"`
+	// Payers splits the request: each payer owes share_cents, or a weighted share of
+	// what the fixed shares leave. Replaces payer_account_id.
+	Payers []merchantRequestPayerReq `json:"payers"`
+	// Metadata: the client's own key/value data, echoed on reads and webhooks and
+	// copied onto the request's pay intents
+	Metadata domain.Metadata `json:"metadata"`
+}
+
+type merchantRequestPayerReq struct {
+	AccountID  string `json:"account_id"`
+	ShareCents *int64 `json:"share_cents"`
+	Weight     int64  `json:"weight"`
+}
+
+func merchantRequestPayersJSON(mr *repo.MerchantRequest) []map[string]any {
+	out := make([]map[string]any, 0, len(mr.Payers))
+	for _, p := range mr.Payers {
+		out = append(out, map[string]any{
+			"account_id":        p.AccountID,
+			"share_cents":       p.ShareCents,
+			"paid_cents":        p.PaidCents,
+			"outstanding_cents": p.Outstanding(),
+		})
+	}
+	return out
+}
+
+// firstPayerAccount returns the request's first payer: payer_account_id, or the first of
+// payers (which replaces it).
+func firstPayerAccount(w http.ResponseWriter, payerAccountID string, payers []merchantRequestPayerReq) (string, bool) {
+	if len(payers) > 0 {
+		if payerAccountID != "" {
+			writeParamError(w, "payers", "pass either payer_account_id or payers, not both")
+			return "", false
+		}
+		payerAccountID = payers[0].AccountID
+	}
+	if payerAccountID == "" {
+		writeParamError(w, "payer_account_id", "missing payer_account_id")
+		return "", false
+	}
+	if _, err := uuid.Parse(payerAccountID); err != nil {
+		writeParamError(w, "payer_account_id", "invalid payer_account_id")
+		return "", false
+	}
+	return payerAccountID, true
+}
+
+// splitPayers shares targetCents among the requested payers; without payers the first
+// payer owes all of it.
+func splitPayers(w http.ResponseWriter, firstPayer string, reqPayers []merchantRequestPayerReq, targetCents int64) ([]repo.MerchantRequestPayer, bool) {
+	payers := []repo.MerchantRequestPayer{{AccountID: firstPayer, ShareCents: targetCents}}
+	if len(reqPayers) > 0 {
+		shares := make([]domain.PayerShare, len(reqPayers))
+		for i, p := range reqPayers {
+			if p.AccountID == "" {
+				writeParamError(w, "payers", "every payer needs an account_id")
+				return nil, false
+			}
+			shares[i].Weight = p.Weight
+			if p.ShareCents != nil {
+				c := money.Cents(*p.ShareCents)
+				shares[i].Fixed = &c
+			}
+		}
+		amounts, err := domain.SplitTarget(money.Cents(targetCents), shares)
+		if err != nil {
+			writeParamError(w, "payers", "each payer needs share_cents or a weight, and shares must add up to the target")
+			return nil, false
+		}
+		payers = make([]repo.MerchantRequestPayer, len(reqPayers))
+		for i, p := range reqPayers {
+			payers[i] = repo.MerchantRequestPayer{AccountID: p.AccountID, ShareCents: int64(amounts[i])}
+		}
+	}
+	return payers, true
+}
+
+func writeCreateMerchantRequestError(w http.ResponseWriter, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeNotFound(w, "payer_account_id", "payer account not found")
+		return
+	}
+	// if payer_account_id FK fails, Postgres returns 23503
+	writeRepoError(w, err, "failed to create merchant request")
 }
 
 func (h *MerchantRequestsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req createMerchantRequestReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid json")
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
 		return
 	}
 
 	if req.MerchantID == "" {
-		WriteError(w, http.StatusBadRequest, "missing merchant_id")
+		writeParamError(w, "merchant_id", "missing merchant_id")
+		return
+	}
+	firstPayer, ok := firstPayerAccount(w, req.PayerAccountID, req.Payers)
+	if !ok {
 		return
 	}
-	if req.PayerAccountID == "" {
-		WriteError(w, http.StatusBadRequest, "missing payer_account_id")
+
+	var currency money.Currency
+	if req.Currency != "" {
+		c, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			writeParamError(w, "currency", "unsupported currency")
+			return
+		}
+		currency = c
+	}
+
+	// a decimal target needs the currency's minor units
+	parseIn := currency
+	if req.Target != nil && parseIn == "" {
+		a, err := repo.GetAccountByID(r.Context(), h.DB, firstPayer)
+		if err != nil {
+			writeLookupError(w, err, "payer_account_id", "payer account not found")
+			return
+		}
+		parseIn = a.Currency
+	}
+	targetCents, err := resolveAmountCents(req.Target, req.TargetCents, parseIn)
+	if err != nil {
+		if errors.Is(err, errAmountAndCents) {
+			writeParamError(w, "target", "pass either target or target_cents, not both")
+			return
+		}
+		writeParamError(w, "target", "invalid target: use a plain decimal like \"1.00\"")
 		return
 	}
-	if req.TargetCents <= 0 {
-		WriteError(w, http.StatusBadRequest, "target_cents must be > 0")
+	if targetCents <= 0 {
+		writeParamError(w, "target_cents", "target_cents must be > 0")
+		return
+	}
+
+	payers, ok := splitPayers(w, firstPayer, req.Payers, targetCents)
+	if !ok {
 		return
 	}
 
@@ -48,17 +177,14 @@ func (h *MerchantRequestsHandler) Create(w http.ResponseWriter, r *http.Request)
 		h.DB,
 		req.MerchantID,
 		req.MerchantRequestRefrence,
-		req.PayerAccountID,
-		req.TargetCents,
+		payers,
+		targetCents,
+		currency,
 		req.WebhookURL,
+		req.Metadata,
 	)
 	if err != nil {
-		if err == repo.ErrDuplicateMerchantRequest {
-			WriteError(w, http.StatusConflict, "duplicate merchant_request_reference")
-			return
-		}
-		// if payer_account_id FK fails, Postgres returns 23503
-		WriteError(w, http.StatusInternalServerError, "failed to create merchant request")
+		writeCreateMerchantRequestError(w, err)
 		return
 	}
 
@@ -68,8 +194,15 @@ func (h *MerchantRequestsHandler) Create(w http.ResponseWriter, r *http.Request)
 		"merchant_request_reference": mr.MerchantRequestReference,
 		"payer_account_id":           mr.PayerAccountID,
 		"target_cents":               mr.TargetCents,
+		"target_formatted":           formatted(mr.TargetCents, mr.Currency),
+		"currency":                   mr.Currency,
 		"paid_cents":                 mr.PaidCents,
+		"paid_formatted":             formatted(mr.PaidCents, mr.Currency),
+		"fee_cents":                  mr.FeeCents,
+		"net_cents":                  mr.PaidCents - mr.FeeCents,
 		"status":                     mr.Status,
+		"payers":                     merchantRequestPayersJSON(mr),
+		"metadata":                   mr.Metadata,
 		"webhook_url":                mr.WebhookURL,
 	})
 }
@@ -77,19 +210,19 @@ func (h *MerchantRequestsHandler) Create(w http.ResponseWriter, r *http.Request)
 func (h *MerchantRequestsHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	if idStr == "" {
-		WriteError(w, http.StatusBadRequest, "missing merchant request id")
+		writeParamError(w, "id", "missing merchant request id")
 		return
 	}
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id <= 0 {
-		WriteError(w, http.StatusBadRequest, "invalid merchant request id")
+		writeParamError(w, "id", "invalid merchant request id")
 		return
 	}
 
 	mr, err := repo.GetMerchantRequestByID(r.Context(), h.DB, id)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "merchant request not found")
+		writeLookupError(w, err, "id", "merchant request not found")
 		return
 	}
 
@@ -98,11 +231,53 @@ func (h *MerchantRequestsHandler) GetByID(w http.ResponseWriter, r *http.Request
 		"merchant_id":                mr.MerchantID,
 		"merchant_request_reference": mr.MerchantRequestReference,
 		"target_cents":               mr.TargetCents,
+		"target_formatted":           formatted(mr.TargetCents, mr.Currency),
+		"currency":                   mr.Currency,
 		"paid_cents":                 mr.PaidCents,
+		"paid_formatted":             formatted(mr.PaidCents, mr.Currency),
+		"fee_cents":                  mr.FeeCents,
+		"net_cents":                  mr.PaidCents - mr.FeeCents,
 		"status":                     mr.Status,
+		"payers":                     merchantRequestPayersJSON(mr),
+		"metadata":                   mr.Metadata,
+		"invoice":                    invoiceJSON(mr.Invoice),
 		"webhook_url":                mr.WebhookURL,
 		"completed_at":               mr.CompletedAt,
 		"created_at":                 mr.CreatedAt,
 		"updated_at":                 mr.UpdatedAt,
 	})
 }
+
+// List returns merchant requests, newest first; filter with ?merchant_id=, ?status= and
+// ?metadata[key]=value (every pair must match).
+func (h *MerchantRequestsHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mrs, err := repo.ListMerchantRequests(r.Context(), h.DB, repo.MerchantRequestFilter{
+		MerchantID: q.Get("merchant_id"),
+		Status:     q.Get("status"),
+		Metadata:   metadataQuery(q),
+	})
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list merchant requests", "")
+		return
+	}
+	out := make([]map[string]any, 0, len(mrs))
+	for _, mr := range mrs {
+		out = append(out, map[string]any{
+			"id":                         mr.ID,
+			"merchant_id":                mr.MerchantID,
+			"merchant_request_reference": mr.MerchantRequestReference,
+			"payer_account_id":           mr.PayerAccountID,
+			"target_cents":               mr.TargetCents,
+			"target_formatted":           formatted(mr.TargetCents, mr.Currency),
+			"currency":                   mr.Currency,
+			"paid_cents":                 mr.PaidCents,
+			"paid_formatted":             formatted(mr.PaidCents, mr.Currency),
+			"status":                     mr.Status,
+			"metadata":                   mr.Metadata,
+			"completed_at":               mr.CompletedAt,
+			"created_at":                 mr.CreatedAt,
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
@@ -1,10 +1,8 @@
 package httpx
 
 import (
-	"errors"
 	"net/http"
 
-	"gateway/internal/domain"
 	"gateway/internal/repo"
 
 	"github.com/go-chi/chi/v5"
@@ -16,13 +14,28 @@ func (h *MerchantRequestsHandler) PayConfirmIntent(w http.ResponseWriter, r *htt
 	idStr := chi.URLParam(r, "id")
 	intentID, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid payment intent id")
+		writeParamError(w, "id", "invalid payment intent id")
 		return
 	}
 
+	var req confirmPaymentIntentReq
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	var quoteID *uuid.UUID
+	if req.QuoteID != nil {
+		id, err := uuid.Parse(*req.QuoteID)
+		if err != nil {
+			writeParamError(w, "quote_id", "invalid quote_id")
+			return
+		}
+		quoteID = &id
+	}
+
 	tx, err := h.DB.BeginTx(r.Context(), pgx.TxOptions{})
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to start transaction")
+		WriteErrorCode(w, CodeInternal, "failed to start transaction", "")
 		return
 	}
 	defer tx.Rollback(r.Context())
@@ -30,14 +43,18 @@ func (h *MerchantRequestsHandler) PayConfirmIntent(w http.ResponseWriter, r *htt
 	// lock mapping row to get merchant_request_id
 	mrID, err := repo.GetMerchantRequestIDByPaymentIntentForUpdate(r.Context(), tx, intentID)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "merchant pay intent not found")
+		writeLookupError(w, err, "id", "merchant pay intent not found")
 		return
 	}
 
 	// lock merchant_request
 	mr, err := repo.GetMerchantRequestByIDForUpdate(r.Context(), tx, mrID)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "merchant request not found")
+		writeLookupError(w, err, "id", "merchant request not found")
+		return
+	}
+	if mr.SubscriptionID != nil {
+		writeRepoError(w, repo.ErrSubscriptionPeriod, "payment confirm failed")
 		return
 	}
 	if mr.Status != "pending" {
@@ -49,34 +66,54 @@ func (h *MerchantRequestsHandler) PayConfirmIntent(w http.ResponseWriter, r *htt
 		return
 	}
 
+	payer, amount, err := repo.GetPaymentIntentAccountTx(r.Context(), tx, intentID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to reload payment intent", "")
+		return
+	}
+
 	// confirm payment inside same tx (idempotent on payment_intents.status)
-	err = repo.ConfirmPaymentTx(r.Context(), tx, intentID, domain.DefaultPolicy())
+	if quoteID != nil {
+		err = repo.ConfirmPaymentWithQuoteTx(r.Context(), tx, intentID, *quoteID)
+	} else {
+		err = repo.ConfirmPaymentTx(r.Context(), tx, intentID)
+	}
 	if err != nil {
-		if errors.Is(err, repo.ErrInsufficientCredit) || errors.Is(err, repo.ErrMoreThan10Cents) || errors.Is(err, repo.ErrAccountLocked) {
+		if repo.IsConfirmBusinessError(err) {
 			_ = tx.Commit(r.Context())
 
-			if errors.Is(err, repo.ErrAccountLocked) {
-				WriteError(w, http.StatusForbidden, "account locked")
-				return
-			}
-			if errors.Is(err, repo.ErrInsufficientCredit) {
-				WriteError(w, http.StatusPaymentRequired, "insufficient credit")
-				return
-			}
-			if errors.Is(err, repo.ErrMoreThan10Cents) {
-				WriteError(w, http.StatusBadRequest, "amount > 10 cents")
+			if writeReviewRequired(w, err) {
 				return
 			}
 		}
+		writeRepoError(w, err, "payment confirm failed")
+		return
+	}
 
-		WriteError(w, http.StatusInternalServerError, "payment confirm failed")
+	// a canceled (or otherwise non-succeeded) intent is a no-op for confirm,
+	// so it must never progress the merchant request
+	intentStatus, err := repo.GetPaymentIntentStatusTx(r.Context(), tx, intentID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to reload payment intent", "")
+		return
+	}
+	if intentStatus != "succeeded" {
+		WriteJSON(w, http.StatusOK, map[string]any{
+			"status":                     "not_progressed",
+			"intent_status":              intentStatus,
+			"merchant_request_id":        mrID,
+			"merchant_request_reference": mr.MerchantRequestReference,
+			"payment_intent_id":          intentID.String(),
+			"paid_cents":                 mr.PaidCents,
+			"target_cents":               mr.TargetCents,
+		})
 		return
 	}
 
 	// ✅ idempotency gate: only one confirm call can progress merchant request
 	first, err := repo.TryMarkMerchantPayProgressedTx(r.Context(), tx, intentID)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to mark merchant pay progressed")
+		WriteErrorCode(w, CodeInternal, "failed to mark merchant pay progressed", "")
 		return
 	}
 
@@ -84,20 +121,18 @@ func (h *MerchantRequestsHandler) PayConfirmIntent(w http.ResponseWriter, r *htt
 	var completedNow bool
 
 	if first {
-		paid, target, completedNow, err = repo.IncrementMerchantRequestProgress(r.Context(), tx, mrID, merchantPayAmountCents)
+		// another installment may have paid the share down since this intent was created;
+		// ErrInstallmentExceedsShare rolls the charge back with the rest of the transaction
+		paid, target, completedNow, err = repo.IncrementMerchantPayerProgress(r.Context(), tx, mrID, payer.String(), amount)
 		if err != nil {
-			if errors.Is(err, repo.ErrMerchantRequestNotPayable) {
-				WriteError(w, http.StatusConflict, "merchant request not payable")
-				return
-			}
-			WriteError(w, http.StatusInternalServerError, "failed to update merchant request")
+			writeRepoError(w, err, "failed to update merchant request")
 			return
 		}
 	} else {
 		// already progressed earlier -> just read current values
 		mr2, err := repo.GetMerchantRequestByIDForUpdate(r.Context(), tx, mrID)
 		if err != nil {
-			WriteError(w, http.StatusInternalServerError, "failed to reload merchant request")
+			WriteErrorCode(w, CodeInternal, "failed to reload merchant request", "")
 			return
 		}
 		paid = mr2.PaidCents
@@ -106,7 +141,7 @@ func (h *MerchantRequestsHandler) PayConfirmIntent(w http.ResponseWriter, r *htt
 	}
 
 	if err := tx.Commit(r.Context()); err != nil {
-		WriteError(w, http.StatusInternalServerError, "transaction commit failed")
+		WriteErrorCode(w, CodeInternal, "transaction commit failed", "")
 		return
 	}
 
@@ -11,26 +11,42 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// merchantPayAmountCents is the largest installment; the last one of a share is what is left.
 const merchantPayAmountCents = int64(10)
 
+type merchantPayReq struct {
+	// PayerAccountID picks the payer of a split request; optional with a single payer
+	PayerAccountID string `json:"payer_account_id"`
+}
+
 func (h *MerchantRequestsHandler) PayCreateIntent(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	mrID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || mrID <= 0 {
-		WriteError(w, http.StatusBadRequest, "invalid merchant request id")
+		writeParamError(w, "id", "invalid merchant request id")
+		return
+	}
+
+	var req merchantPayReq
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
 		return
 	}
 
 	tx, err := h.DB.BeginTx(r.Context(), pgx.TxOptions{})
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to start transaction")
+		WriteErrorCode(w, CodeInternal, "failed to start transaction", "")
 		return
 	}
 	defer tx.Rollback(r.Context())
 
 	mr, err := repo.GetMerchantRequestByIDForUpdate(r.Context(), tx, mrID)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "merchant request not found")
+		writeLookupError(w, err, "id", "merchant request not found")
+		return
+	}
+	if mr.SubscriptionID != nil {
+		writeRepoError(w, repo.ErrSubscriptionPeriod, "failed to create merchant pay intent")
 		return
 	}
 
@@ -53,9 +69,24 @@ func (h *MerchantRequestsHandler) PayCreateIntent(w http.ResponseWriter, r *http
 		return
 	}
 
-	accountID, err := uuid.Parse(mr.PayerAccountID)
+	payer, err := mr.FindPayer(req.PayerAccountID)
+	if err != nil {
+		writeRepoError(w, err, "failed to find payer")
+		return
+	}
+	if payer.Outstanding() == 0 {
+		WriteJSON(w, http.StatusOK, map[string]any{
+			"status":           "payer_share_fulfilled",
+			"payer_account_id": payer.AccountID,
+			"share_cents":      payer.ShareCents,
+			"paid_cents":       payer.PaidCents,
+		})
+		return
+	}
+
+	accountID, err := uuid.Parse(payer.AccountID)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "invalid payer_account_id")
+		WriteErrorCode(w, CodeInternal, "invalid payer_account_id", "")
 		return
 	}
 
@@ -64,15 +95,16 @@ func (h *MerchantRequestsHandler) PayCreateIntent(w http.ResponseWriter, r *http
 		tx,
 		mrID,
 		accountID,
-		merchantPayAmountCents,
+		min(payer.Outstanding(), merchantPayAmountCents),
+		h.IntentTTL,
 	)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to create merchant pay intent")
+		writeRepoError(w, err, "failed to create merchant pay intent")
 		return
 	}
 
 	if err := tx.Commit(r.Context()); err != nil {
-		WriteError(w, http.StatusInternalServerError, "transaction commit failed")
+		WriteErrorCode(w, CodeInternal, "transaction commit failed", "")
 		return
 	}
 
@@ -80,8 +112,13 @@ func (h *MerchantRequestsHandler) PayCreateIntent(w http.ResponseWriter, r *http
 		"status":                     "created",
 		"merchant_request_id":        mrID,
 		"merchant_request_reference": mr.MerchantRequestReference,
+		"payer_account_id":           payer.AccountID,
 		"payment_intent_id":          pi.ID.String(),
 		"amount_cents":               pi.Amount,
+		"amount_formatted":           formatted(pi.Amount, pi.Currency),
+		"currency":                   pi.Currency,
 		"intent_status":              pi.Status, // should be "pending"
+		"expires_at":                 pi.ExpiresAt,
+		"metadata":                   pi.Metadata,
 	})
 }
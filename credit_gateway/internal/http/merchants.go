@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MerchantsHandler struct {
+	DB     *pgxpool.Pool
+	Payout domain.PayoutPolicy
+}
+
+// Balance shows what the gateway owes the merchant, per currency, and what the next payout
+// run would pay.
+func (h *MerchantsHandler) Balance(w http.ResponseWriter, r *http.Request) {
+	merchantID := chi.URLParam(r, "id")
+
+	balances, err := repo.GetMerchantBalances(r.Context(), h.DB, merchantID, h.Payout)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load merchant balance", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(balances))
+	for _, b := range balances {
+		out = append(out, map[string]any{
+			"currency":          b.Currency,
+			"balance_cents":     b.BalanceCents,
+			"balance_formatted": formatted(b.BalanceCents, b.Currency),
+			"held_back_cents":   b.ImmatureCents,
+			"retained_cents":    b.RetainedCents,
+			"payable_cents":     b.PayableCents,
+			"payable_formatted": formatted(b.PayableCents, b.Currency),
+			"updated_at":        b.UpdatedAt,
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"merchant_id":      merchantID,
+		"balances":         out,
+		"holdback_seconds": int64(h.Payout.Holdback / time.Second),
+		"reserve_bps":      h.Payout.ReserveBPS,
+	})
+}
+
+func (h *MerchantsHandler) Payouts(w http.ResponseWriter, r *http.Request) {
+	merchantID := chi.URLParam(r, "id")
+
+	payouts, err := repo.ListPayouts(r.Context(), h.DB, merchantID, 100)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list payouts", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(payouts))
+	for _, p := range payouts {
+		out = append(out, map[string]any{
+			"id":               p.ID,
+			"currency":         p.Currency,
+			"amount_cents":     p.AmountCents,
+			"amount_formatted": formatted(p.AmountCents, p.Currency),
+			"retained_cents":   p.RetainedCents,
+			"created_at":       p.CreatedAt,
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+type feeScheduleJSON struct {
+	FlatCents    int64  `json:"flat_cents"`
+	RateBPS      int64  `json:"rate_bps"`
+	MinFeeCents  int64  `json:"min_fee_cents"`
+	RoundingMode string `json:"rounding_mode,omitempty"`
+}
+
+func feeScheduleResponse(merchantID string, s domain.FeeSchedule) map[string]any {
+	return map[string]any{
+		"merchant_id":   merchantID,
+		"flat_cents":    s.Flat,
+		"rate_bps":      s.RateBPS,
+		"min_fee_cents": s.MinFee,
+		"rounding_mode": s.Rounding.String(),
+	}
+}
+
+func (h *MerchantsHandler) GetFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	merchantID := chi.URLParam(r, "id")
+
+	s, err := repo.GetMerchantFeeSchedule(r.Context(), h.DB, merchantID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load fee schedule", "")
+		return
+	}
+	WriteJSON(w, http.StatusOK, feeScheduleResponse(merchantID, s))
+}
+
+// SetFeeSchedule replaces the merchant's fee schedule for future installments.
+func (h *MerchantsHandler) SetFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	merchantID := chi.URLParam(r, "id")
+
+	var req feeScheduleJSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	s := domain.FeeSchedule{
+		Flat:     money.Cents(req.FlatCents),
+		RateBPS:  money.RateBPS(req.RateBPS),
+		MinFee:   money.Cents(req.MinFeeCents),
+		Rounding: money.RoundHalfUp,
+	}
+	if req.RoundingMode != "" {
+		mode, err := money.ParseRoundingMode(req.RoundingMode)
+		if err != nil {
+			writeParamError(w, "rounding_mode", "rounding_mode must be floor, ceil, half_up or half_even")
+			return
+		}
+		s.Rounding = mode
+	}
+
+	if err := repo.SetMerchantFeeSchedule(r.Context(), h.DB, merchantID, s); err != nil {
+		writeRepoError(w, err, "failed to save fee schedule")
+		return
+	}
+	WriteJSON(w, http.StatusOK, feeScheduleResponse(merchantID, s))
+}
@@ -4,8 +4,12 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
+const requestIDHeader = "X-Request-Id"
+
 func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -15,3 +19,14 @@ func RequestLogger(next http.Handler) http.Handler {
 		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
 	})
 }
+
+// RequestIDHeader returns chi's request id to the client, so it can be quoted when
+// reporting a problem; error bodies repeat it as request_id.
+func RequestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(requestIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,91 +2,316 @@ package httpx
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"gateway/internal/domain"
+	"gateway/internal/money"
 	"gateway/internal/repo"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PaymentIntentsHandler struct {
-	DB *pgxpool.Pool
+	DB        *pgxpool.Pool
+	IntentTTL time.Duration
+	HoldTTL   time.Duration
+	QuoteTTL  time.Duration
 }
 
 type createPaymentIntentReq struct {
 	AccountID   string `json:"account_id"`
-	AmountCents int64  `json:"amount_cents"`
+	AmountCents *int64 `json:"amount_cents"`
+	// Amount: decimal alternative to AmountCents, e.g. "0.10"
+	Amount   *string `json:"amount"`
+	Currency string  `json:"currency"` // optional, must match the account's currency
+	// Metadata: the client's own key/value data, echoed on reads
+	Metadata domain.Metadata `json:"metadata"`
+}
+
+type confirmPaymentIntentReq struct {
+	QuoteID *string `json:"quote_id"`
 }
 
 func (h *PaymentIntentsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req createPaymentIntentReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid json")
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
 		return
 	}
 
 	// if req.AmountCents > 10 {
-	// 	WriteError(w, http.StatusBadRequest, "only 10 cents is allowed")
+	// 	writeParamError(w, "amount_cents", "only 10 cents is allowed")
 	// 	return
 	// }
 
 	accountID, err := uuid.Parse(req.AccountID)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid account_id")
+		writeParamError(w, "account_id", "invalid account_id")
+		return
+	}
+	if err := req.Metadata.Validate(); err != nil {
+		writeParamError(w, "metadata", err.Error())
 		return
 	}
 
-	pi, err := repo.CreatePaymentIntent(
+	var amount money.Amount
+	if req.Currency != "" {
+		c, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			writeParamError(w, "currency", "unsupported currency")
+			return
+		}
+		amount.Currency = c
+	}
+
+	// a decimal amount needs the currency's minor units
+	parseIn := amount.Currency
+	if req.Amount != nil && parseIn == "" {
+		a, err := repo.GetAccountByID(r.Context(), h.DB, accountID.String())
+		if err != nil {
+			writeLookupError(w, err, "account_id", "account not found")
+			return
+		}
+		parseIn = a.Currency
+	}
+	cents, err := resolveAmountCents(req.Amount, req.AmountCents, parseIn)
+	if err != nil {
+		if errors.Is(err, errAmountAndCents) {
+			writeParamError(w, "amount", err.Error())
+			return
+		}
+		writeParamError(w, "amount", "invalid amount: use a plain decimal like \"0.10\"")
+		return
+	}
+	amount.Value = money.Cents(cents)
+
+	pi, err := repo.CreatePaymentIntentInCurrency(
 		r.Context(),
 		h.DB,
 		accountID,
-		req.AmountCents,
+		amount,
+		h.IntentTTL,
+		req.Metadata,
 	)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to create payment_intent")
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "account_id", "account not found")
+		default:
+			writeRepoError(w, err, "failed to create payment_intent")
+		}
 		return
 	}
 
 	WriteJSON(w, http.StatusCreated, map[string]any{
-		"id":           pi.ID.String(),
-		"account_id":   pi.AccountID.String(),
-		"amount_cents": pi.Amount,
-		"status":       pi.Status,
+		"id":               pi.ID.String(),
+		"account_id":       pi.AccountID.String(),
+		"amount_cents":     pi.Amount,
+		"amount_formatted": formatted(pi.Amount, pi.Currency),
+		"currency":         pi.Currency,
+		"status":           pi.Status,
+		"expires_at":       pi.ExpiresAt,
+		"metadata":         pi.Metadata,
 	})
 }
 
+// List returns intents, newest first; filter with ?account_id=, ?status= and
+// ?metadata[key]=value (every pair must match).
+func (h *PaymentIntentsHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := repo.PaymentIntentFilter{Status: q.Get("status"), Metadata: metadataQuery(q)}
+	if s := q.Get("account_id"); s != "" {
+		accountID, err := uuid.Parse(s)
+		if err != nil {
+			writeParamError(w, "account_id", "invalid account_id")
+			return
+		}
+		f.AccountID = &accountID
+	}
+
+	intents, err := repo.ListPaymentIntents(r.Context(), h.DB, f)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list payment_intents", "")
+		return
+	}
+	out := make([]map[string]any, 0, len(intents))
+	for _, pi := range intents {
+		out = append(out, map[string]any{
+			"id":               pi.ID.String(),
+			"account_id":       pi.AccountID.String(),
+			"amount_cents":     pi.Amount,
+			"amount_formatted": formatted(pi.Amount, pi.Currency),
+			"currency":         pi.Currency,
+			"status":           pi.Status,
+			"created_at":       pi.CreatedAt,
+			"canceled_at":      pi.CanceledAt,
+			"expires_at":       pi.ExpiresAt,
+			"metadata":         pi.Metadata,
+		})
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
 func (h *PaymentIntentsHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	intentID, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid intent id")
+		writeParamError(w, "id", "invalid intent id")
 		return
 	}
 
-	err = repo.ConfirmPayment(
-		r.Context(),
-		h.DB,
-		intentID,
-		domain.DefaultPolicy(),
-	)
+	var req confirmPaymentIntentReq
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	if req.QuoteID != nil {
+		quoteID, perr := uuid.Parse(*req.QuoteID)
+		if perr != nil {
+			writeParamError(w, "quote_id", "invalid quote_id")
+			return
+		}
+		err = repo.ConfirmPaymentWithQuote(r.Context(), h.DB, intentID, quoteID)
+	} else {
+		err = repo.ConfirmPayment(
+			r.Context(),
+			h.DB,
+			intentID,
+		)
+	}
 
 	if err != nil {
-		if err == repo.ErrInsufficientCredit {
-			WriteError(w, http.StatusPaymentRequired, "insufficient credit")
+		writeConfirmError(w, err, "confirm failed")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// writeConfirmError answers a failed confirm or authorize of the intent in the path.
+func writeConfirmError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeNotFound(w, "id", "payment_intent not found")
+	case !writeReviewRequired(w, err):
+		writeRepoError(w, err, fallback)
+	}
+}
+
+func (h *PaymentIntentsHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	intentID, err := uuid.Parse(idStr)
+	if err != nil {
+		writeParamError(w, "id", "invalid intent id")
+		return
+	}
+
+	pi, err := repo.GetPaymentIntentByID(r.Context(), h.DB, intentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "id", "payment_intent not found")
 			return
-		} else if err == repo.ErrMoreThan10Cents {
-			WriteError(w, http.StatusForbidden, "Refused payment over 10 cents and 10 dollars fined")
+		}
+		WriteErrorCode(w, CodeInternal, "failed to load payment_intent", "")
+		return
+	}
+
+	charges, err := repo.GetPaymentIntentCharges(r.Context(), h.DB, intentID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load payment_intent charges", "")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"id":                  pi.ID.String(),
+		"account_id":          pi.AccountID.String(),
+		"amount_cents":        pi.Amount,
+		"amount_formatted":    formatted(pi.Amount, pi.Currency),
+		"currency":            pi.Currency,
+		"status":              pi.Status,
+		"principal_cents":     charges.PrincipalCents,
+		"interest_cents":      charges.InterestCents,
+		"penalty_cents":       charges.PenaltyCents,
+		"total_charged_cents": charges.PrincipalCents + charges.InterestCents + charges.PenaltyCents,
+		"total_charged_formatted": formatted(
+			charges.PrincipalCents+charges.InterestCents+charges.PenaltyCents, pi.Currency,
+		),
+		"created_at":  pi.CreatedAt,
+		"canceled_at": pi.CanceledAt,
+		"expires_at":  pi.ExpiresAt,
+		"metadata":    pi.Metadata,
+	})
+}
+
+func (h *PaymentIntentsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	intentID, err := uuid.Parse(idStr)
+	if err != nil {
+		writeParamError(w, "id", "invalid intent id")
+		return
+	}
+
+	pi, err := repo.CancelPaymentIntent(r.Context(), h.DB, intentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "id", "payment_intent not found")
 			return
-		} else if err == repo.ErrAccountLocked {
-			WriteError(w, http.StatusForbidden, "account locked")
+		}
+		if errors.Is(err, repo.ErrPaymentIntentNotCancelable) {
+			WriteErrorCode(w, CodePaymentIntentNotCancelable, "payment_intent is "+pi.Status+" and cannot be canceled", "")
 			return
 		}
-		WriteError(w, http.StatusInternalServerError, "confirm failed")
+		WriteErrorCode(w, CodeInternal, "cancel failed", "")
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"id":               pi.ID.String(),
+		"account_id":       pi.AccountID.String(),
+		"amount_cents":     pi.Amount,
+		"amount_formatted": formatted(pi.Amount, pi.Currency),
+		"currency":         pi.Currency,
+		"status":           pi.Status,
+		"canceled_at":      pi.CanceledAt,
+		"metadata":         pi.Metadata,
+	})
+}
+
+func (h *PaymentIntentsHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	intentID, err := uuid.Parse(idStr)
+	if err != nil {
+		writeParamError(w, "id", "invalid intent id")
+		return
+	}
+
+	q, err := repo.CreateQuote(r.Context(), h.DB, intentID, h.QuoteTTL)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "payment_intent not found")
+		default:
+			writeRepoError(w, err, "quote failed")
+		}
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, map[string]any{
+		"quote_id":             q.ID.String(),
+		"payment_intent_id":    q.PaymentIntentID.String(),
+		"attempt_count":        q.AttemptCount,
+		"rate_bps":             q.RateBPS,
+		"principal_cents":      q.PrincipalCents,
+		"interest_cents":       q.InterestCents,
+		"penalty_cents":        q.PenaltyCents,
+		"total_cents":          q.TotalCents,
+		"exceeds_credit_limit": q.ExceedsCreditLimit,
+		"expires_at":           q.ExpiresAt,
+	})
 }
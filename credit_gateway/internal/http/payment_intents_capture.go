@@ -0,0 +1,109 @@
+package httpx
+
+import (
+	"errors"
+	"net/http"
+
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (h *PaymentIntentsHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	intentID, err := uuid.Parse(idStr)
+	if err != nil {
+		writeParamError(w, "id", "invalid intent id")
+		return
+	}
+
+	err = repo.AuthorizePayment(r.Context(), h.DB, intentID, h.HoldTTL)
+	if err != nil {
+		writeConfirmError(w, err, "authorize failed")
+		return
+	}
+
+	h.writeIntentState(w, r, intentID)
+}
+
+func (h *PaymentIntentsHandler) Capture(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	intentID, err := uuid.Parse(idStr)
+	if err != nil {
+		writeParamError(w, "id", "invalid intent id")
+		return
+	}
+
+	err = repo.CapturePayment(r.Context(), h.DB, intentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "payment_intent not found")
+		default:
+			writeRepoError(w, err, "capture failed")
+		}
+		return
+	}
+
+	h.writeIntentState(w, r, intentID)
+}
+
+func (h *PaymentIntentsHandler) Void(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	intentID, err := uuid.Parse(idStr)
+	if err != nil {
+		writeParamError(w, "id", "invalid intent id")
+		return
+	}
+
+	err = repo.VoidPayment(r.Context(), h.DB, intentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "payment_intent not found")
+		default:
+			writeRepoError(w, err, "void failed")
+		}
+		return
+	}
+
+	h.writeIntentState(w, r, intentID)
+}
+
+// writeIntentState answers with the intent status and its hold, if any.
+func (h *PaymentIntentsHandler) writeIntentState(w http.ResponseWriter, r *http.Request, intentID uuid.UUID) {
+	pi, err := repo.GetPaymentIntentByID(r.Context(), h.DB, intentID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load payment_intent", "")
+		return
+	}
+
+	resp := map[string]any{
+		"id":               pi.ID.String(),
+		"account_id":       pi.AccountID.String(),
+		"amount_cents":     pi.Amount,
+		"amount_formatted": formatted(pi.Amount, pi.Currency),
+		"currency":         pi.Currency,
+		"status":           pi.Status,
+	}
+
+	hold, err := repo.GetHoldByPaymentIntent(r.Context(), h.DB, intentID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		WriteErrorCode(w, CodeInternal, "failed to load hold", "")
+		return
+	}
+	if hold != nil {
+		resp["hold"] = map[string]any{
+			"id":              hold.ID.String(),
+			"status":          hold.Status,
+			"principal_cents": hold.PrincipalCents,
+			"interest_cents":  hold.InterestCents,
+			"amount_cents":    hold.AmountCents,
+			"expires_at":      hold.ExpiresAt,
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,59 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+)
+
+// decodeOptionalJSON decodes the request body into v; an empty body leaves v untouched.
+func decodeOptionalJSON(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+var errAmountAndCents = errors.New("pass either amount or amount_cents, not both")
+
+// resolveAmountCents picks the minor-unit amount from a request that may carry either a
+// decimal string (amount: "0.10") or an integer (amount_cents: 10).
+func resolveAmountCents(amount *string, amountCents *int64, currency money.Currency) (int64, error) {
+	if amount == nil {
+		if amountCents == nil {
+			return 0, nil
+		}
+		return *amountCents, nil
+	}
+	if amountCents != nil {
+		return 0, errAmountAndCents
+	}
+	v, err := money.ParseDecimal(*amount, currency)
+	if err != nil {
+		return 0, err
+	}
+	return int64(v), nil
+}
+
+// metadataQuery collects metadata[key]=value query parameters into a filter.
+func metadataQuery(q url.Values) domain.Metadata {
+	var m domain.Metadata
+	for param, values := range q {
+		key, ok := strings.CutPrefix(param, "metadata[")
+		if !ok || !strings.HasSuffix(key, "]") || len(values) == 0 {
+			continue
+		}
+		if m == nil {
+			m = domain.Metadata{}
+		}
+		m[strings.TrimSuffix(key, "]")] = values[0]
+	}
+	return m
+}
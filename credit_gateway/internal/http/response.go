@@ -3,11 +3,10 @@ package httpx
 import (
 	"encoding/json"
 	"net/http"
-)
+	"time"
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
+	"gateway/internal/money"
+)
 
 func WriteJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -15,6 +14,15 @@ func WriteJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func WriteError(w http.ResponseWriter, status int, msg string) {
-	WriteJSON(w, status, ErrorResponse{Error: msg})
+// formatted renders minor units for dashboards, e.g. "$0.10".
+func formatted(cents int64, currency money.Currency) string {
+	return money.NewAmount(money.Cents(cents), currency).Display()
+}
+
+// dateOrNil renders an optional DATE column as "YYYY-MM-DD" (or null).
+func dateOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.DateOnly)
 }
@@ -0,0 +1,192 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gateway/internal/domain"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReviewsHandler serves the manual review queue for payments flagged by risk rules.
+type ReviewsHandler struct {
+	DB *pgxpool.Pool
+	// Pricing is the default for approvals; a request may override it.
+	Pricing domain.ReviewPricing
+}
+
+type reviewDecisionRequest struct {
+	Reviewer string `json:"reviewer"`
+	Reason   string `json:"reason"`
+	Pricing  string `json:"pricing"`
+}
+
+func reviewJSON(rv repo.PaymentReview) map[string]any {
+	out := map[string]any{
+		"id":                rv.ID,
+		"payment_intent_id": rv.PaymentIntentID,
+		"account_id":        rv.AccountID,
+		"risk_decision_id":  rv.RiskDecisionID,
+		"status":            rv.Status,
+		"amount_cents":      rv.AmountCents,
+		"amount_formatted":  formatted(rv.AmountCents, rv.Currency),
+		"currency":          rv.Currency,
+		"flagged": map[string]any{
+			"attempt_count":  rv.AttemptCount,
+			"policy_version": rv.PolicyVersion,
+			"rate_bps":       rv.RateBPS,
+			"interest_cents": rv.InterestCents,
+			"promotion_id":   rv.PromotionID,
+		},
+		"pricing":                rv.Pricing,
+		"charged_rate_bps":       rv.ChargedRateBPS,
+		"charged_interest_cents": rv.ChargedInterestCents,
+		"decided_by":             rv.DecidedBy,
+		"decision_reason":        rv.DecisionReason,
+		"decided_at":             rv.DecidedAt,
+		"created_at":             rv.CreatedAt,
+	}
+	if rv.Events != nil {
+		events := make([]map[string]any, 0, len(rv.Events))
+		for _, e := range rv.Events {
+			events = append(events, map[string]any{
+				"id":         e.ID,
+				"event":      e.Event,
+				"actor":      e.Actor,
+				"reason":     e.Reason,
+				"details":    e.Details,
+				"created_at": e.CreatedAt,
+			})
+		}
+		out["events"] = events
+	}
+	return out
+}
+
+// List returns the queue; ?status=pending (default) | approved | declined | all.
+func (h *ReviewsHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "pending"
+	case "all":
+		status = ""
+	case "pending", "approved", "declined":
+	default:
+		writeParamError(w, "status", "status must be pending, approved, declined or all")
+		return
+	}
+
+	reviews, err := repo.ListReviews(r.Context(), h.DB, status, 100)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list reviews", "")
+		return
+	}
+	out := make([]map[string]any, 0, len(reviews))
+	for _, rv := range reviews {
+		out = append(out, reviewJSON(rv))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+// Get returns one review with its audit trail.
+func (h *ReviewsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+	rv, err := repo.GetReview(r.Context(), h.DB, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "id", "review not found")
+			return
+		}
+		WriteErrorCode(w, CodeInternal, "failed to load review", "")
+		return
+	}
+	WriteJSON(w, http.StatusOK, reviewJSON(*rv))
+}
+
+func (h *ReviewsHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+	var req reviewDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	pricing := h.Pricing
+	if req.Pricing != "" {
+		p, err := domain.ParseReviewPricing(req.Pricing)
+		if err != nil {
+			writeParamError(w, "pricing", "pricing must be flagged or approval")
+			return
+		}
+		pricing = p
+	}
+
+	rv, err := repo.ApproveReview(r.Context(), h.DB, id, req.Reviewer, pricing)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, reviewJSON(*rv))
+}
+
+func (h *ReviewsHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+	var req reviewDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	rv, err := repo.DeclineReview(r.Context(), h.DB, id, req.Reviewer, req.Reason)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, reviewJSON(*rv))
+}
+
+func reviewID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeParamError(w, "id", "invalid review id")
+		return 0, false
+	}
+	return id, true
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeNotFound(w, "id", "review not found")
+	default:
+		writeRepoError(w, err, "failed to decide review")
+	}
+}
+
+// writeReviewRequired answers a confirm parked for manual review.
+func writeReviewRequired(w http.ResponseWriter, err error) bool {
+	var re *repo.ReviewRequiredError
+	if !errors.As(err, &re) {
+		return false
+	}
+	WriteJSON(w, http.StatusAccepted, map[string]any{
+		"status":    "requires_review",
+		"review_id": re.ReviewID,
+	})
+	return true
+}
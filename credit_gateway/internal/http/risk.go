@@ -0,0 +1,182 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gateway/internal/repo"
+	"gateway/internal/risk"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RiskHandler struct {
+	DB *pgxpool.Pool
+}
+
+type riskRuleJSON struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	WindowSeconds int64  `json:"window_seconds"`
+	Threshold     int64  `json:"threshold"`
+	Action        string `json:"action"`
+	Enabled       *bool  `json:"enabled"`
+}
+
+func riskRuleResponse(r risk.Rule) riskRuleJSON {
+	return riskRuleJSON{
+		ID:            r.ID,
+		Name:          r.Name,
+		Kind:          r.Kind,
+		WindowSeconds: int64(r.Window / time.Second),
+		Threshold:     r.Threshold,
+		Action:        string(r.Action),
+		Enabled:       &r.Enabled,
+	}
+}
+
+func (req riskRuleJSON) rule() risk.Rule {
+	r := risk.Rule{
+		ID:        req.ID,
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Window:    time.Duration(req.WindowSeconds) * time.Second,
+		Threshold: req.Threshold,
+		Action:    risk.Action(req.Action),
+		Enabled:   true,
+	}
+	if req.Enabled != nil {
+		r.Enabled = *req.Enabled
+	}
+	return r
+}
+
+func (h *RiskHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := repo.ListRiskRules(r.Context(), h.DB)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list risk rules", "")
+		return
+	}
+	out := make([]riskRuleJSON, 0, len(rules))
+	for _, rr := range rules {
+		out = append(out, riskRuleResponse(rr))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out, "kinds": risk.Kinds()})
+}
+
+func (h *RiskHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req riskRuleJSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	rule, err := repo.CreateRiskRule(r.Context(), h.DB, req.rule())
+	if err != nil {
+		writeRiskRuleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, riskRuleResponse(rule))
+}
+
+// UpdateRule replaces a rule's definition (e.g. to retune a threshold or disable it).
+func (h *RiskHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeParamError(w, "id", "invalid rule id")
+		return
+	}
+	var req riskRuleJSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	req.ID = id
+
+	rule, err := repo.UpdateRiskRule(r.Context(), h.DB, req.rule())
+	if err != nil {
+		writeRiskRuleError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, riskRuleResponse(rule))
+}
+
+func writeRiskRuleError(w http.ResponseWriter, err error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeNotFound(w, "id", "risk rule not found")
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		WriteErrorCode(w, CodeConflict, "a risk rule with this name already exists", "name")
+	case errors.As(err, &pgErr):
+		WriteErrorCode(w, CodeInternal, "failed to save risk rule", "")
+	case writeKnownError(w, err):
+	default:
+		// rule validation
+		WriteErrorCode(w, CodeInvalidRequest, err.Error(), "")
+	}
+}
+
+func riskDecisionJSON(d repo.RiskDecision) map[string]any {
+	return map[string]any{
+		"id":                   d.ID,
+		"account_id":           d.AccountID,
+		"payment_intent_id":    d.PaymentIntentID,
+		"merchant_id":          d.MerchantID,
+		"amount_cents":         d.AmountCents,
+		"action":               d.Action,
+		"hits":                 d.Hits,
+		"step_up_completed_at": d.StepUpCompletedAt,
+		"created_at":           d.CreatedAt,
+	}
+}
+
+// ListAccountDecisions lists an account's risk decisions; ?hits=true skips clean allows.
+func (h *RiskHandler) ListAccountDecisions(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	decisions, err := repo.ListRiskDecisions(r.Context(), h.DB, accountID, r.URL.Query().Get("hits") == "true", 100)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list risk decisions", "")
+		return
+	}
+	out := make([]map[string]any, 0, len(decisions))
+	for _, d := range decisions {
+		out = append(out, riskDecisionJSON(d))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+// CompleteStepUp records that the account owner passed the step-up challenge; the intent
+// can then be confirmed again.
+func (h *RiskHandler) CompleteStepUp(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeParamError(w, "id", "invalid decision id")
+		return
+	}
+
+	d, err := repo.CompleteStepUp(r.Context(), h.DB, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "risk decision not found")
+		default:
+			writeRepoError(w, err, "failed to complete step-up")
+		}
+		return
+	}
+	WriteJSON(w, http.StatusOK, riskDecisionJSON(*d))
+}
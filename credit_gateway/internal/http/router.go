@@ -6,20 +6,30 @@ import (
 	"net/http"
 	"time"
 
+	"gateway/internal/config"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewRouter(db *pgxpool.Pool) http.Handler {
+func NewRouter(db *pgxpool.Pool, cfg *config.Config) http.Handler {
 	r := chi.NewRouter()
 
 	// middleware (keep it sane)
 	r.Use(middleware.RequestID)
+	r.Use(RequestIDHeader)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
 	r.Use(RequestLogger)
 
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		WriteErrorCode(w, CodeNotFound, "no such route", "")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		WriteErrorCode(w, CodeMethodNotAllowed, "method not allowed", "")
+	})
+
 	// health
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
@@ -30,7 +40,7 @@ func NewRouter(db *pgxpool.Pool) http.Handler {
 		defer cancel()
 
 		if err := db.Ping(ctx); err != nil {
-			WriteError(w, http.StatusServiceUnavailable, "db not ready")
+			WriteErrorCode(w, CodeUnavailable, "db not ready", "")
 			return
 		}
 
@@ -38,15 +48,79 @@ func NewRouter(db *pgxpool.Pool) http.Handler {
 	})
 
 	r.Route("/v1", func(r chi.Router) {
+		r.Get("/errors", ListErrorCodes)
+		r.Get("/errors/{code}", GetErrorCode)
+
 		h := &AccountsHandler{DB: db}
 		r.Get("/accounts/{id}", h.GetByID)
+		r.Get("/accounts/{id}/accruals", h.Accruals)
+		r.Post("/accounts/{id}/repayments", h.Repay)
+		r.Get("/accounts/{id}/delinquency", h.Delinquency)
+		r.Get("/accounts/{id}/spending_controls", h.GetSpendingControls)
+		r.Put("/accounts/{id}/spending_controls", h.SetSpendingControls)
+
+		sth := &StatementsHandler{DB: db}
+		r.Put("/accounts/{id}/statement_day", sth.SetStatementDay)
+		r.Get("/accounts/{id}/statements", sth.List)
+		r.Get("/accounts/{id}/statements/{period}", sth.Get)
+
+		rh := &RiskHandler{DB: db}
+		r.Get("/risk_rules", rh.ListRules)
+		r.Post("/risk_rules", rh.CreateRule)
+		r.Put("/risk_rules/{id}", rh.UpdateRule)
+		r.Get("/accounts/{id}/risk_decisions", rh.ListAccountDecisions)
+		r.Post("/risk_decisions/{id}/step_up", rh.CompleteStepUp)
 
-		pi := &PaymentIntentsHandler{DB: db}
+		mh := &MerchantsHandler{DB: db, Payout: cfg.Payout}
+		r.Get("/merchants/{id}/balance", mh.Balance)
+		r.Get("/merchants/{id}/payouts", mh.Payouts)
+		r.Get("/merchants/{id}/fee_schedule", mh.GetFeeSchedule)
+		r.Put("/merchants/{id}/fee_schedule", mh.SetFeeSchedule)
+
+		rvh := &ReviewsHandler{DB: db, Pricing: cfg.ReviewPricing}
+		r.Get("/reviews", rvh.List)
+		r.Get("/reviews/{id}", rvh.Get)
+		r.Post("/reviews/{id}/approve", rvh.Approve)
+		r.Post("/reviews/{id}/decline", rvh.Decline)
+
+		subh := &SubscriptionsHandler{DB: db}
+		r.Post("/subscriptions", subh.Create)
+		r.Get("/subscriptions/{id}", subh.Get)
+		r.Post("/subscriptions/{id}/pause", subh.Pause)
+		r.Post("/subscriptions/{id}/resume", subh.Resume)
+		r.Post("/subscriptions/{id}/cancel", subh.Cancel)
+		r.Get("/merchants/{id}/subscriptions", subh.ListForMerchant)
+
+		dh := &DisputesHandler{DB: db}
+		r.Post("/disputes", dh.Open)
+		r.Get("/disputes/{id}", dh.Get)
+		r.Post("/disputes/{id}/request_evidence", dh.RequestEvidence)
+		r.Post("/disputes/{id}/evidence", dh.SubmitEvidence)
+		r.Post("/disputes/{id}/resolve", dh.Resolve)
+		r.Get("/merchant_requests/{id}/disputes", dh.ListForMerchantRequest)
+
+		iph := &InterestPoliciesHandler{DB: db}
+		r.Get("/interest_policies", iph.List)
+		r.Post("/interest_policies", iph.Create)
+		r.Put("/accounts/{id}/interest_policy", iph.AssignToAccount)
+		r.Put("/accounts/{id}/interest_override", iph.SetOverride)
+		r.Get("/accounts/{id}/promotions", iph.ListPromotions)
+		r.Post("/accounts/{id}/promotions", iph.CreatePromotion)
+
+		pi := &PaymentIntentsHandler{DB: db, IntentTTL: cfg.PaymentIntentTTL, HoldTTL: cfg.HoldTTL, QuoteTTL: cfg.QuoteTTL}
 		r.Post("/payment_intents", pi.Create)
+		r.Get("/payment_intents", pi.List)
+		r.Get("/payment_intents/{id}", pi.GetByID)
+		r.Post("/payment_intents/{id}/quote", pi.Quote)
 		r.Post("/payment_intents/{id}/confirm", pi.Confirm)
+		r.Post("/payment_intents/{id}/cancel", pi.Cancel)
+		r.Post("/payment_intents/{id}/authorize", pi.Authorize)
+		r.Post("/payment_intents/{id}/capture", pi.Capture)
+		r.Post("/payment_intents/{id}/void", pi.Void)
 
-		mrh := &MerchantRequestsHandler{DB: db}
+		mrh := &MerchantRequestsHandler{DB: db, IntentTTL: cfg.PaymentIntentTTL}
 		r.Post("/merchant_requests", mrh.Create)
+		r.Get("/merchant_requests", mrh.List)
 		r.Get("/merchant_requests/{id}", mrh.GetByID)
 		// r.Post("/merchant_requests/{id}/pay", mrh.Pay)
 
@@ -55,6 +129,10 @@ func NewRouter(db *pgxpool.Pool) http.Handler {
 		// confirm merchant-payment intent
 		r.Post("/merchant_requests/payment_intents/{id}/confirm", mrh.PayConfirmIntent)
 
+		ih := &InvoicesHandler{DB: db}
+		r.Post("/invoices", ih.Create)
+		r.Get("/invoices/{id}", ih.Get)
+
 	})
 	return r
 }
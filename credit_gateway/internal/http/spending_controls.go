@@ -0,0 +1,100 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type merchantRuleJSON struct {
+	MerchantID      string       `json:"merchant_id"`
+	Access          string       `json:"access"`
+	DailyLimitCents *money.Cents `json:"daily_limit_cents"`
+}
+
+type spendingControlsJSON struct {
+	DailyLimitCents         *money.Cents       `json:"daily_limit_cents"`
+	MerchantDailyLimitCents *money.Cents       `json:"merchant_daily_limit_cents"`
+	MaxAttempts             *int64             `json:"max_attempts"`
+	Merchants               []merchantRuleJSON `json:"merchants"`
+}
+
+func spendingControlsResponse(c domain.SpendingControls) spendingControlsJSON {
+	out := spendingControlsJSON{
+		DailyLimitCents:         c.DailyLimit,
+		MerchantDailyLimitCents: c.MerchantDailyLimit,
+		MaxAttempts:             c.MaxAttempts,
+		Merchants:               make([]merchantRuleJSON, 0, len(c.Merchants)),
+	}
+	for _, r := range c.Merchants {
+		out.Merchants = append(out.Merchants, merchantRuleJSON{
+			MerchantID:      r.MerchantID,
+			Access:          string(r.Access),
+			DailyLimitCents: r.DailyLimit,
+		})
+	}
+	return out
+}
+
+func (h *AccountsHandler) GetSpendingControls(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+	if _, err := repo.GetAccountByID(r.Context(), h.DB, accountID.String()); err != nil {
+		writeLookupError(w, err, "id", "account not found")
+		return
+	}
+
+	c, err := repo.GetSpendingControls(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to load spending controls", "")
+		return
+	}
+	WriteJSON(w, http.StatusOK, spendingControlsResponse(c))
+}
+
+// SetSpendingControls replaces the account's limits and merchant allow/block list.
+func (h *AccountsHandler) SetSpendingControls(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	var req spendingControlsJSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	c := domain.SpendingControls{
+		DailyLimit:         req.DailyLimitCents,
+		MerchantDailyLimit: req.MerchantDailyLimitCents,
+		MaxAttempts:        req.MaxAttempts,
+	}
+	for _, m := range req.Merchants {
+		c.Merchants = append(c.Merchants, domain.MerchantRule{
+			MerchantID: m.MerchantID,
+			Access:     domain.MerchantAccess(m.Access),
+			DailyLimit: m.DailyLimitCents,
+		})
+	}
+
+	if err := repo.SetSpendingControls(r.Context(), h.DB, accountID, c); err != nil {
+		switch {
+		case isForeignKeyViolation(err):
+			writeNotFound(w, "id", "account not found")
+		default:
+			writeRepoError(w, err, "failed to save spending controls")
+		}
+		return
+	}
+	WriteJSON(w, http.StatusOK, spendingControlsResponse(c))
+}
@@ -0,0 +1,209 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StatementsHandler struct {
+	DB *pgxpool.Pool
+}
+
+type setStatementDayReq struct {
+	StatementDay int `json:"statement_day"`
+}
+
+func statementJSON(st repo.Statement) map[string]any {
+	return map[string]any{
+		"id":                        st.ID,
+		"account_id":                st.AccountID.String(),
+		"period":                    st.Period,
+		"period_start":              st.PeriodStart.Format(time.DateOnly),
+		"period_end":                st.PeriodEnd.Format(time.DateOnly),
+		"currency":                  st.Currency,
+		"opening_balance_cents":     st.OpeningBalanceCents,
+		"closing_balance_cents":     st.ClosingBalanceCents,
+		"minimum_payment_cents":     st.MinimumPaymentCents,
+		"opening_balance_formatted": formatted(st.OpeningBalanceCents, st.Currency),
+		"closing_balance_formatted": formatted(st.ClosingBalanceCents, st.Currency),
+		"minimum_payment_formatted": formatted(st.MinimumPaymentCents, st.Currency),
+		"due_date":                  st.DueDate.Format(time.DateOnly),
+		"created_at":                st.CreatedAt,
+	}
+}
+
+func (h *StatementsHandler) List(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	sts, err := repo.ListStatements(r.Context(), h.DB, accountID)
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list statements", "")
+		return
+	}
+
+	out := make([]map[string]any, 0, len(sts))
+	for _, st := range sts {
+		out = append(out, statementJSON(st))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+// Get returns one period as JSON, or as an export with ?format=csv|text.
+func (h *StatementsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+	period := chi.URLParam(r, "period")
+	if _, err := time.Parse("2006-01", period); err != nil {
+		writeParamError(w, "period", "period must be YYYY-MM")
+		return
+	}
+
+	st, err := repo.GetStatement(r.Context(), h.DB, accountID, period)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "period", "statement not found")
+			return
+		}
+		WriteErrorCode(w, CodeInternal, "failed to load statement", "")
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%s-%s", st.AccountID, st.Period)
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "csv":
+		body, err := renderStatementCSV(st)
+		if err != nil {
+			WriteErrorCode(w, CodeInternal, "failed to render statement", "")
+			return
+		}
+		writeExport(w, "text/csv; charset=utf-8", filename+".csv", body)
+		return
+	case "text":
+		writeExport(w, "text/plain; charset=utf-8", filename+".txt", renderStatementText(st))
+		return
+	default:
+		writeParamError(w, "format", "format must be json, csv or text")
+		return
+	}
+
+	resp := statementJSON(*st)
+	entries := make([]map[string]any, 0, len(st.Entries))
+	for _, e := range st.Entries {
+		entries = append(entries, map[string]any{
+			"ledger_entry_id":   e.LedgerEntryID.String(),
+			"payment_intent_id": e.PaymentIntentID,
+			"entry_type":        e.EntryType,
+			"amount_cents":      e.AmountCents,
+			"amount_formatted":  formatted(e.AmountCents, st.Currency),
+			"posted_at":         e.PostedAt,
+		})
+	}
+	resp["entries"] = entries
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *StatementsHandler) SetStatementDay(w http.ResponseWriter, r *http.Request) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeParamError(w, "id", "invalid account id")
+		return
+	}
+
+	var req setStatementDayReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+
+	if err := repo.SetStatementDay(r.Context(), h.DB, accountID, req.StatementDay); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "id", "account not found")
+		default:
+			writeRepoError(w, err, "failed to set statement day")
+		}
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"account_id":    accountID.String(),
+		"statement_day": req.StatementDay,
+	})
+}
+
+func writeExport(w http.ResponseWriter, contentType, filename string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// renderStatementCSV writes one row per ledger entry, bracketed by opening and closing rows.
+func renderStatementCSV(st *repo.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"date", "type", "payment_intent_id", "amount_cents", "amount", "currency"},
+		{st.PeriodStart.Format(time.DateOnly), "opening_balance", "", strconv.FormatInt(st.OpeningBalanceCents, 10), formatted(st.OpeningBalanceCents, st.Currency), string(st.Currency)},
+	}
+	for _, e := range st.Entries {
+		intent := ""
+		if e.PaymentIntentID != nil {
+			intent = e.PaymentIntentID.String()
+		}
+		rows = append(rows, []string{
+			e.PostedAt.UTC().Format(time.RFC3339), e.EntryType, intent,
+			strconv.FormatInt(e.AmountCents, 10), formatted(e.AmountCents, st.Currency), string(st.Currency),
+		})
+	}
+	rows = append(rows,
+		[]string{st.PeriodEnd.Format(time.DateOnly), "closing_balance", "", strconv.FormatInt(st.ClosingBalanceCents, 10), formatted(st.ClosingBalanceCents, st.Currency), string(st.Currency)},
+		[]string{st.DueDate.Format(time.DateOnly), "minimum_payment_due", "", strconv.FormatInt(st.MinimumPaymentCents, 10), formatted(st.MinimumPaymentCents, st.Currency), string(st.Currency)},
+	)
+
+	if err := cw.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderStatementText(st *repo.Statement) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Statement %s for account %s\n", st.Period, st.AccountID)
+	fmt.Fprintf(&buf, "Period: %s to %s (exclusive)\n\n", st.PeriodStart.Format(time.DateOnly), st.PeriodEnd.Format(time.DateOnly))
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Opening balance\t%s\t\n", formatted(st.OpeningBalanceCents, st.Currency))
+	for _, e := range st.Entries {
+		fmt.Fprintf(tw, "%s  %s\t%s\t\n", e.PostedAt.UTC().Format(time.DateOnly), e.EntryType, formatted(e.AmountCents, st.Currency))
+	}
+	fmt.Fprintf(tw, "Closing balance\t%s\t\n", formatted(st.ClosingBalanceCents, st.Currency))
+	_ = tw.Flush()
+
+	fmt.Fprintf(&buf, "\nMinimum payment due: %s by %s\n",
+		formatted(st.MinimumPaymentCents, st.Currency), st.DueDate.Format(time.DateOnly))
+	return buf.Bytes()
+}
@@ -0,0 +1,207 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+	"gateway/internal/repo"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SubscriptionsHandler serves recurring merchant billing of a payer.
+type SubscriptionsHandler struct {
+	DB *pgxpool.Pool
+}
+
+type createSubscriptionReq struct {
+	MerchantID     string     `json:"merchant_id"`
+	PayerAccountID string     `json:"payer_account_id"`
+	AmountCents    *int64     `json:"amount_cents"`
+	Amount         *string    `json:"amount"`
+	Currency       string     `json:"currency"`
+	Interval       string     `json:"interval"`
+	StartsAt       *time.Time `json:"starts_at"` // default now
+	EndsAt         *time.Time `json:"ends_at"`
+	WebhookURL     string     `json:"webhook_url"`
+}
+
+func subscriptionJSON(s repo.Subscription) map[string]any {
+	return map[string]any{
+		"id":                          s.ID,
+		"merchant_id":                 s.MerchantID,
+		"payer_account_id":            s.PayerAccountID,
+		"amount_cents":                s.AmountCents,
+		"amount_formatted":            formatted(s.AmountCents, s.Currency),
+		"currency":                    s.Currency,
+		"interval":                    s.Interval,
+		"starts_at":                   s.StartsAt,
+		"ends_at":                     s.EndsAt,
+		"webhook_url":                 s.WebhookURL,
+		"status":                      s.Status,
+		"periods":                     s.Periods,
+		"next_period_at":              s.NextPeriodAt,
+		"current_merchant_request_id": s.CurrentMerchantRequestID,
+		"failed_attempts":             s.FailedAttempts,
+		"next_attempt_at":             s.NextAttemptAt,
+		"last_error":                  s.LastError,
+		"canceled_at":                 s.CanceledAt,
+		"created_at":                  s.CreatedAt,
+		"updated_at":                  s.UpdatedAt,
+	}
+}
+
+func (h *SubscriptionsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createSubscriptionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorCode(w, CodeInvalidJSON, "invalid json", "")
+		return
+	}
+	payer, err := uuid.Parse(req.PayerAccountID)
+	if err != nil {
+		writeParamError(w, "payer_account_id", "invalid payer_account_id")
+		return
+	}
+	interval, err := domain.ParseBillingInterval(req.Interval)
+	if err != nil {
+		writeParamError(w, "interval", "interval must be day, week or month")
+		return
+	}
+
+	var currency money.Currency
+	if req.Currency != "" {
+		if currency, err = money.ParseCurrency(req.Currency); err != nil {
+			writeParamError(w, "currency", "unsupported currency")
+			return
+		}
+	}
+	// a decimal amount needs the currency's minor units
+	parseIn := currency
+	if req.Amount != nil && parseIn == "" {
+		a, err := repo.GetAccountByID(r.Context(), h.DB, payer.String())
+		if err != nil {
+			writeLookupError(w, err, "payer_account_id", "payer account not found")
+			return
+		}
+		parseIn = a.Currency
+	}
+	amountCents, err := resolveAmountCents(req.Amount, req.AmountCents, parseIn)
+	if err != nil {
+		if errors.Is(err, errAmountAndCents) {
+			writeParamError(w, "amount", err.Error())
+			return
+		}
+		writeParamError(w, "amount", "invalid amount: use a plain decimal like \"1.00\"")
+		return
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+
+	s, err := repo.CreateSubscription(r.Context(), h.DB, repo.CreateSubscriptionInput{
+		MerchantID:     req.MerchantID,
+		PayerAccountID: payer,
+		AmountCents:    amountCents,
+		Currency:       currency,
+		Interval:       interval,
+		StartsAt:       startsAt,
+		EndsAt:         req.EndsAt,
+		WebhookURL:     req.WebhookURL,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "payer_account_id", "payer account not found")
+		default:
+			writeRepoError(w, err, "failed to create subscription")
+		}
+		return
+	}
+	WriteJSON(w, http.StatusCreated, subscriptionJSON(*s))
+}
+
+func (h *SubscriptionsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+	s, err := repo.GetSubscription(r.Context(), h.DB, id)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, subscriptionJSON(*s))
+}
+
+// ListForMerchant returns a merchant's subscriptions.
+func (h *SubscriptionsHandler) ListForMerchant(w http.ResponseWriter, r *http.Request) {
+	subs, err := repo.ListMerchantSubscriptions(r.Context(), h.DB, chi.URLParam(r, "id"))
+	if err != nil {
+		WriteErrorCode(w, CodeInternal, "failed to list subscriptions", "")
+		return
+	}
+	out := make([]map[string]any, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, subscriptionJSON(s))
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+func (h *SubscriptionsHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(id int64) (*repo.Subscription, error) {
+		return repo.PauseSubscription(r.Context(), h.DB, id)
+	})
+}
+
+func (h *SubscriptionsHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(id int64) (*repo.Subscription, error) {
+		return repo.ResumeSubscription(r.Context(), h.DB, id, time.Now())
+	})
+}
+
+func (h *SubscriptionsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, func(id int64) (*repo.Subscription, error) {
+		return repo.CancelSubscription(r.Context(), h.DB, id, time.Now())
+	})
+}
+
+func (h *SubscriptionsHandler) transition(w http.ResponseWriter, r *http.Request, apply func(id int64) (*repo.Subscription, error)) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+	s, err := apply(id)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, subscriptionJSON(*s))
+}
+
+func subscriptionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeParamError(w, "id", "invalid subscription id")
+		return 0, false
+	}
+	return id, true
+}
+
+func writeSubscriptionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeNotFound(w, "id", "subscription not found")
+	default:
+		writeRepoError(w, err, "failed to update subscription")
+	}
+}
@@ -0,0 +1,173 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+var ErrOverflow = errors.New("money: arithmetic overflow")
+
+// Quantity is any int64-backed money quantity (Cents, RateBPS).
+type Quantity interface {
+	~int64
+}
+
+// Add returns a + b, or ErrOverflow if the result does not fit in int64.
+func Add[T Quantity](a, b T) (T, error) {
+	s := a + b
+	if (b > 0 && s < a) || (b < 0 && s > a) {
+		return 0, ErrOverflow
+	}
+	return s, nil
+}
+
+// Sub returns a - b, or ErrOverflow if the result does not fit in int64.
+func Sub[T Quantity](a, b T) (T, error) {
+	d := a - b
+	if (b > 0 && d > a) || (b < 0 && d < a) {
+		return 0, ErrOverflow
+	}
+	return d, nil
+}
+
+// Mul returns a * n, or ErrOverflow if the result does not fit in int64.
+func Mul[T Quantity](a T, n int64) (T, error) {
+	q, err := MulDiv(int64(a), n, 1, RoundFloor)
+	return T(q), err
+}
+
+// MulDiv returns a*b/den rounded with mode. The product is computed in 128 bits,
+// so only a quotient outside int64 is an overflow. den must be > 0.
+func MulDiv(a, b, den int64, mode RoundingMode) (int64, error) {
+	if den <= 0 {
+		return 0, errors.New("money: non-positive divisor")
+	}
+	neg := (a < 0) != (b < 0)
+	hi, lo := bits.Mul64(absU(a), absU(b))
+	d := uint64(den)
+	if hi >= d {
+		return 0, ErrOverflow
+	}
+	q, r := bits.Div64(hi, lo, d)
+	return roundQuotient(q, r, d, neg, mode)
+}
+
+// DivRound returns num/den rounded with mode. den must be > 0.
+func DivRound(num, den int64, mode RoundingMode) int64 {
+	q, _ := MulDiv(num, 1, den, mode) // |num/den| <= |num| cannot overflow
+	return q
+}
+
+// MulBPS applies a basis-point rate to c: round(c * rate / 10000).
+func MulBPS(c Cents, rate RateBPS, mode RoundingMode) (Cents, error) {
+	q, err := MulDiv(int64(c), int64(rate), int64(BPSDenominator), mode)
+	return Cents(q), err
+}
+
+func absU(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1 // safe for MinInt64
+	}
+	return uint64(v)
+}
+
+// roundQuotient applies mode to the magnitude q remainder r (of den), then the sign.
+func roundQuotient(q, r, den uint64, neg bool, mode RoundingMode) (int64, error) {
+	if r != 0 {
+		up := false
+		switch mode {
+		case RoundCeil:
+			up = !neg
+		case RoundHalfUp:
+			up = r >= den-r
+		case RoundHalfEven:
+			up = r > den-r || (r == den-r && q%2 != 0)
+		default: // RoundFloor
+			up = neg
+		}
+		if up {
+			q++
+			if q == 0 {
+				return 0, ErrOverflow
+			}
+		}
+	}
+
+	if neg && q != 0 {
+		if q > uint64(math.MaxInt64)+1 {
+			return 0, ErrOverflow
+		}
+		return -int64(q-1) - 1, nil
+	}
+	if q > math.MaxInt64 {
+		return 0, ErrOverflow
+	}
+	return int64(q), nil
+}
+
+// Allocate splits total across len(ratios) parts proportionally, without losing cents:
+// every part gets the floor of its share, and the leftover cents go one each to the
+// parts with the largest remainders (earlier parts win ties). Ratios must be >= 0 with
+// a positive sum.
+func Allocate(total Cents, ratios []int64) ([]Cents, error) {
+	var sum int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, errors.New("money: negative allocation ratio")
+		}
+		var err error
+		if sum, err = Add(sum, r); err != nil {
+			return nil, err
+		}
+	}
+	if sum == 0 {
+		return nil, errors.New("money: allocation ratios sum to zero")
+	}
+
+	neg := total < 0
+	t := absU(int64(total))
+
+	parts := make([]uint64, len(ratios))
+	rems := make([]uint64, len(ratios))
+	var given uint64
+	for i, r := range ratios {
+		// t*r/sum <= t, so the quotient always fits
+		hi, lo := bits.Mul64(t, uint64(r))
+		parts[i], rems[i] = bits.Div64(hi, lo, uint64(sum))
+		given += parts[i]
+	}
+
+	for left := t - given; left > 0; left-- {
+		best := -1
+		for i := range rems {
+			if ratios[i] > 0 && (best < 0 || rems[i] > rems[best]) {
+				best = i
+			}
+		}
+		parts[best]++
+		rems[best] = 0
+	}
+
+	out := make([]Cents, len(parts))
+	for i, p := range parts {
+		v, err := roundQuotient(p, 0, 1, neg, RoundFloor)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = Cents(v)
+	}
+	return out, nil
+}
+
+// Split divides total into n near-equal parts (see Allocate).
+func Split(total Cents, n int) ([]Cents, error) {
+	if n <= 0 {
+		return nil, errors.New("money: split into non-positive number of parts")
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return Allocate(total, ratios)
+}
@@ -0,0 +1,88 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func TestAddSubOverflow(t *testing.T) {
+	if _, err := Add(Cents(math.MaxInt64), 1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Add overflow err = %v", err)
+	}
+	if _, err := Sub(Cents(math.MinInt64), 1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Sub overflow err = %v", err)
+	}
+	if got, err := Add(Cents(40), -2); err != nil || got != 38 {
+		t.Fatalf("Add = %d, %v", got, err)
+	}
+	if got, err := Sub(RateBPS(100), 250); err != nil || got != -150 {
+		t.Fatalf("Sub = %d, %v", got, err)
+	}
+	if _, err := Mul(RateBPS(math.MaxInt64/2), 3); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Mul overflow err = %v", err)
+	}
+}
+
+func TestMulBPS(t *testing.T) {
+	// the product overflows int64 but the quotient does not
+	got, err := MulBPS(Cents(math.MaxInt64/2), 10000, RoundFloor)
+	if err != nil || got != Cents(math.MaxInt64/2) {
+		t.Fatalf("MulBPS(max/2, 100%%) = %d, %v", got, err)
+	}
+	if _, err := MulBPS(Cents(math.MaxInt64/2), 30000, RoundFloor); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("MulBPS overflow err = %v", err)
+	}
+	if got, _ := MulBPS(Cents(-10), 10100, RoundFloor); got != -11 {
+		t.Fatalf("MulBPS(-10, 101%%) = %d, want -11", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	got, err := Split(Cents(100), 3)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	if got[0] != 34 || got[1] != 33 || got[2] != 33 {
+		t.Fatalf("Split(100, 3) = %v", got)
+	}
+
+	got, err = Allocate(Cents(-5), []int64{1, 1})
+	if err != nil || got[0] != -3 || got[1] != -2 {
+		t.Fatalf("Allocate(-5, 1:1) = %v, %v", got, err)
+	}
+
+	got, err = Allocate(Cents(10), []int64{0, 3, 7})
+	if err != nil || got[0] != 0 || got[1] != 3 || got[2] != 7 {
+		t.Fatalf("Allocate(10, 0:3:7) = %v, %v", got, err)
+	}
+
+	if _, err := Allocate(Cents(10), []int64{0, 0}); err == nil {
+		t.Fatal("expected error for zero ratios")
+	}
+}
+
+func TestAllocate_PropertyNoCentLost(t *testing.T) {
+	f := func(total int64, raw []uint16) bool {
+		ratios := make([]int64, 0, len(raw)+1)
+		for _, r := range raw {
+			ratios = append(ratios, int64(r))
+		}
+		ratios = append(ratios, 1) // non-zero sum
+		parts, err := Allocate(Cents(total), ratios)
+		if err != nil {
+			return false
+		}
+		var sum Cents
+		for _, p := range parts {
+			if sum, err = Add(sum, p); err != nil {
+				return false
+			}
+		}
+		return sum == Cents(total)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,97 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// Currency is an ISO-4217 alphabetic code, e.g. "USD".
+type Currency string
+
+const USD Currency = "USD"
+
+// minorUnits is the ISO-4217 exponent: digits after the decimal point.
+// Cents values are always counted in the currency's minor unit (JPY has none).
+var minorUnits = map[Currency]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"SEK": 2,
+	"NOK": 2,
+	"DKK": 2,
+	"PLN": 2,
+	"MXN": 2,
+	"BRL": 2,
+	"INR": 2,
+	"CNY": 2,
+	"SGD": 2,
+	"HKD": 2,
+	"AED": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"ISK": 0,
+	"CLP": 0,
+	"VND": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+}
+
+// ParseCurrency validates an ISO-4217 code against the supported set (case-insensitive).
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := minorUnits[c]; !ok {
+		return "", fmt.Errorf("money: unsupported currency %q", code)
+	}
+	return c, nil
+}
+
+// MinorUnits is the number of decimal places of c (2 for unknown codes).
+func (c Currency) MinorUnits() int {
+	if n, ok := minorUnits[c]; ok {
+		return n
+	}
+	return 2
+}
+
+// Amount is a value in the minor unit of its currency.
+type Amount struct {
+	Value    Cents
+	Currency Currency
+}
+
+func NewAmount(value Cents, currency Currency) Amount {
+	return Amount{Value: value, Currency: currency}
+}
+
+// Add returns a + b; both must be in the same currency.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, ErrCurrencyMismatch
+	}
+	v, err := Add(a.Value, b.Value)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Value: v, Currency: a.Currency}, nil
+}
+
+// Sub returns a - b; both must be in the same currency.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, ErrCurrencyMismatch
+	}
+	v, err := Sub(a.Value, b.Value)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Value: v, Currency: a.Currency}, nil
+}
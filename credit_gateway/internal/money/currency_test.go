@@ -0,0 +1,35 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCurrencyMinorUnits(t *testing.T) {
+	cases := map[string]int{"usd": 2, "JPY": 0, "BHD": 3}
+	for code, want := range cases {
+		c, err := ParseCurrency(code)
+		if err != nil {
+			t.Fatalf("ParseCurrency(%q): %v", code, err)
+		}
+		if got := c.MinorUnits(); got != want {
+			t.Fatalf("%s minor units = %d, want %d", c, got, want)
+		}
+	}
+	if _, err := ParseCurrency("XXX"); err == nil {
+		t.Fatal("expected error for unsupported currency")
+	}
+}
+
+func TestAmountAddRequiresSameCurrency(t *testing.T) {
+	sum, err := NewAmount(150, "JPY").Add(NewAmount(50, "JPY"))
+	if err != nil || sum.Value != 200 || sum.Currency != "JPY" {
+		t.Fatalf("Add = %+v, %v", sum, err)
+	}
+	if _, err := NewAmount(1, USD).Add(NewAmount(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("mismatch err = %v", err)
+	}
+	if _, err := NewAmount(1, USD).Sub(NewAmount(1, "BHD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("mismatch err = %v", err)
+	}
+}
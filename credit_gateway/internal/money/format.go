@@ -0,0 +1,104 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("money: invalid decimal amount")
+
+// symbols are only used for display; unknown currencies fall back to the code.
+var symbols = map[Currency]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"INR": "₹",
+	"KRW": "₩",
+}
+
+// decimal renders |c| with the currency's minor units, e.g. 123 USD => "1.23", 5 JPY => "5".
+func decimal(c Cents, currency Currency) (sign, digits string) {
+	v := int64(c)
+	var u uint64
+	if v < 0 {
+		sign = "-"
+		u = absU(v)
+	} else {
+		u = uint64(v)
+	}
+
+	s := strconv.FormatUint(u, 10)
+	n := currency.MinorUnits()
+	if n == 0 {
+		return sign, s
+	}
+	if len(s) <= n {
+		s = strings.Repeat("0", n-len(s)+1) + s
+	}
+	return sign, s[:len(s)-n] + "." + s[len(s)-n:]
+}
+
+// String is the locale-independent code form: "1.23 USD", "-5 JPY".
+func (a Amount) String() string {
+	sign, digits := decimal(a.Value, a.Currency)
+	return sign + digits + " " + string(a.Currency)
+}
+
+// Display is the symbol form for dashboards: "$1.23", "-¥5". Currencies without a
+// symbol use the code form.
+func (a Amount) Display() string {
+	sym, ok := symbols[a.Currency]
+	if !ok {
+		return a.String()
+	}
+	sign, digits := decimal(a.Value, a.Currency)
+	return sign + sym + digits
+}
+
+// ParseDecimal strictly parses a plain decimal string ("0.10", "-12", "1.5") into minor
+// units of currency, without floats. Signs other than a leading '-', grouping separators,
+// exponents and more fraction digits than the currency has are rejected.
+func ParseDecimal(s string, currency Currency) (Cents, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+
+	neg := strings.HasPrefix(s, "-")
+	body := strings.TrimPrefix(s, "-")
+
+	whole, frac, hasPoint := strings.Cut(body, ".")
+	n := currency.MinorUnits()
+	if whole == "" || !allDigits(whole) {
+		return 0, invalid
+	}
+	if hasPoint && (frac == "" || !allDigits(frac) || len(frac) > n) {
+		return 0, invalid
+	}
+	frac += strings.Repeat("0", n-len(frac))
+
+	u, err := strconv.ParseUint(whole+frac, 10, 64)
+	if err != nil {
+		return 0, ErrOverflow
+	}
+	v, err := roundQuotient(u, 0, 1, neg, RoundFloor)
+	return Cents(v), err
+}
+
+// ParseAmount is ParseDecimal tagged with its currency.
+func ParseAmount(s string, currency Currency) (Amount, error) {
+	v, err := ParseDecimal(s, currency)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Value: v, Currency: currency}, nil
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAmountFormatting(t *testing.T) {
+	cases := []struct {
+		a       Amount
+		code    string
+		display string
+	}{
+		{NewAmount(123, USD), "1.23 USD", "$1.23"},
+		{NewAmount(10, USD), "0.10 USD", "$0.10"},
+		{NewAmount(-5, USD), "-0.05 USD", "-$0.05"},
+		{NewAmount(500, "JPY"), "500 JPY", "¥500"},
+		{NewAmount(1234, "BHD"), "1.234 BHD", "1.234 BHD"},
+		{NewAmount(7, "BHD"), "0.007 BHD", "0.007 BHD"},
+	}
+	for _, c := range cases {
+		if got := c.a.String(); got != c.code {
+			t.Errorf("String(%d %s) = %q, want %q", c.a.Value, c.a.Currency, got, c.code)
+		}
+		if got := c.a.Display(); got != c.display {
+			t.Errorf("Display(%d %s) = %q, want %q", c.a.Value, c.a.Currency, got, c.display)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	ok := []struct {
+		s    string
+		cur  Currency
+		want Cents
+	}{
+		{"0.10", USD, 10},
+		{"0.1", USD, 10},
+		{"12", USD, 1200},
+		{"-1.05", USD, -105},
+		{"500", "JPY", 500},
+		{"1.234", "BHD", 1234},
+		{"0.01", "BHD", 10},
+	}
+	for _, c := range ok {
+		got, err := ParseDecimal(c.s, c.cur)
+		if err != nil || got != c.want {
+			t.Errorf("ParseDecimal(%q, %s) = %d, %v; want %d", c.s, c.cur, got, err, c.want)
+		}
+	}
+
+	bad := []struct {
+		s   string
+		cur Currency
+	}{
+		{"", USD}, {"-", USD}, {".5", USD}, {"1.", USD}, {"0.105", USD}, {"1,000", USD},
+		{"+1", USD}, {"1e2", USD}, {" 1", USD}, {"$1", USD}, {"1.5", "JPY"}, {"--1", USD},
+	}
+	for _, c := range bad {
+		if _, err := ParseDecimal(c.s, c.cur); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseDecimal(%q, %s) err = %v, want ErrInvalidAmount", c.s, c.cur, err)
+		}
+	}
+
+	if _, err := ParseDecimal("99999999999999999999", USD); !errors.Is(err, ErrOverflow) {
+		t.Errorf("overflow err = %v", err)
+	}
+}
+
+func TestParseFormatRoundTrip(t *testing.T) {
+	for _, a := range []Amount{NewAmount(0, USD), NewAmount(-987654321, USD), NewAmount(42, "JPY"), NewAmount(1001, "KWD")} {
+		sign, digits := decimal(a.Value, a.Currency)
+		got, err := ParseAmount(sign+digits, a.Currency)
+		if err != nil || got != a {
+			t.Errorf("round trip %v => %v, %v", a, got, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package money
+
+import "fmt"
+
+// RoundingMode decides what happens to the fraction of a cent left by a division.
+// The zero value is RoundFloor, the original integer-division behaviour.
+type RoundingMode int
+
+const (
+	RoundFloor    RoundingMode = iota // toward -inf
+	RoundCeil                         // toward +inf
+	RoundHalfUp                       // nearest, ties away from zero
+	RoundHalfEven                     // nearest, ties to the even cent (banker's)
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundFloor:    "floor",
+	RoundCeil:     "ceil",
+	RoundHalfUp:   "half_up",
+	RoundHalfEven: "half_even",
+}
+
+func (m RoundingMode) String() string {
+	if s, ok := roundingModeNames[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// ParseRoundingMode is the inverse of String.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	for m, name := range roundingModeNames {
+		if name == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode %q", s)
+}
@@ -0,0 +1,41 @@
+package money
+
+import "testing"
+
+func TestDivRound(t *testing.T) {
+	cases := []struct {
+		num, den int64
+		mode     RoundingMode
+		want     int64
+	}{
+		{101, 10, RoundFloor, 10},
+		{101, 10, RoundCeil, 11},
+		{-101, 10, RoundFloor, -11},
+		{-101, 10, RoundCeil, -10},
+		{105, 10, RoundHalfUp, 11},
+		{104, 10, RoundHalfUp, 10},
+		{-105, 10, RoundHalfUp, -11},
+		{105, 10, RoundHalfEven, 10},
+		{115, 10, RoundHalfEven, 12},
+		{116, 10, RoundHalfEven, 12},
+		{-125, 10, RoundHalfEven, -12},
+		{100, 10, RoundCeil, 10},
+	}
+	for _, c := range cases {
+		if got := DivRound(c.num, c.den, c.mode); got != c.want {
+			t.Errorf("DivRound(%d, %d, %s) = %d, want %d", c.num, c.den, c.mode, got, c.want)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for m := RoundFloor; m <= RoundHalfEven; m++ {
+		got, err := ParseRoundingMode(m.String())
+		if err != nil || got != m {
+			t.Fatalf("ParseRoundingMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseRoundingMode("truncate"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
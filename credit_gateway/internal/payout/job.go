@@ -0,0 +1,75 @@
+package payout
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/repo"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job pays merchants on a schedule: every run batches each merchant's payable balance
+// (after holdback and reserve, per Policy) into one payout per currency.
+type Job struct {
+	DB     *pgxpool.Pool
+	Policy domain.PayoutPolicy
+
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+func NewJob(db *pgxpool.Pool) *Job {
+	return &Job{
+		DB:           db,
+		Policy:       domain.DefaultPayoutPolicy(),
+		PollInterval: 24 * time.Hour,
+		BatchSize:    100,
+	}
+}
+
+func (j *Job) Run(ctx context.Context) {
+	if err := j.RunOnce(ctx); err != nil {
+		log.Printf("payout: %v", err)
+	}
+
+	t := time.NewTicker(j.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := j.RunOnce(ctx); err != nil {
+				log.Printf("payout: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce creates the payouts due now and returns after one pass over all balances.
+func (j *Job) RunOnce(ctx context.Context) error {
+	var after repo.MerchantBalanceKey
+	for {
+		keys, err := repo.ListPositiveMerchantBalances(ctx, j.DB, after, j.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			p, err := repo.CreatePayout(ctx, j.DB, k.MerchantID, k.Currency, j.Policy)
+			if err != nil {
+				return err
+			}
+			if p != nil {
+				log.Printf("payout: %d %s to merchant %s (payout %d)", p.AmountCents, p.Currency, p.MerchantID, p.ID)
+			}
+			after = k
+		}
+		if len(keys) < j.BatchSize {
+			return nil
+		}
+	}
+}
@@ -2,28 +2,41 @@ package repo
 
 import (
 	"context"
+	"time"
+
+	"gateway/internal/money"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Account struct {
 	ID               string
+	Currency         money.Currency
 	CreditLimitCents int64
 	BalanceCents     int64
 	AttemptCount     int64
+	LastAttemptAt    *time.Time
 	SpentCents       int64
+	HeldCents        int64
+	// InterestPolicyVersion is the pinned policy version (nil = follow the version in effect).
+	InterestPolicyVersion *int64
+	// DelinquencyStatus: current | late | delinquent | charged_off
+	DelinquencyStatus string
+	PastDueSince      *time.Time
 }
 
 func GetAccountByID(ctx context.Context, db *pgxpool.Pool, id string) (*Account, error) {
 	const q = `
-SELECT id, credit_limit_cents, balance_cents, attempt_count, spent_cents
+SELECT id, currency, credit_limit_cents, balance_cents, attempt_count, last_attempt_at, spent_cents,
+  COALESCE((SELECT sum(h.amount_cents) FROM holds h WHERE h.account_id = accounts.id AND h.status = 'active'), 0),
+  interest_policy_version, delinquency_status, past_due_since
 FROM accounts
 WHERE id = $1
 `
 	row := db.QueryRow(ctx, q, id)
 
 	var a Account
-	if err := row.Scan(&a.ID, &a.CreditLimitCents, &a.BalanceCents, &a.AttemptCount, &a.SpentCents); err != nil {
+	if err := row.Scan(&a.ID, &a.Currency, &a.CreditLimitCents, &a.BalanceCents, &a.AttemptCount, &a.LastAttemptAt, &a.SpentCents, &a.HeldCents, &a.InterestPolicyVersion, &a.DelinquencyStatus, &a.PastDueSince); err != nil {
 		return nil, err
 	}
 
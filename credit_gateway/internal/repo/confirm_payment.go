@@ -4,8 +4,6 @@ import (
 	"context"
 	"errors"
 
-	"gateway/internal/domain"
-
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,20 +13,51 @@ var ErrInsufficientCredit = errors.New("insufficient credit")
 
 var ErrMoreThan10Cents = errors.New("Refused payment over 10 cents and 10 dollars fined")
 
+// IsConfirmBusinessError reports whether err is a business outcome of a confirm
+// whose side effects (refused/expired status, lock, penalty) must be committed.
+func IsConfirmBusinessError(err error) bool {
+	return errors.Is(err, ErrInsufficientCredit) ||
+		errors.Is(err, ErrMoreThan10Cents) ||
+		errors.Is(err, ErrAccountLocked) ||
+		errors.Is(err, ErrPaymentIntentExpired) ||
+		errors.Is(err, ErrSpendingLimitExceeded) ||
+		errors.Is(err, ErrMerchantBlocked) ||
+		errors.Is(err, ErrRiskDeclined) ||
+		errors.Is(err, ErrStepUpRequired) ||
+		errors.Is(err, ErrPaymentRequiresReview)
+}
+
 func ConfirmPayment(
 	ctx context.Context,
 	db *pgxpool.Pool,
 	intentID uuid.UUID,
-	policy domain.InterestPolicy,
 ) error {
+	return runConfirmTx(ctx, db, func(tx pgx.Tx) error {
+		return ConfirmPaymentTx(ctx, tx, intentID)
+	})
+}
+
+func ConfirmPaymentWithQuote(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	intentID uuid.UUID,
+	quoteID uuid.UUID,
+) error {
+	return runConfirmTx(ctx, db, func(tx pgx.Tx) error {
+		return ConfirmPaymentWithQuoteTx(ctx, tx, intentID, quoteID)
+	})
+}
+
+// runConfirmTx runs confirm in its own transaction, committing business outcomes too.
+func runConfirmTx(ctx context.Context, db *pgxpool.Pool, confirm func(pgx.Tx) error) error {
 	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
-	err = ConfirmPaymentTx(ctx, tx, intentID, policy)
+	err = confirm(tx)
 	if err != nil {
-		if errors.Is(err, ErrInsufficientCredit) || errors.Is(err, ErrMoreThan10Cents) || errors.Is(err, ErrAccountLocked) {
+		if IsConfirmBusinessError(err) {
 			// keep the lock / penalty / refused status
 			if commitErr := tx.Commit(ctx); commitErr != nil {
 				return commitErr
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"gateway/internal/domain"
 	"gateway/internal/money"
+	"gateway/internal/risk"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -14,81 +16,269 @@ import (
 
 var ErrAccountLocked = errors.New("account locked")
 
-func ConfirmPaymentTx(
-	ctx context.Context,
-	tx pgx.Tx,
-	intentID uuid.UUID,
-	policy domain.InterestPolicy,
-) error {
+// lockedIntent is a payment intent joined with its account, read FOR UPDATE.
+type lockedIntent struct {
+	IntentID      uuid.UUID
+	AccountID     uuid.UUID
+	AmountCents   int64
+	Status        string
+	AttemptCount  int64
+	LastAttemptAt *time.Time
+	SpentCents    int64
+	CreditLimit   int64
+	BalanceCents  int64
+	HeldCents     int64
+	AccountStatus string
+	Expired       bool
+	// MerchantID: set when the intent pays a merchant request
+	MerchantID *string
+	// Collector: the gateway's subscription collector is confirming (see confirmInstallmentTx)
+	Collector bool
+	// Risk: the decision recorded while pricing (set by priceIntentTx)
+	Risk *RiskDecision
+}
 
-	var (
-		accountID     uuid.UUID
-		amountCents   int64
-		intentStatus  string
-		attemptCount  int64
-		spentCents    int64
-		creditLimit   int64
-		balanceCents  int64
-		accountStatus string
-	)
+// flaggedForReview reports whether the risk rules sent this confirm to manual review.
+func (li *lockedIntent) flaggedForReview() bool {
+	return li.Risk != nil && li.Risk.Action == risk.Review
+}
 
+// lockIntentTx locks the intent and its account. Active holds are summed in a second
+// statement: a subquery next to FOR UPDATE would read the snapshot taken before the lock
+// was granted and miss holds placed by the transaction we waited on.
+func lockIntentTx(ctx context.Context, tx pgx.Tx, intentID uuid.UUID) (*lockedIntent, error) {
 	const q = `
 select
   pi.account_id, pi.amount_cents, pi.status,
-  a.attempt_count, a.spent_cents, a.credit_limit_cents, a.balance_cents, a.status
+  a.attempt_count, a.last_attempt_at, a.spent_cents, a.credit_limit_cents, a.balance_cents, a.status,
+  (pi.expires_at is not null and pi.expires_at <= now()),
+  (select mr.merchant_id
+     from merchant_pay_intents mpi
+     join merchant_requests mr on mr.id = mpi.merchant_request_id
+    where mpi.payment_intent_id = pi.id)
 from payment_intents pi
 join accounts a on a.id = pi.account_id
 where pi.id = $1
 for update
 `
+	li := lockedIntent{IntentID: intentID}
 	if err := tx.QueryRow(ctx, q, intentID).Scan(
-		&accountID,
-		&amountCents,
-		&intentStatus,
-		&attemptCount,
-		&spentCents,
-		&creditLimit,
-		&balanceCents,
-		&accountStatus,
+		&li.AccountID,
+		&li.AmountCents,
+		&li.Status,
+		&li.AttemptCount,
+		&li.LastAttemptAt,
+		&li.SpentCents,
+		&li.CreditLimit,
+		&li.BalanceCents,
+		&li.AccountStatus,
+		&li.Expired,
+		&li.MerchantID,
 	); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(ctx,
+		`select coalesce(sum(amount_cents), 0) from holds where account_id = $1 and status = 'active'`,
+		li.AccountID,
+	).Scan(&li.HeldCents); err != nil {
+		return nil, err
+	}
+	return &li, nil
+}
+
+// ConfirmPaymentTx charges a pending intent using the account's interest policy
+// (pinned version, or the version in effect). Non-pending intents are a no-op.
+func ConfirmPaymentTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	intentID uuid.UUID,
+) error {
+	return confirmIntentTx(ctx, tx, intentID, false)
+}
+
+// confirmInstallmentTx confirms an installment the subscription collector just created.
+// Only collectInstallment calls it; see priceIntentTx for what it skips.
+func confirmInstallmentTx(ctx context.Context, tx pgx.Tx, intentID uuid.UUID) error {
+	return confirmIntentTx(ctx, tx, intentID, true)
+}
+
+func confirmIntentTx(ctx context.Context, tx pgx.Tx, intentID uuid.UUID, collector bool) error {
+	li, err := lockIntentTx(ctx, tx, intentID)
+	if err != nil {
 		return err
 	}
+	li.Collector = collector
 
 	// idempotency: only process pending
-	if intentStatus != "pending" {
+	if li.Status != "pending" {
 		return nil
 	}
 
-	// account lock check
-	if accountStatus != "active" {
-		_ = RefusePaymentIntentTx(ctx, tx, intentID)
-		return ErrAccountLocked
+	policy, err := LoadAccountPolicy(ctx, tx, li.AccountID)
+	if err != nil {
+		return err
+	}
+
+	charge, err := priceIntentTx(ctx, tx, li, policy, nil)
+	if err != nil {
+		return err
+	}
+	if li.flaggedForReview() {
+		return parkForReviewTx(ctx, tx, li, policy.Version, charge)
+	}
+
+	return postChargeTx(ctx, tx, li.AccountID, intentID, money.Cents(li.AmountCents), charge, policy.Version)
+}
+
+// ConfirmPaymentWithQuoteTx confirms at the interest locked by a quote.
+// The quote must belong to the intent, be unexpired and unused, and neither the account's
+// attempt_count nor its policy version may have moved since it was issued.
+func ConfirmPaymentWithQuoteTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	intentID uuid.UUID,
+	quoteID uuid.UUID,
+) error {
+
+	li, err := lockIntentTx(ctx, tx, intentID)
+	if err != nil {
+		return err
+	}
+
+	// idempotency: only process pending
+	if li.Status != "pending" {
+		return nil
+	}
+
+	q, err := lockQuoteTx(ctx, tx, quoteID, intentID)
+	if err != nil {
+		return err
+	}
+	if q.AttemptCount != li.AttemptCount {
+		return ErrQuoteStale
+	}
+
+	policy, err := LoadAccountPolicy(ctx, tx, li.AccountID)
+	if err != nil {
+		return err
+	}
+	if q.PolicyVersion != policy.Version {
+		return ErrQuoteStale
+	}
+
+	quoted := domain.Charge{
+		RateBPS:     money.RateBPS(q.RateBPS),
+		Interest:    money.Cents(q.InterestCents),
+		PromotionID: q.PromotionID,
+	}
+	charge, err := priceIntentTx(ctx, tx, li, policy, &quoted)
+	if err != nil {
+		return err
 	}
 
-	// increment global attempts
-	attemptCount++
 	if _, err := tx.Exec(ctx,
-		`update accounts set attempt_count = $1, updated_at = now() where id = $2`,
-		attemptCount, accountID,
+		`update payment_quotes set used_at = now() where id = $1`,
+		quoteID,
 	); err != nil {
 		return err
 	}
-	// calculate interest
-	spent := money.Cents(amountCents)
-	interest := policy.InterestDue(spent, attemptCount)
+	if li.flaggedForReview() {
+		return parkForReviewTx(ctx, tx, li, policy.Version, charge)
+	}
+
+	return postChargeTx(ctx, tx, li.AccountID, intentID, money.Cents(li.AmountCents), charge, policy.Version)
+}
+
+// priceIntentTx runs the checks shared by confirm and authorize on a locked pending intent:
+// expiry, account lock, spending controls, risk rules, attempt decay + increment, invalid
+// amount penalty and the credit check against available credit (limit - balance - active
+// holds). It returns the charge (policy or promotion rate), or quoted when a quote locked the price.
+func priceIntentTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	li *lockedIntent,
+	policy domain.InterestPolicy,
+	quoted *domain.Charge,
+) (domain.Charge, error) {
+	accountID, intentID := li.AccountID, li.IntentID
+
+	// expired intents are never charged, whatever attempt_count is now
+	if li.Expired {
+		if _, err := tx.Exec(ctx,
+			`update payment_intents set status = 'expired' where id = $1 and status = 'pending'`,
+			intentID,
+		); err != nil {
+			return domain.Charge{}, err
+		}
+		return domain.Charge{}, ErrPaymentIntentExpired
+	}
+
+	// account lock check
+	if li.AccountStatus != "active" {
+		_ = RefusePaymentIntentTx(ctx, tx, intentID)
+		return domain.Charge{}, ErrAccountLocked
+	}
+
+	// the owner's spending controls refuse before the attempt counts
+	now := time.Now()
+	next := policy.NextAttempt(li.AttemptCount, li.LastAttemptAt, now)
+	if err := checkSpendingControlsTx(ctx, tx, li, next, now); err != nil {
+		if errors.Is(err, ErrSpendingLimitExceeded) || errors.Is(err, ErrMerchantBlocked) {
+			_ = RefusePaymentIntentTx(ctx, tx, intentID)
+		}
+		return domain.Charge{}, err
+	}
+
+	// velocity rules: decline refuses, step_up keeps the intent pending, review only flags.
+	// The collector's installments are charged back to back by the gateway, not confirmed
+	// by the payer: they skip the rules and stay out of the velocity history.
+	if !li.Collector {
+		rd, err := evaluateRiskTx(ctx, tx, li, now)
+		if err != nil {
+			return domain.Charge{}, err
+		}
+		li.Risk = rd
+	}
+
+	// increment global attempts (after any decay earned since the last attempt). The
+	// collector's installments are priced as that next attempt but do not store it: a
+	// period is one charge to the payer, and counting each 10 cent installment would push
+	// the rate to MaxRateBPS and trip max_attempts within a single period.
+	li.AttemptCount = next
+	if !li.Collector {
+		if _, err := tx.Exec(ctx,
+			`update accounts set attempt_count = $1, last_attempt_at = $2, updated_at = now() where id = $3`,
+			li.AttemptCount, now, accountID,
+		); err != nil {
+			return domain.Charge{}, err
+		}
+	}
+	// calculate interest (a running promotion beats the policy rate)
+	promo, err := LoadActivePromotion(ctx, tx, li.AccountID, li.AttemptCount)
+	if err != nil {
+		return domain.Charge{}, err
+	}
+	spent := money.Cents(li.AmountCents)
+	charge, err := policy.Price(spent, li.AttemptCount, promo, now)
+	if err != nil {
+		return domain.Charge{}, err
+	}
+	if quoted != nil {
+		charge = *quoted
+	}
 
 	// invalid amount -> penalty + refused
-	if amountCents < 1 || amountCents > 10 {
-		fine := money.Cents(domain.InvalidAmountFineCents)
+	if li.AmountCents < 1 || li.AmountCents > 10 {
+		fine := policy.InvalidAmountFineCents
 
-		if balanceCents+int64(fine) > creditLimit {
+		if !fitsCredit(li.CreditLimit, li.BalanceCents, li.HeldCents, int64(fine)) {
 			_ = RefusePaymentIntentTx(ctx, tx, intentID)
 			_ = LockAccountTx(ctx, tx, accountID, "insufficient_credit")
-			return ErrInsufficientCredit
+			return domain.Charge{}, ErrInsufficientCredit
 		}
 
-		if err := insertLedger(ctx, tx, accountID, intentID, "penalty", fine); err != nil {
-			return err
+		if err := insertLedger(ctx, tx, accountID, intentID, "penalty", fine, ledgerMeta{PolicyVersion: policy.Version}); err != nil {
+			return domain.Charge{}, err
 		}
 
 		if _, err := tx.Exec(ctx,
@@ -98,33 +288,71 @@ for update
 			 where id = $2`,
 			int64(fine), accountID,
 		); err != nil {
-			return err
+			return domain.Charge{}, err
 		}
 
 		if _, err := tx.Exec(ctx,
 			`update payment_intents set status = 'refused' where id = $1`,
 			intentID,
 		); err != nil {
-			return err
+			return domain.Charge{}, err
 		}
 
 		// keep your custom error
-		return ErrMoreThan10Cents
+		return domain.Charge{}, ErrMoreThan10Cents
 	}
 
 	// valid amount path
-	total := amountCents + int64(interest)
+	total, err := money.Add(spent, charge.Interest)
+	if err != nil {
+		return domain.Charge{}, err
+	}
 
-	if balanceCents+total > creditLimit {
+	if !fitsCredit(li.CreditLimit, li.BalanceCents, li.HeldCents, int64(total)) {
 		_ = RefusePaymentIntentTx(ctx, tx, intentID)
 		_ = LockAccountTx(ctx, tx, accountID, "insufficient_credit")
-		return ErrInsufficientCredit
+		return domain.Charge{}, ErrInsufficientCredit
+	}
+
+	return charge, nil
+}
+
+// fitsCredit reports whether amount still fits in the available credit (limit - balance -
+// active holds, since credit promised to open holds is not available). Sums too large for
+// int64 are past any limit.
+func fitsCredit(creditLimit, balance, held, amount int64) bool {
+	committed, err := money.Add(balance, held)
+	if err != nil {
+		return false
 	}
+	committed, err = money.Add(committed, amount)
+	return err == nil && committed <= creditLimit
+}
 
-	if err := insertLedger(ctx, tx, accountID, intentID, "principal", money.Cents(amountCents)); err != nil {
+// postChargeTx books principal + interest on the ledger and the account, and marks the intent succeeded.
+func postChargeTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	accountID uuid.UUID,
+	intentID uuid.UUID,
+	principal money.Cents,
+	charge domain.Charge,
+	policyVersion int64,
+) error {
+	total, err := money.Add(principal, charge.Interest)
+	if err != nil {
 		return err
 	}
-	if err := insertLedger(ctx, tx, accountID, intentID, "interest", interest); err != nil {
+
+	if err := insertLedger(ctx, tx, accountID, intentID, "principal", principal, ledgerMeta{PolicyVersion: policyVersion}); err != nil {
+		return err
+	}
+	rate := int64(charge.RateBPS)
+	if err := insertLedger(ctx, tx, accountID, intentID, "interest", charge.Interest, ledgerMeta{
+		PolicyVersion: policyVersion,
+		RateBPS:       &rate,
+		PromotionID:   charge.PromotionID,
+	}); err != nil {
 		return err
 	}
 
@@ -134,7 +362,7 @@ for update
 		       spent_cents   = spent_cents + $2,
 		       updated_at    = now()
 		 where id = $3`,
-		total, amountCents, accountID,
+		int64(total), int64(principal), accountID,
 	); err != nil {
 		return err
 	}
@@ -149,6 +377,15 @@ for update
 	return nil
 }
 
+// ledgerMeta explains how a ledger entry was produced.
+type ledgerMeta struct {
+	PolicyVersion int64 // 0 => not produced by an interest policy
+	RateBPS       *int64
+	PromotionID   *int64
+}
+
+// insertLedger books an entry in the account's currency; intentID uuid.Nil leaves
+// payment_intent_id null (entries not caused by a single intent, e.g. accrued interest).
 func insertLedger(
 	ctx context.Context,
 	tx pgx.Tx,
@@ -156,12 +393,20 @@ func insertLedger(
 	intentID uuid.UUID,
 	entryType string,
 	amount money.Cents,
+	meta ledgerMeta,
 ) error {
+	var intent any = intentID
+	if intentID == uuid.Nil {
+		intent = nil
+	}
 	_, err := tx.Exec(
 		ctx,
-		`insert into ledger_entries (id, account_id, payment_intent_id, entry_type, amount_cents)
-		 values ($1, $2, $3, $4, $5)`,
-		uuid.New(), accountID, intentID, entryType, int64(amount),
+		`insert into ledger_entries
+		   (id, account_id, payment_intent_id, entry_type, amount_cents, currency, policy_version, rate_bps, promotion_id)
+		 select $1, a.id, $3, $4, $5, a.currency, nullif($6, 0), $7, $8
+		 from accounts a
+		 where a.id = $2`,
+		uuid.New(), accountID, intent, entryType, int64(amount), meta.PolicyVersion, meta.RateBPS, meta.PromotionID,
 	)
 	return err
 }
@@ -92,12 +92,12 @@ func TestConfirmPayment_NormalSuccess_UpdatesLedgerAndAccount(t *testing.T) {
 	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	seedAccount(t, db, accountID, 5000, "active")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
 
-	if err := ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy()); err != nil {
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
 		t.Fatalf("ConfirmPayment: %v", err)
 	}
 
@@ -134,12 +134,12 @@ func TestConfirmPayment_InvalidAmount_11_RefusedPenaltyAndErr(t *testing.T) {
 	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	seedAccount(t, db, accountID, 5000, "active")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 11)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 11, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
 
-	err = ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy())
+	err = ConfirmPayment(context.Background(), db, pi.ID)
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -175,12 +175,12 @@ func TestConfirmPayment_InsufficientCredit_LocksAccount_RefusesIntent_Persists(t
 	// attempt=1 => interest=floor(10*1.01)=10 => total=20
 	seedAccount(t, db, accountID, 19, "active")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
 
-	err = ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy())
+	err = ConfirmPayment(context.Background(), db, pi.ID)
 	if err == nil {
 		t.Fatalf("expected ErrInsufficientCredit, got nil")
 	}
@@ -215,12 +215,12 @@ func TestConfirmPayment_LockedAccount_Rejects(t *testing.T) {
 	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	seedAccount(t, db, accountID, 5000, "locked")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
 
-	err = ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy())
+	err = ConfirmPayment(context.Background(), db, pi.ID)
 	if err == nil {
 		t.Fatalf("expected ErrAccountLocked, got nil")
 	}
@@ -240,15 +240,15 @@ func TestConfirmPayment_Idempotent_DoubleConfirm_NoDoubleLedger(t *testing.T) {
 	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	seedAccount(t, db, accountID, 5000, "active")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
 
-	if err := ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy()); err != nil {
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
 		t.Fatalf("first ConfirmPayment: %v", err)
 	}
-	if err := ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy()); err != nil {
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
 		t.Fatalf("second ConfirmPayment should be no-op, got: %v", err)
 	}
 
@@ -264,14 +264,14 @@ func TestConfirmPayment_Concurrency_SameIntent_OnlyOnce(t *testing.T) {
 	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	seedAccount(t, db, accountID, 5000, "active")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
 
 	errCh := make(chan error, 2)
-	go func() { errCh <- ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy()) }()
-	go func() { errCh <- ConfirmPayment(context.Background(), db, pi.ID, domain.DefaultPolicy()) }()
+	go func() { errCh <- ConfirmPayment(context.Background(), db, pi.ID) }()
+	go func() { errCh <- ConfirmPayment(context.Background(), db, pi.ID) }()
 
 	e1 := <-errCh
 	e2 := <-errCh
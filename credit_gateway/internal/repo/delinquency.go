@@ -0,0 +1,249 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DelinquencyTransition struct {
+	ID          int64
+	AccountID   uuid.UUID
+	StatementID *int64
+	From        domain.DelinquencyStatus
+	To          domain.DelinquencyStatus
+	DaysPastDue int
+	CreatedAt   time.Time
+}
+
+// EvaluateDelinquency re-derives the account's delinquency status as of today (UTC) from its
+// latest statement whose due date has passed. If repayments since that statement closed
+// cover its minimum payment the account is cured back to current; otherwise the late fee is
+// posted once for the statement, the status advances one step at a time (each step recorded
+// and, when webhookURL is set, published through the outbox), and the account is locked at
+// policy.LockAt. charged_off is never left.
+func EvaluateDelinquency(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	accountID uuid.UUID,
+	today time.Time,
+	policy domain.DelinquencyPolicy,
+	webhookURL string,
+) ([]DelinquencyTransition, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ts, err := EvaluateDelinquencyTx(ctx, tx, accountID, today, policy, webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	return ts, tx.Commit(ctx)
+}
+
+func EvaluateDelinquencyTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	accountID uuid.UUID,
+	today time.Time,
+	policy domain.DelinquencyPolicy,
+	webhookURL string,
+) ([]DelinquencyTransition, error) {
+	y, m, d := today.UTC().Date()
+	today = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	var (
+		accountStatus string
+		status        domain.DelinquencyStatus
+		pastDueSince  *time.Time
+	)
+	if err := tx.QueryRow(ctx, `
+select status, delinquency_status, past_due_since
+from accounts
+where id = $1
+for update
+`, accountID).Scan(&accountStatus, &status, &pastDueSince); err != nil {
+		return nil, err
+	}
+	if status == domain.ChargedOff {
+		return nil, nil
+	}
+
+	var (
+		stmtID      int64
+		periodEnd   time.Time
+		dueDate     time.Time
+		minPayment  int64
+		lateFeeAt   *time.Time
+		repaidCents int64
+	)
+	err := tx.QueryRow(ctx, `
+select s.id, s.period_end, s.due_date, s.minimum_payment_cents, s.late_fee_posted_at,
+       coalesce((select -sum(l.amount_cents)
+                   from ledger_entries l
+                  where l.account_id = s.account_id
+                    and l.entry_type = 'repayment'
+                    and l.created_at >= (s.period_end::timestamp at time zone 'UTC')), 0)
+from statements s
+where s.account_id = $1
+  and s.due_date < $2::date
+order by s.period_end desc
+limit 1
+`, accountID, today).Scan(&stmtID, &periodEnd, &dueDate, &minPayment, &lateFeeAt, &repaidCents)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if repaidCents >= minPayment {
+		if status == domain.Current {
+			return nil, nil
+		}
+		t, err := recordTransitionTx(ctx, tx, accountID, stmtID, status, domain.Current, 0, webhookURL)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+update accounts
+   set delinquency_status = 'current',
+       past_due_since = null,
+       updated_at = now()
+ where id = $1
+`, accountID); err != nil {
+			return nil, err
+		}
+		return []DelinquencyTransition{t}, nil
+	}
+
+	if pastDueSince == nil {
+		pastDueSince = &dueDate
+	}
+	days := int(today.Sub(*pastDueSince).Hours() / 24)
+	target := policy.StatusFor(days)
+
+	if lateFeeAt == nil && policy.LateFee > 0 && target != domain.Current {
+		if err := insertLedger(ctx, tx, accountID, uuid.Nil, "penalty", policy.LateFee, ledgerMeta{}); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+update accounts
+   set balance_cents = balance_cents + $2,
+       updated_at = now()
+ where id = $1
+`, accountID, int64(policy.LateFee)); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx,
+			`update statements set late_fee_posted_at = now() where id = $1`,
+			stmtID,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []DelinquencyTransition
+	from := status
+	for _, to := range domain.Advance(status, target) {
+		t, err := recordTransitionTx(ctx, tx, accountID, stmtID, from, to, days, webhookURL)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+		from = to
+	}
+
+	if _, err := tx.Exec(ctx, `
+update accounts
+   set delinquency_status = $2,
+       past_due_since = $3,
+       updated_at = now()
+ where id = $1
+`, accountID, from, *pastDueSince); err != nil {
+		return nil, err
+	}
+
+	if policy.Locks(from) && accountStatus != "locked" {
+		if err := LockAccountTx(ctx, tx, accountID, "delinquency"); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func recordTransitionTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	accountID uuid.UUID,
+	statementID int64,
+	from, to domain.DelinquencyStatus,
+	days int,
+	webhookURL string,
+) (DelinquencyTransition, error) {
+	t := DelinquencyTransition{
+		AccountID:   accountID,
+		StatementID: &statementID,
+		From:        from,
+		To:          to,
+		DaysPastDue: days,
+	}
+	if err := tx.QueryRow(ctx, `
+insert into delinquency_transitions (account_id, statement_id, from_status, to_status, days_past_due)
+values ($1, $2, $3, $4, $5)
+returning id, created_at
+`, accountID, statementID, string(from), string(to), days).Scan(&t.ID, &t.CreatedAt); err != nil {
+		return t, err
+	}
+
+	if webhookURL == "" {
+		return t, nil
+	}
+	_, err := InsertOutboxEventTx(ctx, tx, OutboxEvent{
+		EventType:     "account.delinquency_changed",
+		AggregateType: "delinquency_transition",
+		AggregateID:   t.ID,
+		TargetURL:     webhookURL,
+		Payload: map[string]any{
+			"account_id":    accountID.String(),
+			"statement_id":  statementID,
+			"from_status":   string(from),
+			"to_status":     string(to),
+			"days_past_due": days,
+		},
+	})
+	return t, err
+}
+
+// ListDelinquencyTransitions returns an account's transitions, newest first.
+func ListDelinquencyTransitions(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID) ([]DelinquencyTransition, error) {
+	rows, err := db.Query(ctx, `
+select id, account_id, statement_id, from_status, to_status, days_past_due, created_at
+from delinquency_transitions
+where account_id = $1
+order by id desc
+`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DelinquencyTransition
+	for rows.Next() {
+		var t DelinquencyTransition
+		if err := rows.Scan(&t.ID, &t.AccountID, &t.StatementID, &t.From, &t.To, &t.DaysPastDue, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestEvaluateDelinquency_LateFeeLockAndCure(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	if _, err := db.Exec(context.Background(),
+		`UPDATE accounts SET created_at = $2, statement_day = 15 WHERE id = $1`, accountID, day(2026, 1, 2),
+	); err != nil {
+		t.Fatalf("backdate account: %v", err)
+	}
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	if _, err := db.Exec(context.Background(),
+		`UPDATE ledger_entries SET created_at = $2 WHERE payment_intent_id = $1`, pi.ID, day(2026, 1, 10),
+	); err != nil {
+		t.Fatalf("backdate ledger: %v", err)
+	}
+	// closes at 20, minimum 20, due 2026-02-05
+	if _, err := GenerateStatement(context.Background(), db, accountID, day(2026, 1, 15), domain.DefaultBillingTerms()); err != nil {
+		t.Fatalf("GenerateStatement: %v", err)
+	}
+
+	policy := domain.DefaultDelinquencyPolicy()
+	const hook = "http://example.test/accounts"
+
+	ts, err := EvaluateDelinquency(context.Background(), db, accountID, day(2026, 2, 6), policy, hook)
+	if err != nil {
+		t.Fatalf("EvaluateDelinquency feb 6: %v", err)
+	}
+	if len(ts) != 1 || ts[0].To != domain.Late {
+		t.Fatalf("feb 6 transitions = %+v", ts)
+	}
+	// a second run posts no second fee
+	if _, err := EvaluateDelinquency(context.Background(), db, accountID, day(2026, 2, 7), policy, hook); err != nil {
+		t.Fatalf("EvaluateDelinquency feb 7: %v", err)
+	}
+	status, balance, _, _ := getAccountState(t, db, accountID)
+	if status != "active" || balance != 20+domain.LateFeeCents {
+		t.Fatalf("after late: status=%s balance=%d", status, balance)
+	}
+
+	ts, err = EvaluateDelinquency(context.Background(), db, accountID, day(2026, 3, 8), policy, hook)
+	if err != nil {
+		t.Fatalf("EvaluateDelinquency mar 8: %v", err)
+	}
+	if len(ts) != 1 || ts[0].To != domain.Delinquent || ts[0].DaysPastDue != 31 {
+		t.Fatalf("mar 8 transitions = %+v", ts)
+	}
+	if status, _, _, _ := getAccountState(t, db, accountID); status != "locked" {
+		t.Fatalf("delinquent account status = %s, want locked", status)
+	}
+
+	if _, err := RecordRepayment(context.Background(), db, accountID, 20); err != nil {
+		t.Fatalf("RecordRepayment: %v", err)
+	}
+	ts, err = EvaluateDelinquency(context.Background(), db, accountID, day(2026, 3, 9), policy, hook)
+	if err != nil {
+		t.Fatalf("EvaluateDelinquency after repayment: %v", err)
+	}
+	if len(ts) != 1 || ts[0].From != domain.Delinquent || ts[0].To != domain.Current {
+		t.Fatalf("cure transitions = %+v", ts)
+	}
+
+	var events int64
+	if err := db.QueryRow(context.Background(),
+		`SELECT count(*) FROM webhook_outbox WHERE aggregate_type = 'delinquency_transition'`,
+	).Scan(&events); err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if events != 3 {
+		t.Fatalf("outbox events = %d, want 3", events)
+	}
+}
@@ -0,0 +1,506 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrDisputeTargetRequired = errors.New("merchant_request_id or payment_intent_id is required")
+	ErrDisputeNeedsPayIntent = errors.New("a split merchant request is disputed per payment_intent_id")
+	ErrDisputeReasonRequired = errors.New("a reason is required to open a dispute")
+	ErrInvalidDisputeAmount  = errors.New("dispute amount must not be negative (0 disputes the whole undisputed amount)")
+	ErrDisputeExceedsPaid    = errors.New("dispute exceeds the undisputed paid amount")
+	ErrPaymentNotDisputable  = errors.New("payment intent has not been paid to the merchant")
+	ErrDisputeNotOpen        = errors.New("dispute is not open")
+	ErrDisputeClosed         = errors.New("dispute already resolved")
+	ErrInvalidEvidence       = errors.New("evidence needs submitted_by (payer or merchant) and a description")
+	ErrInvalidDisputeOutcome = errors.New("outcome must be won or lost")
+	ErrResolverRequired      = errors.New("resolved_by is required")
+)
+
+// Dispute statuses. Won and lost are from the merchant's side: a won dispute gives the
+// merchant its money back and takes back the payer's provisional credit.
+const (
+	DisputeOpened           = "opened"
+	DisputeEvidenceRequired = "evidence_required"
+	DisputeWon              = "won"
+	DisputeLost             = "lost"
+)
+
+type Dispute struct {
+	ID                int64
+	MerchantRequestID int64
+	PaymentIntentID   *uuid.UUID
+	AccountID         uuid.UUID
+	MerchantID        string
+	Currency          money.Currency
+	AmountCents       int64
+	// CreditedCents: provisional credit applied to the payer's balance, which never goes
+	// below zero, so it can be less than AmountCents
+	CreditedCents int64
+	Status        string
+	Reason        string
+	ResolvedBy    *string
+	ResolvedAt    *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	Evidence []DisputeEvidence
+
+	webhookURL *string
+	metadata   domain.Metadata // the merchant request's, echoed on webhooks
+}
+
+type DisputeEvidence struct {
+	ID          int64
+	SubmittedBy string
+	Description string
+	CreatedAt   time.Time
+}
+
+type OpenDisputeInput struct {
+	// exactly one of MerchantRequestID / PaymentIntentID
+	MerchantRequestID int64
+	PaymentIntentID   *uuid.UUID
+	// AmountCents: 0 disputes everything not yet disputed (of the intent, or of the request)
+	AmountCents int64
+	Reason      string
+}
+
+// OpenDispute opens a dispute, credits the payer's balance provisionally and debits the
+// merchant's balance (which may go negative; payouts wait until it recovers).
+func OpenDispute(ctx context.Context, db *pgxpool.Pool, in OpenDisputeInput) (*Dispute, error) {
+	if (in.MerchantRequestID == 0) == (in.PaymentIntentID == nil) {
+		return nil, ErrDisputeTargetRequired
+	}
+	if in.Reason == "" {
+		return nil, ErrDisputeReasonRequired
+	}
+	if in.AmountCents < 0 {
+		return nil, ErrInvalidDisputeAmount
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	mrID := in.MerchantRequestID
+	var (
+		accountID        uuid.UUID
+		intentUndisputed int64
+	)
+	if in.PaymentIntentID != nil {
+		var (
+			amount     int64
+			progressed bool
+		)
+		if err := tx.QueryRow(ctx, `
+select mpi.merchant_request_id, pi.account_id, pi.amount_cents, mpi.progressed_at is not null,
+       coalesce((select sum(d.amount_cents) from disputes d
+                  where d.payment_intent_id = mpi.payment_intent_id and d.status <> 'won'), 0)
+from merchant_pay_intents mpi
+join payment_intents pi on pi.id = mpi.payment_intent_id
+where mpi.payment_intent_id = $1
+`, *in.PaymentIntentID).Scan(&mrID, &accountID, &amount, &progressed, &intentUndisputed); err != nil {
+			return nil, err
+		}
+		if !progressed {
+			return nil, ErrPaymentNotDisputable
+		}
+		intentUndisputed = amount - intentUndisputed
+	} else {
+		var split bool
+		if err := tx.QueryRow(ctx, `
+select payer_account_id,
+       (select count(*) from merchant_request_payers p where p.merchant_request_id = mr.id) > 1
+from merchant_requests mr
+where id = $1
+`, mrID).Scan(&accountID, &split); err != nil {
+			return nil, err
+		}
+		if split {
+			return nil, ErrDisputeNeedsPayIntent
+		}
+	}
+
+	// account before merchant request, the same order as confirm
+	var balance int64
+	if err := tx.QueryRow(ctx,
+		`select balance_cents from accounts where id = $1 for update`, accountID,
+	).Scan(&balance); err != nil {
+		return nil, err
+	}
+
+	var (
+		merchantID string
+		currency   money.Currency
+		paid       int64
+		disputed   int64
+		webhookURL *string
+		metadata   domain.Metadata
+	)
+	if err := tx.QueryRow(ctx, `
+select merchant_id, currency, paid_cents, webhook_url, metadata,
+       coalesce((select sum(d.amount_cents) from disputes d
+                  where d.merchant_request_id = mr.id and d.status <> 'won'), 0)
+from merchant_requests mr
+where id = $1
+for update
+`, mrID).Scan(&merchantID, &currency, &paid, &webhookURL, &metadata, &disputed); err != nil {
+		return nil, err
+	}
+
+	undisputed := paid - disputed
+	if in.PaymentIntentID != nil {
+		undisputed = min(undisputed, intentUndisputed)
+	}
+	amount := in.AmountCents
+	if amount == 0 {
+		amount = undisputed
+	}
+	if amount <= 0 || amount > undisputed {
+		return nil, ErrDisputeExceedsPaid
+	}
+	credited := min(amount, balance)
+
+	d := Dispute{
+		MerchantRequestID: mrID,
+		PaymentIntentID:   in.PaymentIntentID,
+		AccountID:         accountID,
+		MerchantID:        merchantID,
+		Currency:          currency,
+		AmountCents:       amount,
+		CreditedCents:     credited,
+		Status:            DisputeOpened,
+		Reason:            in.Reason,
+		webhookURL:        webhookURL,
+		metadata:          metadata,
+	}
+	if err := tx.QueryRow(ctx, `
+insert into disputes (merchant_request_id, payment_intent_id, account_id, merchant_id, currency, amount_cents, credited_cents, reason)
+values ($1, $2, $3, $4, $5, $6, $7, $8)
+returning id, created_at, updated_at
+`, mrID, in.PaymentIntentID, accountID, merchantID, currency, amount, credited, in.Reason,
+	).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := adjustPayerBalanceTx(ctx, tx, &d, "dispute_credit", -credited); err != nil {
+		return nil, err
+	}
+	if err := adjustMerchantBalanceTx(ctx, tx, &d, "dispute", -amount); err != nil {
+		return nil, err
+	}
+	if err := disputeEventTx(ctx, tx, &d, "dispute.opened", nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return GetDispute(ctx, db, d.ID)
+}
+
+// adjustPayerBalanceTx books a dispute movement on the payer's ledger and balance.
+func adjustPayerBalanceTx(ctx context.Context, tx pgx.Tx, d *Dispute, entryType string, amountCents int64) error {
+	if amountCents == 0 {
+		return nil
+	}
+	intentID := uuid.Nil
+	if d.PaymentIntentID != nil {
+		intentID = *d.PaymentIntentID
+	}
+	if err := insertLedger(ctx, tx, d.AccountID, intentID, entryType, money.Cents(amountCents), ledgerMeta{}); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+update accounts
+   set balance_cents = balance_cents + $2,
+       updated_at = now()
+ where id = $1
+`, d.AccountID, amountCents)
+	return err
+}
+
+// adjustMerchantBalanceTx books a dispute movement on the merchant's ledger and balance.
+func adjustMerchantBalanceTx(ctx context.Context, tx pgx.Tx, d *Dispute, entryType string, amountCents int64) error {
+	if _, err := tx.Exec(ctx, `
+insert into merchant_ledger_entries (merchant_id, currency, entry_type, amount_cents, merchant_request_id, dispute_id)
+values ($1, $2, $3, $4, $5, $6)
+`, d.MerchantID, d.Currency, entryType, amountCents, d.MerchantRequestID, d.ID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+insert into merchant_balances (merchant_id, currency, balance_cents)
+values ($1, $2, $3)
+on conflict (merchant_id, currency) do update
+  set balance_cents = merchant_balances.balance_cents + excluded.balance_cents,
+      updated_at = now()
+`, d.MerchantID, d.Currency, amountCents)
+	return err
+}
+
+// disputeEventTx enqueues a dispute webhook to the merchant request's webhook_url, if it has one.
+func disputeEventTx(ctx context.Context, tx pgx.Tx, d *Dispute, eventType string, extra map[string]any) error {
+	if d.webhookURL == nil || *d.webhookURL == "" {
+		return nil
+	}
+	payload := map[string]any{
+		"dispute_id":          d.ID,
+		"merchant_request_id": d.MerchantRequestID,
+		"payment_intent_id":   d.PaymentIntentID,
+		"merchant_id":         d.MerchantID,
+		"payer_account_id":    d.AccountID.String(),
+		"status":              d.Status,
+		"amount_cents":        d.AmountCents,
+		"credited_cents":      d.CreditedCents,
+		"currency":            d.Currency,
+		"reason":              d.Reason,
+		"metadata":            d.metadata,
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	_, err := InsertOutboxEventTx(ctx, tx, OutboxEvent{
+		EventType:     eventType,
+		AggregateType: "dispute",
+		AggregateID:   d.ID,
+		TargetURL:     *d.webhookURL,
+		Payload:       payload,
+	})
+	return err
+}
+
+const disputeColumns = `d.id, d.merchant_request_id, d.payment_intent_id, d.account_id, d.merchant_id, d.currency,
+  d.amount_cents, d.credited_cents, d.status, d.reason, d.resolved_by, d.resolved_at,
+  d.created_at, d.updated_at, mr.webhook_url, mr.metadata`
+
+func scanDispute(row pgx.Row, d *Dispute) error {
+	return row.Scan(
+		&d.ID, &d.MerchantRequestID, &d.PaymentIntentID, &d.AccountID, &d.MerchantID, &d.Currency,
+		&d.AmountCents, &d.CreditedCents, &d.Status, &d.Reason, &d.ResolvedBy, &d.ResolvedAt,
+		&d.CreatedAt, &d.UpdatedAt, &d.webhookURL, &d.metadata,
+	)
+}
+
+// GetDispute returns a dispute with its evidence.
+func GetDispute(ctx context.Context, db *pgxpool.Pool, id int64) (*Dispute, error) {
+	var d Dispute
+	if err := scanDispute(db.QueryRow(ctx, `
+select `+disputeColumns+`
+from disputes d
+join merchant_requests mr on mr.id = d.merchant_request_id
+where d.id = $1
+`, id), &d); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `
+select id, submitted_by, description, created_at
+from dispute_evidence
+where dispute_id = $1
+order by id
+`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	d.Evidence = []DisputeEvidence{}
+	for rows.Next() {
+		var e DisputeEvidence
+		if err := rows.Scan(&e.ID, &e.SubmittedBy, &e.Description, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Evidence = append(d.Evidence, e)
+	}
+	return &d, rows.Err()
+}
+
+// ListMerchantRequestDisputes returns the disputes of a merchant request, oldest first.
+func ListMerchantRequestDisputes(ctx context.Context, db *pgxpool.Pool, merchantRequestID int64) ([]Dispute, error) {
+	rows, err := db.Query(ctx, `
+select `+disputeColumns+`
+from disputes d
+join merchant_requests mr on mr.id = d.merchant_request_id
+where d.merchant_request_id = $1
+order by d.id
+`, merchantRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Dispute
+	for rows.Next() {
+		var d Dispute
+		if err := scanDispute(rows, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// lockDisputeTx locks the payer's account and then the dispute (the same order as confirm)
+// and fails unless the dispute is still open.
+func lockDisputeTx(ctx context.Context, tx pgx.Tx, id int64) (*Dispute, error) {
+	var accountID uuid.UUID
+	if err := tx.QueryRow(ctx, `select account_id from disputes where id = $1`, id).Scan(&accountID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `select 1 from accounts where id = $1 for update`, accountID); err != nil {
+		return nil, err
+	}
+
+	var d Dispute
+	if err := scanDispute(tx.QueryRow(ctx, `
+select `+disputeColumns+`
+from disputes d
+join merchant_requests mr on mr.id = d.merchant_request_id
+where d.id = $1
+for update of d
+`, id), &d); err != nil {
+		return nil, err
+	}
+	if d.Status != DisputeOpened && d.Status != DisputeEvidenceRequired {
+		return nil, ErrDisputeClosed
+	}
+	return &d, nil
+}
+
+// RequestDisputeEvidence moves an opened dispute to evidence_required.
+func RequestDisputeEvidence(ctx context.Context, db *pgxpool.Pool, id int64) (*Dispute, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	d, err := lockDisputeTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != DisputeOpened {
+		return nil, ErrDisputeNotOpen
+	}
+
+	d.Status = DisputeEvidenceRequired
+	if _, err := tx.Exec(ctx,
+		`update disputes set status = $2, updated_at = now() where id = $1`, id, d.Status,
+	); err != nil {
+		return nil, err
+	}
+	if err := disputeEventTx(ctx, tx, d, "dispute.evidence_required", nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return GetDispute(ctx, db, id)
+}
+
+// SubmitDisputeEvidence attaches evidence from the payer or the merchant to an open dispute.
+func SubmitDisputeEvidence(ctx context.Context, db *pgxpool.Pool, id int64, submittedBy, description string) (*Dispute, error) {
+	if (submittedBy != "payer" && submittedBy != "merchant") || description == "" {
+		return nil, ErrInvalidEvidence
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	d, err := lockDisputeTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var evidenceID int64
+	if err := tx.QueryRow(ctx, `
+insert into dispute_evidence (dispute_id, submitted_by, description)
+values ($1, $2, $3)
+returning id
+`, id, submittedBy, description).Scan(&evidenceID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `update disputes set updated_at = now() where id = $1`, id); err != nil {
+		return nil, err
+	}
+	if err := disputeEventTx(ctx, tx, d, "dispute.evidence_submitted", map[string]any{
+		"evidence_id":  evidenceID,
+		"submitted_by": submittedBy,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return GetDispute(ctx, db, id)
+}
+
+// ResolveDispute closes an open dispute. Won re-debits the payer's provisional credit and
+// re-credits the merchant; lost makes both movements final.
+func ResolveDispute(ctx context.Context, db *pgxpool.Pool, id int64, outcome, resolvedBy string) (*Dispute, error) {
+	if outcome != DisputeWon && outcome != DisputeLost {
+		return nil, ErrInvalidDisputeOutcome
+	}
+	if resolvedBy == "" {
+		return nil, ErrResolverRequired
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the payer's account is locked before the dispute (inside lockDisputeTx) and before its
+	// balance moves below, the order OpenDispute, confirm and accrual use
+	d, err := lockDisputeTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome == DisputeWon {
+		if err := adjustPayerBalanceTx(ctx, tx, d, "dispute_reversal", d.CreditedCents); err != nil {
+			return nil, err
+		}
+		if err := adjustMerchantBalanceTx(ctx, tx, d, "dispute_reversal", d.AmountCents); err != nil {
+			return nil, err
+		}
+	}
+
+	d.Status = outcome
+	if _, err := tx.Exec(ctx, `
+update disputes
+   set status = $2, resolved_by = $3, resolved_at = now(), updated_at = now()
+ where id = $1
+`, id, outcome, resolvedBy); err != nil {
+		return nil, err
+	}
+	if err := disputeEventTx(ctx, tx, d, "dispute."+outcome, map[string]any{
+		"resolved_by": resolvedBy,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return GetDispute(ctx, db, id)
+}
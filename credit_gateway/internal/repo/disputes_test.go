@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestDispute_ProvisionalCreditAndMerchantDebitReversedWhenWon(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+	mrID := createMerchantRequestRowWithWebhook(t, db, "merchant_dispute", ptr("order_001"), accountID.String(), 100, "http://example.test/webhook")
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		t.Fatalf("begin tx: %v", err)
+	}
+	if _, _, _, err := IncrementMerchantRequestProgress(ctx, tx, mrID, 40); err != nil {
+		t.Fatalf("IncrementMerchantRequestProgress: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	// the payer already repaid part of it: only 30 is left to credit
+	if _, err := db.Exec(ctx, `update accounts set balance_cents = 30 where id = $1`, accountID); err != nil {
+		t.Fatalf("set balance: %v", err)
+	}
+
+	d, err := OpenDispute(ctx, db, OpenDisputeInput{MerchantRequestID: mrID, Reason: "not authorized"})
+	if err != nil {
+		t.Fatalf("OpenDispute: %v", err)
+	}
+	if d.Status != DisputeOpened || d.AmountCents != 40 || d.CreditedCents != 30 {
+		t.Fatalf("dispute = %+v, want opened for 40 with 30 credited", d)
+	}
+	if _, balance, _, _ := getAccountState(t, db, accountID); balance != 0 {
+		t.Fatalf("payer balance = %d, want 0", balance)
+	}
+	policy := domain.PayoutPolicy{MinPayout: 1}
+	balances, err := GetMerchantBalances(ctx, db, "merchant_dispute", policy)
+	if err != nil || len(balances) != 1 || balances[0].BalanceCents != 0 {
+		t.Fatalf("merchant balances = %+v, %v; want 0", balances, err)
+	}
+
+	if _, err := OpenDispute(ctx, db, OpenDisputeInput{MerchantRequestID: mrID, Reason: "again"}); !errors.Is(err, ErrDisputeExceedsPaid) {
+		t.Fatalf("second dispute err = %v, want ErrDisputeExceedsPaid", err)
+	}
+
+	if _, err := RequestDisputeEvidence(ctx, db, d.ID); err != nil {
+		t.Fatalf("RequestDisputeEvidence: %v", err)
+	}
+	d, err = SubmitDisputeEvidence(ctx, db, d.ID, "merchant", "signed delivery receipt")
+	if err != nil {
+		t.Fatalf("SubmitDisputeEvidence: %v", err)
+	}
+	if d.Status != DisputeEvidenceRequired || len(d.Evidence) != 1 {
+		t.Fatalf("dispute after evidence = %+v", d)
+	}
+
+	d, err = ResolveDispute(ctx, db, d.ID, DisputeWon, "ops@example.test")
+	if err != nil {
+		t.Fatalf("ResolveDispute: %v", err)
+	}
+	if d.Status != DisputeWon || d.ResolvedAt == nil {
+		t.Fatalf("resolved dispute = %+v", d)
+	}
+	if _, balance, _, _ := getAccountState(t, db, accountID); balance != 30 {
+		t.Fatalf("payer balance after won = %d, want 30", balance)
+	}
+	balances, err = GetMerchantBalances(ctx, db, "merchant_dispute", policy)
+	if err != nil || len(balances) != 1 || balances[0].BalanceCents != 40 {
+		t.Fatalf("merchant balances after won = %+v, %v; want 40", balances, err)
+	}
+	if _, err := ResolveDispute(ctx, db, d.ID, DisputeLost, "ops@example.test"); !errors.Is(err, ErrDisputeClosed) {
+		t.Fatalf("resolve twice err = %v, want ErrDisputeClosed", err)
+	}
+
+	var events int64
+	if err := db.QueryRow(ctx,
+		`SELECT count(*) FROM webhook_outbox WHERE aggregate_type = 'dispute' AND aggregate_id = $1`, d.ID,
+	).Scan(&events); err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if events != 4 {
+		t.Fatalf("dispute outbox events = %d, want 4", events)
+	}
+}
@@ -0,0 +1,355 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrPaymentIntentNotAuthorized = errors.New("payment intent not authorized")
+
+var ErrHoldExpired = errors.New("authorization hold expired")
+
+type Hold struct {
+	ID              uuid.UUID
+	AccountID       uuid.UUID
+	PaymentIntentID uuid.UUID
+	PrincipalCents  int64
+	InterestCents   int64
+	AmountCents     int64
+	PolicyVersion   int64
+	RateBPS         int64
+	PromotionID     *int64
+	Status          string
+	ExpiresAt       *time.Time // nil = never expires
+	CreatedAt       time.Time
+}
+
+// AuthorizePaymentTx prices a pending intent exactly like confirm (attempt increment,
+// penalty, credit check) but only places a hold against available credit.
+// Money moves later on CapturePaymentTx. A review hit parks the intent without a hold,
+// as confirm does. Non-pending intents are a no-op.
+// A non-positive holdTTL places a hold that never expires, like an intent's ttl.
+func AuthorizePaymentTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	intentID uuid.UUID,
+	holdTTL time.Duration,
+) error {
+
+	li, err := lockIntentTx(ctx, tx, intentID)
+	if err != nil {
+		return err
+	}
+
+	// idempotency: only process pending
+	if li.Status != "pending" {
+		return nil
+	}
+
+	policy, err := LoadAccountPolicy(ctx, tx, li.AccountID)
+	if err != nil {
+		return err
+	}
+
+	charge, err := priceIntentTx(ctx, tx, li, policy, nil)
+	if err != nil {
+		return err
+	}
+	if li.flaggedForReview() {
+		return parkForReviewTx(ctx, tx, li, policy.Version, charge)
+	}
+
+	principal := money.Cents(li.AmountCents)
+	amount, err := money.Add(principal, charge.Interest)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+insert into holds
+  (id, account_id, payment_intent_id, principal_cents, interest_cents, amount_cents,
+   policy_version, rate_bps, promotion_id, expires_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, now() + $10::bigint * interval '1 microsecond')
+`, uuid.New(), li.AccountID, intentID, int64(principal), int64(charge.Interest), int64(amount),
+		policy.Version, int64(charge.RateBPS), charge.PromotionID, ttlMicros(holdTTL)); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`update payment_intents set status = 'authorized' where id = $1`,
+		intentID,
+	)
+	return err
+}
+
+// lockHoldTx loads the intent status and its hold (if any) FOR UPDATE.
+func lockHoldTx(ctx context.Context, tx pgx.Tx, intentID uuid.UUID) (string, *Hold, bool, error) {
+	var status string
+	if err := tx.QueryRow(ctx,
+		`select status from payment_intents where id = $1 for update`,
+		intentID,
+	).Scan(&status); err != nil {
+		return "", nil, false, err
+	}
+
+	var (
+		h       Hold
+		expired bool
+	)
+	err := tx.QueryRow(ctx, `
+select id, account_id, payment_intent_id, principal_cents, interest_cents, amount_cents,
+       coalesce(policy_version, 0), coalesce(rate_bps, 0), promotion_id, status, expires_at, created_at,
+       coalesce(expires_at <= now(), false)
+from holds
+where payment_intent_id = $1
+for update
+`, intentID).Scan(
+		&h.ID,
+		&h.AccountID,
+		&h.PaymentIntentID,
+		&h.PrincipalCents,
+		&h.InterestCents,
+		&h.AmountCents,
+		&h.PolicyVersion,
+		&h.RateBPS,
+		&h.PromotionID,
+		&h.Status,
+		&h.ExpiresAt,
+		&h.CreatedAt,
+		&expired,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return status, nil, false, nil
+	}
+	if err != nil {
+		return "", nil, false, err
+	}
+	return status, &h, expired, nil
+}
+
+// CapturePaymentTx books the held principal + interest and closes the hold.
+// Capturing an already succeeded intent is a no-op.
+func CapturePaymentTx(ctx context.Context, tx pgx.Tx, intentID uuid.UUID) error {
+	status, h, expired, err := lockHoldTx(ctx, tx, intentID)
+	if err != nil {
+		return err
+	}
+
+	if status == "succeeded" {
+		return nil
+	}
+	if status != "authorized" || h == nil || h.Status != "active" {
+		return ErrPaymentIntentNotAuthorized
+	}
+
+	// stale hold not swept yet -> release it now instead of charging
+	if expired {
+		if err := releaseHoldTx(ctx, tx, h.ID, intentID, "released", "expired"); err != nil {
+			return err
+		}
+		return ErrHoldExpired
+	}
+
+	charge := domain.Charge{
+		RateBPS:     money.RateBPS(h.RateBPS),
+		Interest:    money.Cents(h.InterestCents),
+		PromotionID: h.PromotionID,
+	}
+	if err := postChargeTx(ctx, tx, h.AccountID, intentID, money.Cents(h.PrincipalCents), charge, h.PolicyVersion); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`update holds set status = 'captured', updated_at = now() where id = $1`,
+		h.ID,
+	)
+	return err
+}
+
+// VoidPaymentTx releases an authorized hold without charging. Voiding twice is a no-op.
+func VoidPaymentTx(ctx context.Context, tx pgx.Tx, intentID uuid.UUID) error {
+	status, h, _, err := lockHoldTx(ctx, tx, intentID)
+	if err != nil {
+		return err
+	}
+
+	if status == "voided" {
+		return nil
+	}
+	if status != "authorized" || h == nil || h.Status != "active" {
+		return ErrPaymentIntentNotAuthorized
+	}
+
+	return releaseHoldTx(ctx, tx, h.ID, intentID, "voided", "voided")
+}
+
+func releaseHoldTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	holdID uuid.UUID,
+	intentID uuid.UUID,
+	holdStatus string,
+	intentStatus string,
+) error {
+	if _, err := tx.Exec(ctx,
+		`update holds set status = $2, updated_at = now() where id = $1 and status = 'active'`,
+		holdID, holdStatus,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		`update payment_intents set status = $2 where id = $1 and status = 'authorized'`,
+		intentID, intentStatus,
+	)
+	return err
+}
+
+func AuthorizePayment(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	intentID uuid.UUID,
+	holdTTL time.Duration,
+) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = AuthorizePaymentTx(ctx, tx, intentID, holdTTL)
+	if err != nil {
+		if IsConfirmBusinessError(err) {
+			// keep the lock / penalty / refused status
+			if commitErr := tx.Commit(ctx); commitErr != nil {
+				return commitErr
+			}
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func CapturePayment(ctx context.Context, db *pgxpool.Pool, intentID uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = CapturePaymentTx(ctx, tx, intentID)
+	if err != nil {
+		if errors.Is(err, ErrHoldExpired) {
+			// keep the release
+			if commitErr := tx.Commit(ctx); commitErr != nil {
+				return commitErr
+			}
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func VoidPayment(ctx context.Context, db *pgxpool.Pool, intentID uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := VoidPaymentTx(ctx, tx, intentID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReleaseExpiredHolds releases up to limit active holds past expires_at and
+// moves their intents to expired, freeing the held credit.
+func ReleaseExpiredHolds(ctx context.Context, db *pgxpool.Pool, limit int) (int64, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+UPDATE holds
+SET status = 'released', updated_at = now()
+WHERE id IN (
+  SELECT id
+  FROM holds
+  WHERE status = 'active'
+    AND expires_at <= now()
+  ORDER BY expires_at ASC
+  FOR UPDATE SKIP LOCKED
+  LIMIT $1
+)
+RETURNING payment_intent_id
+`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var intentIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		intentIDs = append(intentIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(intentIDs) > 0 {
+		if _, err := tx.Exec(ctx,
+			`update payment_intents set status = 'expired' where id = any($1) and status = 'authorized'`,
+			intentIDs,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return int64(len(intentIDs)), nil
+}
+
+func GetHoldByPaymentIntent(ctx context.Context, db *pgxpool.Pool, intentID uuid.UUID) (*Hold, error) {
+	var h Hold
+	if err := db.QueryRow(ctx, `
+select id, account_id, payment_intent_id, principal_cents, interest_cents, amount_cents,
+       coalesce(policy_version, 0), coalesce(rate_bps, 0), promotion_id, status, expires_at, created_at
+from holds
+where payment_intent_id = $1
+`, intentID).Scan(
+		&h.ID,
+		&h.AccountID,
+		&h.PaymentIntentID,
+		&h.PrincipalCents,
+		&h.InterestCents,
+		&h.AmountCents,
+		&h.PolicyVersion,
+		&h.RateBPS,
+		&h.PromotionID,
+		&h.Status,
+		&h.ExpiresAt,
+		&h.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAuthorizeCapture_HoldsCreditThenBooks(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	// attempt=1 => interest=floor(10*1.01)=10 => hold=20
+	seedAccount(t, db, accountID, 30, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	if err := AuthorizePayment(context.Background(), db, pi.ID, time.Hour); err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	if got := getIntentStatus(t, db, pi.ID); got != "authorized" {
+		t.Fatalf("intent status = %q, want authorized", got)
+	}
+
+	a, err := GetAccountByID(context.Background(), db, accountID.String())
+	if err != nil {
+		t.Fatalf("GetAccountByID: %v", err)
+	}
+	if a.BalanceCents != 0 || a.HeldCents != 20 {
+		t.Fatalf("balance=%d held=%d, want balance=0 held=20", a.BalanceCents, a.HeldCents)
+	}
+
+	// a second payment does not fit next to the hold (20 held + 20 > 30)
+	pi2, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi2.ID); !errors.Is(err, ErrInsufficientCredit) {
+		t.Fatalf("err=%v, want ErrInsufficientCredit", err)
+	}
+
+	if err := CapturePayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("CapturePayment: %v", err)
+	}
+	// capture is idempotent
+	if err := CapturePayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("second CapturePayment: %v", err)
+	}
+
+	if got := getIntentStatus(t, db, pi.ID); got != "succeeded" {
+		t.Fatalf("intent status = %q, want succeeded", got)
+	}
+	if got := countLedgerByIntent(t, db, pi.ID); got != 2 {
+		t.Fatalf("ledger rows = %d, want 2", got)
+	}
+
+	a, err = GetAccountByID(context.Background(), db, accountID.String())
+	if err != nil {
+		t.Fatalf("GetAccountByID: %v", err)
+	}
+	if a.BalanceCents != 20 || a.HeldCents != 0 || a.SpentCents != 10 {
+		t.Fatalf("balance=%d held=%d spent=%d, want 20/0/10", a.BalanceCents, a.HeldCents, a.SpentCents)
+	}
+}
+
+func TestVoidAndRelease_FreeHeldCredit(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	voided, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	stale, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	if err := AuthorizePayment(context.Background(), db, voided.ID, time.Hour); err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	if err := AuthorizePayment(context.Background(), db, stale.ID, time.Hour); err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	if _, err := db.Exec(context.Background(),
+		`UPDATE holds SET expires_at = now() - interval '1 second' WHERE payment_intent_id = $1`, stale.ID,
+	); err != nil {
+		t.Fatalf("backdate hold: %v", err)
+	}
+	// ttl 0 => the hold never expires
+	forever, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := AuthorizePayment(context.Background(), db, forever.ID, 0); err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+
+	if err := VoidPayment(context.Background(), db, voided.ID); err != nil {
+		t.Fatalf("VoidPayment: %v", err)
+	}
+	if err := CapturePayment(context.Background(), db, voided.ID); !errors.Is(err, ErrPaymentIntentNotAuthorized) {
+		t.Fatalf("capture after void err=%v, want ErrPaymentIntentNotAuthorized", err)
+	}
+
+	n, err := ReleaseExpiredHolds(context.Background(), db, 10)
+	if err != nil {
+		t.Fatalf("ReleaseExpiredHolds: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("released=%d want 1", n)
+	}
+
+	if got := getIntentStatus(t, db, voided.ID); got != "voided" {
+		t.Fatalf("voided intent status = %q, want voided", got)
+	}
+	if got := getIntentStatus(t, db, stale.ID); got != "expired" {
+		t.Fatalf("stale intent status = %q, want expired", got)
+	}
+	if got := getIntentStatus(t, db, forever.ID); got != "authorized" {
+		t.Fatalf("no-expiry intent status = %q, want authorized", got)
+	}
+	if err := VoidPayment(context.Background(), db, forever.ID); err != nil {
+		t.Fatalf("VoidPayment: %v", err)
+	}
+
+	a, err := GetAccountByID(context.Background(), db, accountID.String())
+	if err != nil {
+		t.Fatalf("GetAccountByID: %v", err)
+	}
+	if a.BalanceCents != 0 || a.HeldCents != 0 {
+		t.Fatalf("balance=%d held=%d, want 0/0", a.BalanceCents, a.HeldCents)
+	}
+}
+
+func TestAuthorizePayment_Concurrency_SameAccount_RespectsLimit(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	// each hold is at least 20, so only one fits under 30
+	seedAccount(t, db, accountID, 30, "active")
+
+	pi1, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	pi2, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- AuthorizePayment(context.Background(), db, pi1.ID, time.Hour) }()
+	go func() { errCh <- AuthorizePayment(context.Background(), db, pi2.ID, time.Hour) }()
+
+	var ok, refused int
+	for i := 0; i < 2; i++ {
+		switch err := <-errCh; {
+		case err == nil:
+			ok++
+		case errors.Is(err, ErrInsufficientCredit):
+			refused++
+		default:
+			t.Fatalf("AuthorizePayment: %v", err)
+		}
+	}
+	if ok != 1 || refused != 1 {
+		t.Fatalf("authorized=%d refused=%d, want 1/1", ok, refused)
+	}
+
+	a, err := GetAccountByID(context.Background(), db, accountID.String())
+	if err != nil {
+		t.Fatalf("GetAccountByID: %v", err)
+	}
+	if a.HeldCents > a.CreditLimitCents {
+		t.Fatalf("held=%d exceeds limit=%d", a.HeldCents, a.CreditLimitCents)
+	}
+}
@@ -0,0 +1,203 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// InterestAccrual is the interest charged on one account for one day.
+type InterestAccrual struct {
+	ID             int64
+	AccountID      uuid.UUID
+	AccrualDate    time.Time
+	PrincipalCents int64
+	RateBPS        int64
+	InterestCents  int64
+	CreatedAt      time.Time
+}
+
+// ListAccountsDueForAccrual pages (keyset, after id) through accounts with principal booked by
+// through whose accruals stop before it.
+func ListAccountsDueForAccrual(ctx context.Context, db *pgxpool.Pool, through time.Time, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := db.Query(ctx, `
+select a.id
+from accounts a
+where a.id > $2
+  and (select min((l.created_at at time zone 'UTC')::date) from ledger_entries l
+        where l.account_id = a.id and l.entry_type = 'principal') <= $1::date
+  and coalesce((select max(ia.accrual_date) from interest_accruals ia where ia.account_id = a.id), '-infinity') < $1::date
+order by a.id
+limit $3
+`, through, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// AccrueInterest accrues every day from the day after the account's last accrual (or the day
+// of its first principal entry) through the given date, at dailyRate rounded per the account's
+// interest policy. Each day is recorded once; the interest is booked as an accrued_interest
+// ledger entry. It returns the number of days accrued.
+func AccrueInterest(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	accountID uuid.UUID,
+	through time.Time,
+	dailyRate money.RateBPS,
+) (int, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	n, err := AccrueInterestTx(ctx, tx, accountID, through, dailyRate)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit(ctx)
+}
+
+func AccrueInterestTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	accountID uuid.UUID,
+	through time.Time,
+	dailyRate money.RateBPS,
+) (int, error) {
+	// serialize with confirms and other accrual runs on this account
+	if _, err := tx.Exec(ctx, `select 1 from accounts where id = $1 for update`, accountID); err != nil {
+		return 0, err
+	}
+
+	policy, err := LoadAccountPolicy(ctx, tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	// principal outstanding at the end of each pending day (UTC); repayments settle fees and
+	// interest first, so principal only drops once they exceed everything else owed
+	rows, err := tx.Query(ctx, `
+with bounds as (
+  select coalesce(
+           (select max(accrual_date) + 1 from interest_accruals where account_id = $1),
+           (select min((created_at at time zone 'UTC')::date) from ledger_entries
+             where account_id = $1 and entry_type = 'principal')
+         ) as first_day
+)
+select d::date,
+       coalesce((select greatest(0, least(sum(l.amount_cents) filter (where l.entry_type = 'principal'),
+                                          sum(l.amount_cents)))
+                   from ledger_entries l
+                  where l.account_id = $1
+                    and l.created_at < ((d::date + 1)::timestamp at time zone 'UTC')), 0)
+from bounds, generate_series(bounds.first_day, $2::date, interval '1 day') d
+order by d
+`, accountID, through)
+	if err != nil {
+		return 0, err
+	}
+	type day struct {
+		date      time.Time
+		principal int64
+	}
+	var days []day
+	for rows.Next() {
+		var d day
+		if err := rows.Scan(&d.date, &d.principal); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		days = append(days, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	accrued := 0
+	for _, d := range days {
+		interest, err := policy.FeeAt(money.Cents(d.principal), dailyRate)
+		if err != nil {
+			return accrued, err
+		}
+
+		var id int64
+		err = tx.QueryRow(ctx, `
+insert into interest_accruals (account_id, accrual_date, principal_cents, rate_bps, interest_cents)
+values ($1, $2, $3, $4, $5)
+on conflict (account_id, accrual_date) do nothing
+returning id
+`, accountID, d.date, d.principal, int64(dailyRate), int64(interest)).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue // already accrued
+		}
+		if err != nil {
+			return accrued, err
+		}
+		accrued++
+
+		if interest == 0 {
+			continue
+		}
+		rate := int64(dailyRate)
+		if err := insertLedger(ctx, tx, accountID, uuid.Nil, "accrued_interest", interest, ledgerMeta{
+			PolicyVersion: policy.Version,
+			RateBPS:       &rate,
+		}); err != nil {
+			return accrued, err
+		}
+		if _, err := tx.Exec(ctx,
+			`update accounts set balance_cents = balance_cents + $1, updated_at = now() where id = $2`,
+			int64(interest), accountID,
+		); err != nil {
+			return accrued, err
+		}
+	}
+	return accrued, nil
+}
+
+func ListInterestAccruals(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID) ([]InterestAccrual, error) {
+	rows, err := db.Query(ctx, `
+select id, account_id, accrual_date, principal_cents, rate_bps, interest_cents, created_at
+from interest_accruals
+where account_id = $1
+order by accrual_date asc
+`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []InterestAccrual
+	for rows.Next() {
+		var a InterestAccrual
+		if err := rows.Scan(&a.ID, &a.AccountID, &a.AccrualDate, &a.PrincipalCents, &a.RateBPS, &a.InterestCents, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
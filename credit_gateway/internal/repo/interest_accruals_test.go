@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAccrueInterest_CatchesUpMissedDaysOnce(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+
+	// principal booked 3 days ago, engine was down since
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if _, err := db.Exec(context.Background(),
+		`UPDATE ledger_entries SET created_at = $2 WHERE payment_intent_id = $1`,
+		pi.ID, today.AddDate(0, 0, -3).Add(time.Hour),
+	); err != nil {
+		t.Fatalf("backdate ledger: %v", err)
+	}
+
+	var before int64
+	if err := db.QueryRow(context.Background(), `SELECT balance_cents FROM accounts WHERE id = $1`, accountID).Scan(&before); err != nil {
+		t.Fatalf("read balance: %v", err)
+	}
+
+	// 10%/day on 10 cents => 1 cent per day, days -3, -2, -1
+	yesterday := today.AddDate(0, 0, -1)
+	n, err := AccrueInterest(context.Background(), db, accountID, yesterday, 1000)
+	if err != nil {
+		t.Fatalf("AccrueInterest: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("accrued days=%d want 3", n)
+	}
+
+	// second run is a no-op
+	n, err = AccrueInterest(context.Background(), db, accountID, yesterday, 1000)
+	if err != nil {
+		t.Fatalf("AccrueInterest again: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("re-run accrued days=%d want 0", n)
+	}
+
+	var after, entries int64
+	if err := db.QueryRow(context.Background(), `SELECT balance_cents FROM accounts WHERE id = $1`, accountID).Scan(&after); err != nil {
+		t.Fatalf("read balance: %v", err)
+	}
+	if err := db.QueryRow(context.Background(),
+		`SELECT count(*) FROM ledger_entries WHERE account_id = $1 AND entry_type = 'accrued_interest' AND payment_intent_id IS NULL`,
+		accountID,
+	).Scan(&entries); err != nil {
+		t.Fatalf("count accrued entries: %v", err)
+	}
+	if after-before != 3 || entries != 3 {
+		t.Fatalf("balance delta=%d entries=%d, want 3/3", after-before, entries)
+	}
+}
+
+func TestListAccountsDueForAccrual_SkipsPrincipalBookedToday(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+
+	// nothing to accrue through yesterday for principal booked today
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	ids, err := ListAccountsDueForAccrual(context.Background(), db, yesterday, uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("ListAccountsDueForAccrual: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("due = %v, want none", ids)
+	}
+
+	ids, err = ListAccountsDueForAccrual(context.Background(), db, yesterday.AddDate(0, 0, 1), uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("ListAccountsDueForAccrual: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != accountID {
+		t.Fatalf("due = %v, want [%s]", ids, accountID)
+	}
+	// keyset: nothing after the last id
+	if ids, err = ListAccountsDueForAccrual(context.Background(), db, yesterday.AddDate(0, 0, 1), accountID, 10); err != nil || len(ids) != 0 {
+		t.Fatalf("due after %s = %v, %v", accountID, ids, err)
+	}
+}
@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrInterestPolicyNotFound = errors.New("interest policy version not found")
+
+// rowQuerier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// InterestPolicyVersion is a stored policy plus when it takes effect.
+type InterestPolicyVersion struct {
+	Policy        domain.InterestPolicy
+	EffectiveFrom time.Time
+	CreatedAt     time.Time
+}
+
+// policyColumns are the interest_policies columns read by scanPolicy, in order.
+const policyColumns = `p.version, p.base_rate_bps, p.step_bps_per_attempt, p.invalid_amount_fine_cents,
+       p.max_rate_bps, p.attempt_decay_interval_seconds, p.attempt_decay_per_interval, p.attempts_forgiven_per_repayment,
+       p.rounding_mode`
+
+// scanPolicy scans policyColumns followed by extra destinations.
+func scanPolicy(row pgx.Row, p *domain.InterestPolicy, extra ...any) error {
+	var (
+		base, step, fine, maxRate, decaySeconds int64
+		rounding                                string
+	)
+	dest := append([]any{
+		&p.Version, &base, &step, &fine,
+		&maxRate, &decaySeconds, &p.AttemptDecayPerInterval, &p.AttemptsForgivenPerRepayment,
+		&rounding,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	mode, err := money.ParseRoundingMode(rounding)
+	if err != nil {
+		return err
+	}
+	p.Rounding = mode
+	p.BaseRateBPS = money.RateBPS(base)
+	p.StepBPSPerAttempt = money.RateBPS(step)
+	p.InvalidAmountFineCents = money.Cents(fine)
+	p.MaxRateBPS = money.RateBPS(maxRate)
+	p.AttemptDecayInterval = time.Duration(decaySeconds) * time.Second
+	return nil
+}
+
+// LoadAccountPolicy resolves the rules for an account: its pinned version,
+// otherwise the latest version whose effective_from has passed, with the
+// account's base rate / step overrides applied on top.
+func LoadAccountPolicy(ctx context.Context, db rowQuerier, accountID uuid.UUID) (domain.InterestPolicy, error) {
+	const q = `
+select ` + policyColumns + `,
+       o.base_rate_bps, o.step_bps_per_attempt
+from accounts a
+join interest_policies p on p.version = coalesce(
+  a.interest_policy_version,
+  (select version
+     from interest_policies
+    where effective_from <= now()
+    order by effective_from desc, version desc
+    limit 1)
+)
+left join account_interest_overrides o on o.account_id = a.id
+where a.id = $1
+`
+	var (
+		p            domain.InterestPolicy
+		overrideBase *int64
+		overrideStep *int64
+	)
+	if err := scanPolicy(db.QueryRow(ctx, q, accountID), &p, &overrideBase, &overrideStep); err != nil {
+		return domain.InterestPolicy{}, err
+	}
+	return p.WithOverride(rateOrNil(overrideBase), rateOrNil(overrideStep)), nil
+}
+
+func rateOrNil(v *int64) *money.RateBPS {
+	if v == nil {
+		return nil
+	}
+	r := money.RateBPS(*v)
+	return &r
+}
+
+func ListInterestPolicies(ctx context.Context, db *pgxpool.Pool) ([]InterestPolicyVersion, error) {
+	rows, err := db.Query(ctx, `
+select `+policyColumns+`, p.effective_from, p.created_at
+from interest_policies p
+order by p.version asc
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []InterestPolicyVersion
+	for rows.Next() {
+		var v InterestPolicyVersion
+		if err := scanPolicy(rows, &v.Policy, &v.EffectiveFrom, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateInterestPolicy stores a new version; p.Version is ignored and assigned by the DB.
+func CreateInterestPolicy(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	p domain.InterestPolicy,
+	effectiveFrom time.Time,
+) (*InterestPolicyVersion, error) {
+	v := InterestPolicyVersion{Policy: p, EffectiveFrom: effectiveFrom}
+	if err := db.QueryRow(ctx, `
+insert into interest_policies
+  (base_rate_bps, step_bps_per_attempt, invalid_amount_fine_cents, effective_from,
+   max_rate_bps, attempt_decay_interval_seconds, attempt_decay_per_interval, attempts_forgiven_per_repayment,
+   rounding_mode)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+returning version, created_at
+`, int64(p.BaseRateBPS), int64(p.StepBPSPerAttempt), int64(p.InvalidAmountFineCents), effectiveFrom,
+		int64(p.MaxRateBPS), int64(p.AttemptDecayInterval/time.Second), p.AttemptDecayPerInterval, p.AttemptsForgivenPerRepayment,
+		p.Rounding.String(),
+	).Scan(&v.Policy.Version, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// AssignInterestPolicy pins an account to a version; nil unpins it (follow the policy in effect).
+func AssignInterestPolicy(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, version *int64) error {
+	ct, err := db.Exec(ctx, `
+update accounts
+set interest_policy_version = $2,
+    updated_at = now()
+where id = $1
+`, accountID, version)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrInterestPolicyNotFound
+		}
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+)
+
+func TestInterestPolicy_PinnedVersionUsedAndRecordedOnLedger(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	// far-future effective_from so it never becomes the version in effect for other accounts
+	v, err := CreateInterestPolicy(context.Background(), db, domain.InterestPolicy{
+		BaseRateBPS:            20000,
+		StepBPSPerAttempt:      0,
+		InvalidAmountFineCents: 500,
+	}, time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("CreateInterestPolicy: %v", err)
+	}
+
+	// unpinned => version 1 in effect
+	p, err := LoadAccountPolicy(context.Background(), db, accountID)
+	if err != nil {
+		t.Fatalf("LoadAccountPolicy: %v", err)
+	}
+	if p.Version != 1 {
+		t.Fatalf("effective version=%d want 1", p.Version)
+	}
+
+	if err := AssignInterestPolicy(context.Background(), db, accountID, &v.Policy.Version); err != nil {
+		t.Fatalf("AssignInterestPolicy: %v", err)
+	}
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+
+	// 200% flat => interest=20
+	charges, err := GetPaymentIntentCharges(context.Background(), db, pi.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	if charges.InterestCents != 20 {
+		t.Fatalf("interest=%d want 20", charges.InterestCents)
+	}
+
+	var n int64
+	if err := db.QueryRow(context.Background(), `
+SELECT count(*) FROM ledger_entries WHERE payment_intent_id = $1 AND policy_version = $2
+`, pi.ID, v.Policy.Version).Scan(&n); err != nil {
+		t.Fatalf("count ledger by policy: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("ledger rows with policy_version=%d: %d, want 2", v.Policy.Version, n)
+	}
+}
+
+func TestInterestPolicy_CapAndDecayAppliedOnConfirm(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	v, err := CreateInterestPolicy(context.Background(), db, domain.InterestPolicy{
+		BaseRateBPS:             10000,
+		StepBPSPerAttempt:       100,
+		InvalidAmountFineCents:  1000,
+		MaxRateBPS:              15000,
+		AttemptDecayInterval:    24 * time.Hour,
+		AttemptDecayPerInterval: 100,
+	}, time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("CreateInterestPolicy: %v", err)
+	}
+	if err := AssignInterestPolicy(context.Background(), db, accountID, &v.Policy.Version); err != nil {
+		t.Fatalf("AssignInterestPolicy: %v", err)
+	}
+
+	// 500 attempts, last one 2 days ago => decays to 300, priced at attempt 301 => capped at 150%
+	if _, err := db.Exec(context.Background(), `
+UPDATE accounts SET attempt_count = 500, last_attempt_at = now() - interval '49 hours' WHERE id = $1
+`, accountID); err != nil {
+		t.Fatalf("seed attempts: %v", err)
+	}
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+
+	charges, err := GetPaymentIntentCharges(context.Background(), db, pi.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	if charges.InterestCents != 15 {
+		t.Fatalf("interest=%d want 15 (capped)", charges.InterestCents)
+	}
+
+	var attempts int64
+	if err := db.QueryRow(context.Background(),
+		`SELECT attempt_count FROM accounts WHERE id = $1`, accountID,
+	).Scan(&attempts); err != nil {
+		t.Fatalf("read attempts: %v", err)
+	}
+	if attempts != 301 {
+		t.Fatalf("attempt_count=%d want 301", attempts)
+	}
+}
+
+func TestInterestPolicy_RoundingModePersistedAndApplied(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	// 110% flat, half-up => 5 cents costs 5.5 => 6
+	v, err := CreateInterestPolicy(context.Background(), db, domain.InterestPolicy{
+		BaseRateBPS:            11000,
+		InvalidAmountFineCents: 1000,
+		Rounding:               money.RoundHalfUp,
+	}, time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("CreateInterestPolicy: %v", err)
+	}
+	if err := AssignInterestPolicy(context.Background(), db, accountID, &v.Policy.Version); err != nil {
+		t.Fatalf("AssignInterestPolicy: %v", err)
+	}
+
+	p, err := LoadAccountPolicy(context.Background(), db, accountID)
+	if err != nil {
+		t.Fatalf("LoadAccountPolicy: %v", err)
+	}
+	if p.Rounding != money.RoundHalfUp {
+		t.Fatalf("rounding=%s want half_up", p.Rounding)
+	}
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	charges, err := GetPaymentIntentCharges(context.Background(), db, pi.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	if charges.InterestCents != 6 {
+		t.Fatalf("interest=%d want 6", charges.InterestCents)
+	}
+}
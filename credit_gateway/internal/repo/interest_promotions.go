@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// InterestOverride replaces the policy base rate and/or step for one account.
+type InterestOverride struct {
+	AccountID         uuid.UUID
+	BaseRateBPS       *int64
+	StepBPSPerAttempt *int64
+	UpdatedAt         time.Time
+}
+
+// LoadActivePromotion returns the cheapest promotion that applies to attemptCount now, or nil.
+func LoadActivePromotion(
+	ctx context.Context,
+	db rowQuerier,
+	accountID uuid.UUID,
+	attemptCount int64,
+) (*domain.Promotion, error) {
+	var (
+		p    domain.Promotion
+		rate int64
+	)
+	err := db.QueryRow(ctx, `
+select id, rate_bps, max_attempt_count, starts_at, ends_at
+from interest_promotions
+where account_id = $1
+  and starts_at <= now()
+  and (ends_at is null or ends_at > now())
+  and (max_attempt_count is null or $2 <= max_attempt_count)
+order by rate_bps asc, id asc
+limit 1
+`, accountID, attemptCount).Scan(&p.ID, &rate, &p.MaxAttemptCount, &p.StartsAt, &p.EndsAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.RateBPS = money.RateBPS(rate)
+	return &p, nil
+}
+
+func CreatePromotion(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, p domain.Promotion) (*domain.Promotion, error) {
+	if p.StartsAt.IsZero() {
+		p.StartsAt = time.Now().UTC()
+	}
+	if err := db.QueryRow(ctx, `
+insert into interest_promotions (account_id, rate_bps, max_attempt_count, starts_at, ends_at)
+values ($1, $2, $3, $4, $5)
+returning id
+`, accountID, int64(p.RateBPS), p.MaxAttemptCount, p.StartsAt, p.EndsAt).Scan(&p.ID); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func ListPromotions(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID) ([]domain.Promotion, error) {
+	rows, err := db.Query(ctx, `
+select id, rate_bps, max_attempt_count, starts_at, ends_at
+from interest_promotions
+where account_id = $1
+order by id asc
+`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.Promotion
+	for rows.Next() {
+		var (
+			p    domain.Promotion
+			rate int64
+		)
+		if err := rows.Scan(&p.ID, &rate, &p.MaxAttemptCount, &p.StartsAt, &p.EndsAt); err != nil {
+			return nil, err
+		}
+		p.RateBPS = money.RateBPS(rate)
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetInterestOverride upserts the account override; both nil removes it.
+func SetInterestOverride(ctx context.Context, db *pgxpool.Pool, o InterestOverride) (*InterestOverride, error) {
+	if o.BaseRateBPS == nil && o.StepBPSPerAttempt == nil {
+		if _, err := db.Exec(ctx,
+			`delete from account_interest_overrides where account_id = $1`,
+			o.AccountID,
+		); err != nil {
+			return nil, err
+		}
+		return &o, nil
+	}
+
+	if err := db.QueryRow(ctx, `
+insert into account_interest_overrides (account_id, base_rate_bps, step_bps_per_attempt)
+values ($1, $2, $3)
+on conflict (account_id) do update
+set base_rate_bps = excluded.base_rate_bps,
+    step_bps_per_attempt = excluded.step_bps_per_attempt,
+    updated_at = now()
+returning updated_at
+`, o.AccountID, o.BaseRateBPS, o.StepBPSPerAttempt).Scan(&o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestPromotion_ZeroRateForFirstAttemptsRecordedOnLedger(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	maxAttempts := int64(1)
+	promo, err := CreatePromotion(context.Background(), db, accountID, domain.Promotion{
+		RateBPS:         0,
+		MaxAttemptCount: &maxAttempts,
+	})
+	if err != nil {
+		t.Fatalf("CreatePromotion: %v", err)
+	}
+
+	// attempt 1 => promotional 0%
+	pi1, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi1.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	c1, err := GetPaymentIntentCharges(context.Background(), db, pi1.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	if c1.InterestCents != 0 {
+		t.Fatalf("promo interest=%d want 0", c1.InterestCents)
+	}
+
+	var (
+		rate    int64
+		promoID *int64
+	)
+	if err := db.QueryRow(context.Background(), `
+SELECT rate_bps, promotion_id FROM ledger_entries WHERE payment_intent_id = $1 AND entry_type = 'interest'
+`, pi1.ID).Scan(&rate, &promoID); err != nil {
+		t.Fatalf("read ledger: %v", err)
+	}
+	if rate != 0 || promoID == nil || *promoID != promo.ID {
+		t.Fatalf("ledger rate=%d promotion=%v want 0/%d", rate, promoID, promo.ID)
+	}
+
+	// attempt 2 => promotion exhausted, policy rate 100% + 100bps
+	pi2, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi2.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	c2, err := GetPaymentIntentCharges(context.Background(), db, pi2.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	if c2.InterestCents == 0 {
+		t.Fatalf("interest after promotion = 0, want policy rate")
+	}
+}
+
+func TestInterestOverride_ReplacesBaseRate(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	base := int64(5000)
+	if _, err := SetInterestOverride(context.Background(), db, InterestOverride{
+		AccountID:   accountID,
+		BaseRateBPS: &base,
+	}); err != nil {
+		t.Fatalf("SetInterestOverride: %v", err)
+	}
+
+	p, err := LoadAccountPolicy(context.Background(), db, accountID)
+	if err != nil {
+		t.Fatalf("LoadAccountPolicy: %v", err)
+	}
+	if p.BaseRateBPS != 5000 || p.StepBPSPerAttempt != domain.DefaultPolicy().StepBPSPerAttempt {
+		t.Fatalf("policy base=%d step=%d", p.BaseRateBPS, p.StepBPSPerAttempt)
+	}
+
+	// clearing both fields removes the override
+	if _, err := SetInterestOverride(context.Background(), db, InterestOverride{AccountID: accountID}); err != nil {
+		t.Fatalf("SetInterestOverride clear: %v", err)
+	}
+	p, err = LoadAccountPolicy(context.Background(), db, accountID)
+	if err != nil {
+		t.Fatalf("LoadAccountPolicy: %v", err)
+	}
+	if p.BaseRateBPS != domain.DefaultPolicy().BaseRateBPS {
+		t.Fatalf("base after clear=%d", p.BaseRateBPS)
+	}
+}
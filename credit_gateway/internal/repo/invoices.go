@@ -0,0 +1,190 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Invoice is an itemized bill collected through its merchant request.
+type Invoice struct {
+	ID                int64
+	MerchantRequestID int64
+	MerchantID        string
+	Currency          money.Currency
+	Memo              *string
+	SubtotalCents     int64
+	TaxCents          int64
+	TotalCents        int64
+	LineItems         []InvoiceLineItem
+	CreatedAt         time.Time
+}
+
+type InvoiceLineItem struct {
+	Description   string
+	Quantity      int64
+	UnitCents     int64
+	TaxBPS        int64
+	SubtotalCents int64
+	TaxCents      int64
+	TotalCents    int64
+}
+
+type CreateInvoiceInput struct {
+	MerchantID               string
+	MerchantRequestReference *string
+	// Payers share TotalCents of the priced line items (see domain.PriceInvoice).
+	Payers     []MerchantRequestPayer
+	Currency   money.Currency // "" = the first payer's currency
+	WebhookURL *string
+	Memo       *string
+	Metadata   domain.Metadata // stored on the merchant request
+	LineItems  []domain.LineItem
+}
+
+// CreateInvoice prices the line items and opens the merchant request for the total, in
+// one transaction.
+func CreateInvoice(ctx context.Context, db *pgxpool.Pool, in CreateInvoiceInput) (*Invoice, *MerchantRequest, error) {
+	totals, err := domain.PriceInvoice(in.LineItems)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	mr, err := createMerchantRequestTx(ctx, tx, in.MerchantID, in.MerchantRequestReference, in.Payers,
+		int64(totals.Total), in.Currency, in.WebhookURL, in.Metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inv := Invoice{
+		MerchantRequestID: mr.ID,
+		MerchantID:        mr.MerchantID,
+		Currency:          mr.Currency,
+		Memo:              in.Memo,
+		SubtotalCents:     int64(totals.Subtotal),
+		TaxCents:          int64(totals.Tax),
+		TotalCents:        int64(totals.Total),
+	}
+	if err := tx.QueryRow(ctx, `
+insert into invoices (merchant_request_id, merchant_id, currency, memo, subtotal_cents, tax_cents, total_cents)
+values ($1, $2, $3, $4, $5, $6, $7)
+returning id, created_at
+`, inv.MerchantRequestID, inv.MerchantID, inv.Currency, inv.Memo, inv.SubtotalCents, inv.TaxCents, inv.TotalCents,
+	).Scan(&inv.ID, &inv.CreatedAt); err != nil {
+		return nil, nil, err
+	}
+
+	for i, item := range in.LineItems {
+		line := InvoiceLineItem{
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			UnitCents:     int64(item.UnitCents),
+			TaxBPS:        int64(item.TaxBPS),
+			SubtotalCents: int64(totals.Lines[i].Subtotal),
+			TaxCents:      int64(totals.Lines[i].Tax),
+			TotalCents:    int64(totals.Lines[i].Total),
+		}
+		if _, err := tx.Exec(ctx, `
+insert into invoice_line_items
+  (invoice_id, position, description, quantity, unit_cents, tax_bps, subtotal_cents, tax_cents, total_cents)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`, inv.ID, i, line.Description, line.Quantity, line.UnitCents, line.TaxBPS,
+			line.SubtotalCents, line.TaxCents, line.TotalCents); err != nil {
+			return nil, nil, err
+		}
+		inv.LineItems = append(inv.LineItems, line)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return &inv, mr, nil
+}
+
+const invoiceColumns = `id, merchant_request_id, merchant_id, currency, memo, subtotal_cents, tax_cents, total_cents, created_at`
+
+// GetInvoice returns the invoice with its line items.
+func GetInvoice(ctx context.Context, db querier, id int64) (*Invoice, error) {
+	return loadInvoice(ctx, db, `select `+invoiceColumns+` from invoices where id = $1`, id)
+}
+
+// GetMerchantRequestInvoice returns the invoice behind a merchant request, or nil if the
+// request was not opened by one.
+func GetMerchantRequestInvoice(ctx context.Context, db querier, merchantRequestID int64) (*Invoice, error) {
+	inv, err := loadInvoice(ctx, db, `select `+invoiceColumns+` from invoices where merchant_request_id = $1`, merchantRequestID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return inv, err
+}
+
+func loadInvoice(ctx context.Context, db querier, q string, arg int64) (*Invoice, error) {
+	var inv Invoice
+	if err := db.QueryRow(ctx, q, arg).Scan(
+		&inv.ID, &inv.MerchantRequestID, &inv.MerchantID, &inv.Currency, &inv.Memo,
+		&inv.SubtotalCents, &inv.TaxCents, &inv.TotalCents, &inv.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `
+select description, quantity, unit_cents, tax_bps, subtotal_cents, tax_cents, total_cents
+from invoice_line_items
+where invoice_id = $1
+order by position
+`, inv.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inv.LineItems = []InvoiceLineItem{}
+	for rows.Next() {
+		var l InvoiceLineItem
+		if err := rows.Scan(&l.Description, &l.Quantity, &l.UnitCents, &l.TaxBPS,
+			&l.SubtotalCents, &l.TaxCents, &l.TotalCents); err != nil {
+			return nil, err
+		}
+		inv.LineItems = append(inv.LineItems, l)
+	}
+	return &inv, rows.Err()
+}
+
+// invoicePayload is the invoice as carried by merchant_request webhooks.
+func invoicePayload(inv *Invoice) map[string]any {
+	if inv == nil {
+		return nil
+	}
+	lines := make([]map[string]any, 0, len(inv.LineItems))
+	for _, l := range inv.LineItems {
+		lines = append(lines, map[string]any{
+			"description":    l.Description,
+			"quantity":       l.Quantity,
+			"unit_cents":     l.UnitCents,
+			"tax_bps":        l.TaxBPS,
+			"subtotal_cents": l.SubtotalCents,
+			"tax_cents":      l.TaxCents,
+			"total_cents":    l.TotalCents,
+		})
+	}
+	return map[string]any{
+		"invoice_id":     inv.ID,
+		"memo":           inv.Memo,
+		"subtotal_cents": inv.SubtotalCents,
+		"tax_cents":      inv.TaxCents,
+		"total_cents":    inv.TotalCents,
+		"line_items":     lines,
+	}
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestInvoice_OpensMerchantRequestAndRidesCompletionWebhook(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	payer := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, payer, 5000, "active")
+
+	webhook := "http://example.test/webhook"
+	inv, mr, err := CreateInvoice(ctx, db, CreateInvoiceInput{
+		MerchantID:               "merchant_inv",
+		MerchantRequestReference: ptr("inv-1"),
+		Payers:                   []MerchantRequestPayer{{AccountID: payer.String(), ShareCents: 22}},
+		WebhookURL:               &webhook,
+		LineItems: []domain.LineItem{
+			{Description: "sticker", Quantity: 4, UnitCents: 5, TaxBPS: 1000}, // 20 + 2
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateInvoice: %v", err)
+	}
+	if inv.TotalCents != 22 || mr.TargetCents != 22 || inv.MerchantRequestID != mr.ID {
+		t.Fatalf("invoice = %+v, merchant request = %+v", inv, mr)
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		t.Fatalf("begin tx: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	if _, _, completed, err := IncrementMerchantPayerProgress(ctx, tx, mr.ID, payer.String(), 22); err != nil || !completed {
+		t.Fatalf("pay invoice: completed=%v err=%v", completed, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	got, err := GetMerchantRequestByID(ctx, db, mr.ID)
+	if err != nil {
+		t.Fatalf("GetMerchantRequestByID: %v", err)
+	}
+	if got.Invoice == nil || got.Invoice.ID != inv.ID || len(got.Invoice.LineItems) != 1 ||
+		got.Invoice.LineItems[0].TaxCents != 2 {
+		t.Fatalf("merchant request invoice = %+v", got.Invoice)
+	}
+
+	var payload []byte
+	if err := db.QueryRow(ctx, `
+SELECT payload FROM webhook_outbox WHERE aggregate_type = 'merchant_request' AND aggregate_id = $1
+`, mr.ID).Scan(&payload); err != nil {
+		t.Fatalf("load outbox: %v", err)
+	}
+	var event struct {
+		Invoice *struct {
+			InvoiceID  int64 `json:"invoice_id"`
+			TotalCents int64 `json:"total_cents"`
+			LineItems  []struct {
+				Description string `json:"description"`
+			} `json:"line_items"`
+		} `json:"invoice"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if event.Invoice == nil || event.Invoice.InvoiceID != inv.ID || event.Invoice.TotalCents != 22 ||
+		len(event.Invoice.LineItems) != 1 || event.Invoice.LineItems[0].Description != "sticker" {
+		t.Fatalf("webhook invoice = %s", payload)
+	}
+}
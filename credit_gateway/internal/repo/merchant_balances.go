@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MerchantBalance struct {
+	MerchantID   string
+	Currency     money.Currency
+	BalanceCents int64
+	// ImmatureCents: credited (net of fees) within the holdback period, not payable yet
+	ImmatureCents int64
+	// PayableCents / RetainedCents: what a payout run would pay now and keep back
+	PayableCents  int64
+	RetainedCents int64
+	UpdatedAt     time.Time
+}
+
+type Payout struct {
+	ID            int64
+	MerchantID    string
+	Currency      money.Currency
+	AmountCents   int64
+	RetainedCents int64
+	CreatedAt     time.Time
+}
+
+// creditMerchantTx adds a paid installment to the merchant's payable balance, less the fee
+// from the merchant's schedule (booked as its own ledger entry). It returns the fee.
+func creditMerchantTx(ctx context.Context, tx pgx.Tx, merchantID string, currency money.Currency, merchantRequestID int64, grossCents int64) (money.Cents, error) {
+	schedule, err := GetMerchantFeeSchedule(ctx, tx, merchantID)
+	if err != nil {
+		return 0, err
+	}
+	fee, err := schedule.Fee(money.Cents(grossCents))
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+insert into merchant_ledger_entries (merchant_id, currency, entry_type, amount_cents, merchant_request_id)
+values ($1, $2, 'payment', $3, $4)
+`, merchantID, currency, grossCents, merchantRequestID); err != nil {
+		return 0, err
+	}
+	if fee > 0 {
+		if _, err := tx.Exec(ctx, `
+insert into merchant_ledger_entries (merchant_id, currency, entry_type, amount_cents, merchant_request_id)
+values ($1, $2, 'fee', $3, $4)
+`, merchantID, currency, -int64(fee), merchantRequestID); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+insert into merchant_balances (merchant_id, currency, balance_cents)
+values ($1, $2, $3)
+on conflict (merchant_id, currency) do update
+  set balance_cents = merchant_balances.balance_cents + excluded.balance_cents,
+      updated_at = now()
+`, merchantID, currency, grossCents-int64(fee))
+	return fee, err
+}
+
+// merchantBalanceQuery takes the holdback in microseconds as $2; the cutoff is computed
+// from the DB clock, like the created_at it is compared with.
+const merchantBalanceQuery = `
+select b.merchant_id, b.currency, b.balance_cents,
+       coalesce((select sum(l.amount_cents)
+                   from merchant_ledger_entries l
+                  where l.merchant_id = b.merchant_id and l.currency = b.currency
+                    and l.entry_type in ('payment', 'fee') and l.created_at > now() - $2::bigint * interval '1 microsecond'), 0),
+       b.updated_at
+from merchant_balances b
+`
+
+func scanMerchantBalance(row pgx.Row, policy domain.PayoutPolicy) (MerchantBalance, error) {
+	var b MerchantBalance
+	if err := row.Scan(&b.MerchantID, &b.Currency, &b.BalanceCents, &b.ImmatureCents, &b.UpdatedAt); err != nil {
+		return b, err
+	}
+	payable, retained, err := policy.Payout(money.Cents(b.BalanceCents), money.Cents(b.ImmatureCents))
+	if err != nil {
+		return b, err
+	}
+	b.PayableCents, b.RetainedCents = int64(payable), int64(retained)
+	return b, nil
+}
+
+// GetMerchantBalances returns the merchant's balance in every currency it was paid in.
+func GetMerchantBalances(ctx context.Context, db *pgxpool.Pool, merchantID string, policy domain.PayoutPolicy) ([]MerchantBalance, error) {
+	rows, err := db.Query(ctx, merchantBalanceQuery+`
+where b.merchant_id = $1
+order by b.currency
+`, merchantID, policy.Holdback.Microseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MerchantBalance
+	for rows.Next() {
+		b, err := scanMerchantBalance(rows, policy)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// MerchantBalanceKey pages through merchant balances.
+type MerchantBalanceKey struct {
+	MerchantID string
+	Currency   money.Currency
+}
+
+// ListPositiveMerchantBalances pages (keyset, after key) through balances a payout could draw on.
+func ListPositiveMerchantBalances(ctx context.Context, db *pgxpool.Pool, after MerchantBalanceKey, limit int) ([]MerchantBalanceKey, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(ctx, `
+select merchant_id, currency
+from merchant_balances
+where balance_cents > 0
+  and (merchant_id, currency) > ($1, $2)
+order by merchant_id, currency
+limit $3
+`, after.MerchantID, string(after.Currency), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MerchantBalanceKey
+	for rows.Next() {
+		var k MerchantBalanceKey
+		if err := rows.Scan(&k.MerchantID, &k.Currency); err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// CreatePayout pays out what policy allows from one merchant balance, debiting it. It
+// returns nil when nothing is payable (below the minimum, held back or reserved).
+func CreatePayout(ctx context.Context, db *pgxpool.Pool, merchantID string, currency money.Currency, policy domain.PayoutPolicy) (*Payout, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	b, err := scanMerchantBalance(tx.QueryRow(ctx, merchantBalanceQuery+`
+where b.merchant_id = $1 and b.currency = $3
+for update of b
+`, merchantID, policy.Holdback.Microseconds(), currency), policy)
+	if err != nil {
+		return nil, err
+	}
+	if b.PayableCents <= 0 {
+		return nil, nil
+	}
+
+	p := Payout{MerchantID: merchantID, Currency: currency, AmountCents: b.PayableCents, RetainedCents: b.RetainedCents}
+	if err := tx.QueryRow(ctx, `
+insert into payouts (merchant_id, currency, amount_cents, retained_cents)
+values ($1, $2, $3, $4)
+returning id, created_at
+`, p.MerchantID, p.Currency, p.AmountCents, p.RetainedCents).Scan(&p.ID, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+insert into merchant_ledger_entries (merchant_id, currency, entry_type, amount_cents, payout_id)
+values ($1, $2, 'payout', $3, $4)
+`, p.MerchantID, p.Currency, -p.AmountCents, p.ID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+update merchant_balances
+   set balance_cents = balance_cents - $3, updated_at = now()
+ where merchant_id = $1 and currency = $2
+`, p.MerchantID, p.Currency, p.AmountCents); err != nil {
+		return nil, err
+	}
+
+	return &p, tx.Commit(ctx)
+}
+
+// ListPayouts returns the merchant's payouts, newest first.
+func ListPayouts(ctx context.Context, db *pgxpool.Pool, merchantID string, limit int) ([]Payout, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(ctx, `
+select id, merchant_id, currency, amount_cents, retained_cents, created_at
+from payouts
+where merchant_id = $1
+order by id desc
+limit $2
+`, merchantID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Payout
+	for rows.Next() {
+		var p Payout
+		if err := rows.Scan(&p.ID, &p.MerchantID, &p.Currency, &p.AmountCents, &p.RetainedCents, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
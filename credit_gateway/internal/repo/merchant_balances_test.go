@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestMerchantBalance_CreditedByInstallmentsAndPaidOutWithReserve(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+	mrID := createMerchantRequestRow(t, db, "merchant_payout", ptr("order_001"), accountID.String(), 100)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			t.Fatalf("begin tx: %v", err)
+		}
+		if _, _, _, err := IncrementMerchantRequestProgress(ctx, tx, mrID, 10); err != nil {
+			t.Fatalf("IncrementMerchantRequestProgress: %v", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+	}
+
+	held := domain.PayoutPolicy{Holdback: time.Hour, ReserveBPS: 1000, MinPayout: 10}
+	if p, err := CreatePayout(ctx, db, "merchant_payout", money.USD, held); err != nil || p != nil {
+		t.Fatalf("payout inside holdback = %+v, %v; want none", p, err)
+	}
+
+	policy := domain.PayoutPolicy{ReserveBPS: 1000, MinPayout: 10}
+	p, err := CreatePayout(ctx, db, "merchant_payout", money.USD, policy)
+	if err != nil {
+		t.Fatalf("CreatePayout: %v", err)
+	}
+	if p == nil || p.AmountCents != 27 || p.RetainedCents != 3 {
+		t.Fatalf("payout = %+v, want 27 paid, 3 retained", p)
+	}
+
+	balances, err := GetMerchantBalances(ctx, db, "merchant_payout", policy)
+	if err != nil {
+		t.Fatalf("GetMerchantBalances: %v", err)
+	}
+	if len(balances) != 1 || balances[0].BalanceCents != 3 || balances[0].PayableCents != 0 {
+		t.Fatalf("balances after payout = %+v", balances)
+	}
+
+	payouts, err := ListPayouts(ctx, db, "merchant_payout", 10)
+	if err != nil || len(payouts) != 1 || payouts[0].ID != p.ID {
+		t.Fatalf("ListPayouts = %+v, %v", payouts, err)
+	}
+}
+
+func TestMerchantBalance_NetsFeeScheduleFromInstallments(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+	mrID := createMerchantRequestRow(t, db, "merchant_fees", ptr("order_001"), accountID.String(), 40)
+
+	ctx := context.Background()
+	schedule := domain.FeeSchedule{Flat: 1, RateBPS: 500, Rounding: money.RoundHalfUp}
+	if err := SetMerchantFeeSchedule(ctx, db, "merchant_fees", schedule); err != nil {
+		t.Fatalf("SetMerchantFeeSchedule: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			t.Fatalf("begin tx: %v", err)
+		}
+		if _, _, _, err := IncrementMerchantRequestProgress(ctx, tx, mrID, 20); err != nil {
+			t.Fatalf("IncrementMerchantRequestProgress: %v", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+	}
+
+	mr, err := GetMerchantRequestByID(ctx, db, mrID)
+	if err != nil {
+		t.Fatalf("GetMerchantRequestByID: %v", err)
+	}
+	if mr.PaidCents != 40 || mr.FeeCents != 4 {
+		t.Fatalf("merchant request paid=%d fee=%d, want 40 and 4", mr.PaidCents, mr.FeeCents)
+	}
+
+	policy := domain.PayoutPolicy{MinPayout: 1}
+	balances, err := GetMerchantBalances(ctx, db, "merchant_fees", policy)
+	if err != nil {
+		t.Fatalf("GetMerchantBalances: %v", err)
+	}
+	if len(balances) != 1 || balances[0].BalanceCents != 36 {
+		t.Fatalf("balances = %+v, want 36 net of fees", balances)
+	}
+
+	var fees int64
+	if err := db.QueryRow(ctx, `
+select coalesce(sum(amount_cents), 0)
+from merchant_ledger_entries
+where merchant_id = 'merchant_fees' and entry_type = 'fee'
+`).Scan(&fees); err != nil {
+		t.Fatalf("sum fee entries: %v", err)
+	}
+	if fees != -4 {
+		t.Fatalf("fee ledger entries = %d, want -4", fees)
+	}
+}
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrInvalidFeeSchedule = errors.New("fee schedule amounts must be non-negative")
+
+// GetMerchantFeeSchedule returns the merchant's schedule; merchants without one pay no fee.
+func GetMerchantFeeSchedule(ctx context.Context, db rowQuerier, merchantID string) (domain.FeeSchedule, error) {
+	var (
+		s        domain.FeeSchedule
+		rounding string
+	)
+	err := db.QueryRow(ctx, `
+select flat_cents, rate_bps, min_fee_cents, rounding_mode
+from merchant_fee_schedules
+where merchant_id = $1
+`, merchantID).Scan(&s.Flat, &s.RateBPS, &s.MinFee, &rounding)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.FeeSchedule{Rounding: money.RoundHalfUp}, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	s.Rounding, err = money.ParseRoundingMode(rounding)
+	return s, err
+}
+
+// SetMerchantFeeSchedule creates or replaces the merchant's schedule; it applies to
+// installments from now on.
+func SetMerchantFeeSchedule(ctx context.Context, db *pgxpool.Pool, merchantID string, s domain.FeeSchedule) error {
+	if s.Flat < 0 || s.RateBPS < 0 || s.MinFee < 0 {
+		return ErrInvalidFeeSchedule
+	}
+	_, err := db.Exec(ctx, `
+insert into merchant_fee_schedules (merchant_id, flat_cents, rate_bps, min_fee_cents, rounding_mode)
+values ($1, $2, $3, $4, $5)
+on conflict (merchant_id) do update
+  set flat_cents = excluded.flat_cents,
+      rate_bps = excluded.rate_bps,
+      min_fee_cents = excluded.min_fee_cents,
+      rounding_mode = excluded.rounding_mode,
+      updated_at = now()
+`, merchantID, int64(s.Flat), int64(s.RateBPS), int64(s.MinFee), s.Rounding.String())
+	return err
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
 	"github.com/jackc/pgx/v5"
 )
 
@@ -24,11 +27,14 @@ select
   payer_account_id,
   target_cents,
   paid_cents,
+  fee_cents,
   status,
   webhook_url,
   completed_at,
   created_at,
-  updated_at
+  updated_at,
+  metadata,
+  subscription_id
 from merchant_requests
 where id = $1
 for update;
@@ -41,28 +47,51 @@ for update;
 		&mr.PayerAccountID,
 		&mr.TargetCents,
 		&mr.PaidCents,
+		&mr.FeeCents,
 		&mr.Status,
 		&mr.WebhookURL,
 		&mr.CompletedAt,
 		&mr.CreatedAt,
 		&mr.UpdatedAt,
+		&mr.Metadata,
+		&mr.SubscriptionID,
 	); err != nil {
 		return nil, err
 	}
 
+	payers, err := listMerchantRequestPayers(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	mr.Payers = payers
+
 	return &mr, nil
 }
 
+// IncrementMerchantRequestProgress counts an installment paid by the request's first payer.
 func IncrementMerchantRequestProgress(
 	ctx context.Context,
 	tx pgx.Tx,
 	merchantRequestID int64,
 	deltaCents int64,
 ) (paid int64, target int64, completedNow bool, err error) {
+	return IncrementMerchantPayerProgress(ctx, tx, merchantRequestID, "", deltaCents)
+}
+
+// IncrementMerchantPayerProgress counts an installment toward payerAccountID's share
+// ("" = the first payer). The request completes once the target is reached and every
+// payer has paid their share.
+func IncrementMerchantPayerProgress(
+	ctx context.Context,
+	tx pgx.Tx,
+	merchantRequestID int64,
+	payerAccountID string,
+	deltaCents int64,
+) (paid int64, target int64, completedNow bool, err error) {
 
 	// lock + load fields needed for completion + webhook
 	const lockQ = `
-select paid_cents, target_cents, status, merchant_id, merchant_request_reference, webhook_url, payer_account_id
+select paid_cents, target_cents, status, merchant_id, merchant_request_reference, webhook_url, payer_account_id, currency
 from merchant_requests
 where id = $1
 for update;
@@ -73,10 +102,11 @@ for update;
 		merchantRef  *string
 		webhookURL   *string
 		payerAccount string
+		currency     money.Currency
 	)
 	if err = tx.QueryRow(ctx, lockQ, merchantRequestID).Scan(
 		&paid, &target, &status,
-		&merchantID, &merchantRef, &webhookURL, &payerAccount,
+		&merchantID, &merchantRef, &webhookURL, &payerAccount, &currency,
 	); err != nil {
 		return
 	}
@@ -86,6 +116,13 @@ for update;
 		return
 	}
 
+	if payerAccountID == "" {
+		payerAccountID = payerAccount
+	}
+	if err = addPayerProgressTx(ctx, tx, merchantRequestID, payerAccountID, payerAccount, deltaCents); err != nil {
+		return
+	}
+
 	// update progress
 	const updateQ = `
 update merchant_requests
@@ -96,6 +133,18 @@ where id = $1;
 		return
 	}
 
+	// the installment, less the merchant fee, is now owed to the merchant
+	fee, err := creditMerchantTx(ctx, tx, merchantID, currency, merchantRequestID, deltaCents)
+	if err != nil {
+		return
+	}
+	if _, err = tx.Exec(ctx,
+		`update merchant_requests set fee_cents = fee_cents + $2 where id = $1`,
+		merchantRequestID, int64(fee),
+	); err != nil {
+		return
+	}
+
 	// mark completed + capture timestamp only if crossing threshold
 	const completeQ = `
 update merchant_requests
@@ -104,6 +153,10 @@ set status = 'completed',
 where id = $1
   and status = 'pending'
   and paid_cents >= target_cents
+  and not exists (
+    select 1 from merchant_request_payers p
+    where p.merchant_request_id = merchant_requests.id and p.paid_cents < p.share_cents
+  )
 returning completed_at;
 `
 	var completedAt *time.Time
@@ -116,11 +169,15 @@ returning completed_at;
 
 	// read back latest paid/target
 	const readBackQ = `
-select paid_cents, target_cents, status
+select paid_cents, target_cents, status, fee_cents, metadata
 from merchant_requests
 where id = $1;
 `
-	if err = tx.QueryRow(ctx, readBackQ, merchantRequestID).Scan(&paid, &target, &status); err != nil {
+	var (
+		feeTotal int64
+		metadata domain.Metadata
+	)
+	if err = tx.QueryRow(ctx, readBackQ, merchantRequestID).Scan(&paid, &target, &status, &feeTotal, &metadata); err != nil {
 		return
 	}
 
@@ -133,6 +190,23 @@ where id = $1;
 			return
 		}
 
+		var payers []MerchantRequestPayer
+		if payers, err = listMerchantRequestPayers(ctx, tx, merchantRequestID); err != nil {
+			return
+		}
+		var invoice *Invoice
+		if invoice, err = GetMerchantRequestInvoice(ctx, tx, merchantRequestID); err != nil {
+			return
+		}
+		contributions := make([]map[string]any, 0, len(payers))
+		for _, p := range payers {
+			contributions = append(contributions, map[string]any{
+				"payer_account_id": p.AccountID,
+				"share_cents":      p.ShareCents,
+				"paid_cents":       p.PaidCents,
+			})
+		}
+
 		payload := map[string]any{
 			"event":                      "merchant_request.completed",
 			"gateway_request_id":         merchantRequestID,
@@ -141,6 +215,13 @@ where id = $1;
 			"payer_account_id":           payerAccount,
 			"target_cents":               target,
 			"paid_cents":                 paid,
+			"gross_cents":                paid,
+			"fee_cents":                  feeTotal,
+			"net_cents":                  paid - feeTotal,
+			"currency":                   currency,
+			"contributions":              contributions,
+			"metadata":                   metadata,
+			"invoice":                    invoicePayload(invoice), // null unless opened by an invoice
 			"completed_at":               completedAt,
 		}
 
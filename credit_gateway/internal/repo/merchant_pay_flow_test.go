@@ -6,8 +6,6 @@ import (
 	"testing"
 	"time"
 
-	"gateway/internal/domain"
-
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
@@ -62,12 +60,12 @@ func TestMerchantPay_TwoStep_Fulfills1DollarIn10Confirms(t *testing.T) {
 		}
 		defer tx.Rollback(ctx)
 
-		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10)
+		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10, 0)
 		if err != nil {
 			t.Fatalf("CreateMerchantPayIntentTx: %v", err)
 		}
 
-		if err := ConfirmPaymentTx(ctx, tx, pi.ID, domain.DefaultPolicy()); err != nil {
+		if err := ConfirmPaymentTx(ctx, tx, pi.ID); err != nil {
 			t.Fatalf("ConfirmPaymentTx: %v", err)
 		}
 
@@ -112,12 +110,12 @@ func TestMerchantPay_InsufficientCreditMidway_LocksAndStopsProgress(t *testing.T
 		}
 		defer tx.Rollback(ctx)
 
-		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10)
+		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10, 0)
 		if err != nil {
 			return err
 		}
 
-		err = ConfirmPaymentTx(ctx, tx, pi.ID, domain.DefaultPolicy())
+		err = ConfirmPaymentTx(ctx, tx, pi.ID)
 		if err != nil {
 			// business errors should commit to persist refused/locked
 			if errors.Is(err, ErrInsufficientCredit) || errors.Is(err, ErrMoreThan10Cents) || errors.Is(err, ErrAccountLocked) {
@@ -171,7 +169,7 @@ func TestMerchantPay_MappingRequired_ConfirmWithoutMappingNotFound(t *testing.T)
 	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	seedAccount(t, db, accountID, 5000, "active")
 
-	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10)
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
 	if err != nil {
 		t.Fatalf("CreatePaymentIntent: %v", err)
 	}
@@ -209,12 +207,12 @@ func TestMerchantRequestCompletion_EnqueuesWebhookOutbox(t *testing.T) {
 		}
 		defer tx.Rollback(ctx)
 
-		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10)
+		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10, 0)
 		if err != nil {
 			t.Fatalf("CreateMerchantPayIntentTx: %v", err)
 		}
 
-		if err := ConfirmPaymentTx(ctx, tx, pi.ID, domain.DefaultPolicy()); err != nil {
+		if err := ConfirmPaymentTx(ctx, tx, pi.ID); err != nil {
 			t.Fatalf("ConfirmPaymentTx: %v", err)
 		}
 
@@ -272,13 +270,13 @@ func TestMerchantConfirm_Idempotent_NoDoubleProgress(t *testing.T) {
 	}
 	defer tx.Rollback(ctx)
 
-	pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10)
+	pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// confirm once
-	if err := ConfirmPaymentTx(ctx, tx, pi.ID, domain.DefaultPolicy()); err != nil {
+	if err := ConfirmPaymentTx(ctx, tx, pi.ID); err != nil {
 		t.Fatal(err)
 	}
 	first, err := TryMarkMerchantPayProgressedTx(ctx, tx, pi.ID)
@@ -2,6 +2,10 @@ package repo
 
 import (
 	"context"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -13,9 +17,22 @@ func CreateMerchantPayIntentTx(
 	merchantRequestID int64,
 	accountID uuid.UUID,
 	amountCents int64,
+	ttl time.Duration,
 ) (*PaymentIntent, error) {
 
-	pi, err := CreatePaymentIntentTx(ctx, tx, accountID, amountCents)
+	// the payer pays in the request's currency; the intent carries the request's metadata
+	var (
+		currency money.Currency
+		metadata domain.Metadata
+	)
+	if err := tx.QueryRow(ctx,
+		`select currency, metadata from merchant_requests where id = $1`,
+		merchantRequestID,
+	).Scan(&currency, &metadata); err != nil {
+		return nil, err
+	}
+
+	pi, err := insertPaymentIntent(ctx, tx, accountID, money.Amount{Value: money.Cents(amountCents), Currency: currency}, ttl, metadata)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrNotMerchantRequestPayer = errors.New("account is not a payer of this merchant request")
+	ErrPayerRequired           = errors.New("payer_account_id is required for a split merchant request")
+	ErrDuplicatePayer          = errors.New("payer listed more than once")
+	ErrInstallmentExceedsShare = errors.New("installment is more than the payer's outstanding share")
+)
+
+// MerchantRequestPayer is one payer's share of a merchant request and what they paid of it.
+type MerchantRequestPayer struct {
+	AccountID  string
+	ShareCents int64
+	PaidCents  int64
+}
+
+// Outstanding is what the payer still owes on their share.
+func (p MerchantRequestPayer) Outstanding() int64 {
+	return max(p.ShareCents-p.PaidCents, 0)
+}
+
+// FindPayer returns the payer with accountID; an empty accountID picks the only payer
+// of a single-payer request.
+func (mr *MerchantRequest) FindPayer(accountID string) (*MerchantRequestPayer, error) {
+	if accountID == "" {
+		if len(mr.Payers) != 1 {
+			return nil, ErrPayerRequired
+		}
+		return &mr.Payers[0], nil
+	}
+	for i := range mr.Payers {
+		if mr.Payers[i].AccountID == accountID {
+			return &mr.Payers[i], nil
+		}
+	}
+	return nil, ErrNotMerchantRequestPayer
+}
+
+// listMerchantRequestPayers returns the payers in the order they were given. A request
+// without payer rows is owed in full by its payer_account_id.
+func listMerchantRequestPayers(ctx context.Context, db querier, merchantRequestID int64) ([]MerchantRequestPayer, error) {
+	rows, err := db.Query(ctx, `
+select account_id::text, share_cents, paid_cents
+from (
+  select p.account_id, p.share_cents, p.paid_cents, p.position
+  from merchant_request_payers p
+  where p.merchant_request_id = $1
+  union all
+  select mr.payer_account_id, mr.target_cents, mr.paid_cents, 0
+  from merchant_requests mr
+  where mr.id = $1
+    and not exists (select 1 from merchant_request_payers p where p.merchant_request_id = mr.id)
+) payers
+order by position
+`, merchantRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MerchantRequestPayer
+	for rows.Next() {
+		var p MerchantRequestPayer
+		if err := rows.Scan(&p.AccountID, &p.ShareCents, &p.PaidCents); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func insertMerchantRequestPayersTx(ctx context.Context, tx pgx.Tx, merchantRequestID int64, payers []MerchantRequestPayer) error {
+	for i, p := range payers {
+		if _, err := tx.Exec(ctx, `
+insert into merchant_request_payers (merchant_request_id, account_id, position, share_cents)
+values ($1, $2, $3, $4)
+`, merchantRequestID, p.AccountID, i, p.ShareCents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPayerProgressTx counts an installment toward the payer's share, refusing with
+// ErrInstallmentExceedsShare one that is more than the payer still owes. On a request
+// without payer rows only its payer_account_id may pay, up to the target.
+func addPayerProgressTx(ctx context.Context, tx pgx.Tx, merchantRequestID int64, accountID string, primary string, deltaCents int64) error {
+	ct, err := tx.Exec(ctx, `
+update merchant_request_payers
+   set paid_cents = paid_cents + $3
+ where merchant_request_id = $1 and account_id = $2
+   and paid_cents + $3 <= share_cents
+`, merchantRequestID, accountID, deltaCents)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 1 {
+		return nil
+	}
+
+	var split, payer bool
+	if err := tx.QueryRow(ctx, `
+select exists (select 1 from merchant_request_payers where merchant_request_id = $1),
+       exists (select 1 from merchant_request_payers where merchant_request_id = $1 and account_id = $2)
+`, merchantRequestID, accountID).Scan(&split, &payer); err != nil {
+		return err
+	}
+	if payer {
+		return ErrInstallmentExceedsShare
+	}
+	if split || accountID != primary {
+		return ErrNotMerchantRequestPayer
+	}
+
+	var fits bool
+	if err := tx.QueryRow(ctx,
+		`select paid_cents + $2 <= target_cents from merchant_requests where id = $1`,
+		merchantRequestID, deltaCents,
+	).Scan(&fits); err != nil {
+		return err
+	}
+	if !fits {
+		return ErrInstallmentExceedsShare
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestMerchantRequest_SplitCompletesOnlyWhenEveryShareIsPaid(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	alice := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	bob := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	carol := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	for _, id := range []uuid.UUID{alice, bob, carol} {
+		seedAccount(t, db, id, 5000, "active")
+	}
+
+	webhook := "http://example.test/webhook"
+	mr, err := CreateMerchantRequest(ctx, db, "merchant_split", ptr("dinner"), []MerchantRequestPayer{
+		{AccountID: alice.String(), ShareCents: 60},
+		{AccountID: bob.String(), ShareCents: 40},
+	}, 100, "", &webhook, nil)
+	if err != nil {
+		t.Fatalf("CreateMerchantRequest: %v", err)
+	}
+
+	pay := func(payer uuid.UUID, cents int64) (bool, error) {
+		t.Helper()
+		tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			t.Fatalf("begin tx: %v", err)
+		}
+		defer tx.Rollback(ctx)
+		_, _, completed, err := IncrementMerchantPayerProgress(ctx, tx, mr.ID, payer.String(), cents)
+		if err != nil {
+			return false, err
+		}
+		return completed, tx.Commit(ctx)
+	}
+
+	if _, err := pay(carol, 10); !errors.Is(err, ErrNotMerchantRequestPayer) {
+		t.Fatalf("pay by non-payer err = %v, want ErrNotMerchantRequestPayer", err)
+	}
+	// a share cannot be overpaid, so one payer never reaches the target alone
+	if _, err := pay(alice, 70); !errors.Is(err, ErrInstallmentExceedsShare) {
+		t.Fatalf("alice pays 70 of 60: err = %v, want ErrInstallmentExceedsShare", err)
+	}
+	if completed, err := pay(alice, 60); err != nil || completed {
+		t.Fatalf("alice pays 60: completed=%v err=%v; want pending", completed, err)
+	}
+	if completed, err := pay(bob, 30); err != nil || completed {
+		t.Fatalf("bob pays 30: completed=%v err=%v; want pending", completed, err)
+	}
+	if completed, err := pay(bob, 10); err != nil || !completed {
+		t.Fatalf("bob pays 10: completed=%v err=%v; want completed", completed, err)
+	}
+
+	got, err := GetMerchantRequestByID(ctx, db, mr.ID)
+	if err != nil {
+		t.Fatalf("GetMerchantRequestByID: %v", err)
+	}
+	if got.Status != "completed" || len(got.Payers) != 2 ||
+		got.Payers[0].PaidCents != 60 || got.Payers[1].PaidCents != 40 {
+		t.Fatalf("merchant request = %+v", got)
+	}
+
+	var payload []byte
+	if err := db.QueryRow(ctx, `
+SELECT payload FROM webhook_outbox WHERE aggregate_type = 'merchant_request' AND aggregate_id = $1
+`, mr.ID).Scan(&payload); err != nil {
+		t.Fatalf("load outbox: %v", err)
+	}
+	var event struct {
+		Contributions []struct {
+			PayerAccountID string `json:"payer_account_id"`
+			PaidCents      int64  `json:"paid_cents"`
+		} `json:"contributions"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if len(event.Contributions) != 2 || event.Contributions[0].PayerAccountID != alice.String() ||
+		event.Contributions[1].PaidCents != 40 {
+		t.Fatalf("contributions = %+v", event.Contributions)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -17,33 +20,99 @@ type MerchantRequest struct {
 	MerchantID               string
 	MerchantRequestReference *string
 	TargetCents              int64
+	Currency                 money.Currency
 	PaidCents                int64
+	FeeCents                 int64 // merchant fees kept from PaidCents so far
 	Status                   string
 	WebhookURL               *string
 	CompletedAt              *time.Time
 	CreatedAt                time.Time
 	UpdatedAt                time.Time
-	PayerAccountID           string
+	PayerAccountID           string // the first payer
+	Payers                   []MerchantRequestPayer
+	Metadata                 domain.Metadata
+	Invoice                  *Invoice // set by GetMerchantRequestByID when an invoice opened the request
+	SubscriptionID           *int64   // set when a subscription opened the request for a period
 }
 
+// CreateMerchantRequest opens a request in currency ("" = the first payer's currency),
+// which must be every payer account's currency since the payers' intents are charged in
+// it. Payer shares must add up to targetCents. The request's pay intents inherit metadata.
 func CreateMerchantRequest(ctx context.Context, db *pgxpool.Pool,
 	merchantID string,
 	merchantRequestRefrence *string,
-	payerAccountID string,
+	payers []MerchantRequestPayer,
 	targetCents int64,
+	currency money.Currency,
 	webhookURL *string,
+	metadata domain.Metadata,
 ) (*MerchantRequest, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	mr, err := createMerchantRequestTx(ctx, tx, merchantID, merchantRequestRefrence, payers, targetCents, currency, webhookURL, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return mr, nil
+}
+
+func createMerchantRequestTx(ctx context.Context, tx pgx.Tx,
+	merchantID string,
+	merchantRequestRefrence *string,
+	payers []MerchantRequestPayer,
+	targetCents int64,
+	currency money.Currency,
+	webhookURL *string,
+	metadata domain.Metadata,
+) (*MerchantRequest, error) {
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(payers))
+	var shares int64
+	for i, p := range payers {
+		if seen[p.AccountID] {
+			return nil, ErrDuplicatePayer
+		}
+		seen[p.AccountID] = true
+		shares += p.ShareCents
+
+		var payerCurrency money.Currency
+		if err := tx.QueryRow(ctx,
+			`select currency from accounts where id = $1`,
+			p.AccountID,
+		).Scan(&payerCurrency); err != nil {
+			return nil, err
+		}
+		if currency == "" && i == 0 {
+			currency = payerCurrency
+		}
+		if currency != payerCurrency {
+			return nil, money.ErrCurrencyMismatch
+		}
+	}
+	if len(payers) == 0 || shares != targetCents {
+		return nil, domain.ErrInvalidSplit
+	}
 
 	const q = `
 insert into merchant_requests
-  (merchant_id, merchant_request_reference, payer_account_id, target_cents, webhook_url)
+  (merchant_id, merchant_request_reference, payer_account_id, target_cents, currency, webhook_url, metadata)
 values
-  ($1, $2, $3, $4, $5)
+  ($1, $2, $3, $4, $5, $6, $7)
 returning
-  id, merchant_id, merchant_request_reference, payer_account_id, target_cents, paid_cents, status, webhook_url,
-  completed_at, created_at, updated_at;
+  id, merchant_id, merchant_request_reference, payer_account_id, target_cents, currency, paid_cents, fee_cents, status, webhook_url,
+  completed_at, created_at, updated_at, metadata;
 `
-	row := db.QueryRow(ctx, q, merchantID, merchantRequestRefrence, payerAccountID, targetCents, webhookURL)
+	row := tx.QueryRow(ctx, q, merchantID, merchantRequestRefrence, payers[0].AccountID, targetCents, currency, webhookURL, metadata.OrEmpty())
 
 	var mr MerchantRequest
 	if err := row.Scan(
@@ -52,12 +121,15 @@ returning
 		&mr.MerchantRequestReference,
 		&mr.PayerAccountID,
 		&mr.TargetCents,
+		&mr.Currency,
 		&mr.PaidCents,
+		&mr.FeeCents,
 		&mr.Status,
 		&mr.WebhookURL,
 		&mr.CompletedAt,
 		&mr.CreatedAt,
 		&mr.UpdatedAt,
+		&mr.Metadata,
 	); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -66,14 +138,19 @@ returning
 		return nil, err
 	}
 
+	if err := insertMerchantRequestPayersTx(ctx, tx, mr.ID, payers); err != nil {
+		return nil, err
+	}
+
+	mr.Payers = payers
 	return &mr, nil
 }
 
 func GetMerchantRequestByID(ctx context.Context, db *pgxpool.Pool, id int64) (*MerchantRequest, error) {
 	const q = `
 select
-  id, merchant_id, merchant_request_reference, payer_account_id, target_cents, paid_cents, status, webhook_url,
-  completed_at, created_at, updated_at
+  id, merchant_id, merchant_request_reference, payer_account_id, target_cents, currency, paid_cents, fee_cents, status, webhook_url,
+  completed_at, created_at, updated_at, metadata, subscription_id
 from merchant_requests
 where id = $1
 limit 1;
@@ -87,12 +164,16 @@ limit 1;
 		&mr.MerchantRequestReference,
 		&mr.PayerAccountID,
 		&mr.TargetCents,
+		&mr.Currency,
 		&mr.PaidCents,
+		&mr.FeeCents,
 		&mr.Status,
 		&mr.WebhookURL,
 		&mr.CompletedAt,
 		&mr.CreatedAt,
 		&mr.UpdatedAt,
+		&mr.Metadata,
+		&mr.SubscriptionID,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, pgx.ErrNoRows
@@ -100,5 +181,70 @@ limit 1;
 		return nil, err
 	}
 
+	payers, err := listMerchantRequestPayers(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	mr.Payers = payers
+
+	if mr.Invoice, err = GetMerchantRequestInvoice(ctx, db, id); err != nil {
+		return nil, err
+	}
+
 	return &mr, nil
 }
+
+// MerchantRequestFilter narrows ListMerchantRequests; zero fields match everything.
+type MerchantRequestFilter struct {
+	MerchantID string
+	Status     string
+	Metadata   domain.Metadata // every pair must be present
+	Limit      int
+}
+
+// ListMerchantRequests returns matching requests, newest first, without payers or invoice.
+func ListMerchantRequests(ctx context.Context, db *pgxpool.Pool, f MerchantRequestFilter) ([]MerchantRequest, error) {
+	if f.Limit <= 0 {
+		f.Limit = 100
+	}
+	rows, err := db.Query(ctx, `
+select
+  id, merchant_id, merchant_request_reference, payer_account_id, target_cents, currency, paid_cents, fee_cents, status, webhook_url,
+  completed_at, created_at, updated_at, metadata
+from merchant_requests
+where ($1 = '' or merchant_id = $1)
+  and ($2 = '' or status = $2)
+  and metadata @> $3
+order by id desc
+limit $4
+`, f.MerchantID, f.Status, f.Metadata.OrEmpty(), f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MerchantRequest
+	for rows.Next() {
+		var mr MerchantRequest
+		if err := rows.Scan(
+			&mr.ID,
+			&mr.MerchantID,
+			&mr.MerchantRequestReference,
+			&mr.PayerAccountID,
+			&mr.TargetCents,
+			&mr.Currency,
+			&mr.PaidCents,
+			&mr.FeeCents,
+			&mr.Status,
+			&mr.WebhookURL,
+			&mr.CompletedAt,
+			&mr.CreatedAt,
+			&mr.UpdatedAt,
+			&mr.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, mr)
+	}
+	return out, rows.Err()
+}
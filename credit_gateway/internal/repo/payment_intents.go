@@ -2,38 +2,303 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrPaymentIntentNotCancelable = errors.New("payment intent not cancelable")
+
+var ErrPaymentIntentExpired = errors.New("payment intent expired")
+
 type PaymentIntent struct {
-	ID        uuid.UUID
-	AccountID uuid.UUID
-	Amount    int64
-	Status    string
+	ID         uuid.UUID
+	AccountID  uuid.UUID
+	Amount     int64
+	Currency   money.Currency
+	Status     string
+	CreatedAt  time.Time
+	CanceledAt *time.Time
+	ExpiresAt  *time.Time
+	Metadata   domain.Metadata
+}
+
+// IntentCharges sums the ledger entries posted for a single payment intent.
+type IntentCharges struct {
+	PrincipalCents int64
+	InterestCents  int64
+	PenaltyCents   int64
 }
 
+// CreatePaymentIntent creates a pending intent in the account's currency.
 func CreatePaymentIntent(
 	ctx context.Context,
 	db *pgxpool.Pool,
 	accountID uuid.UUID,
 	amountCents int64,
+	ttl time.Duration,
 ) (*PaymentIntent, error) {
-	id := uuid.New()
+	return insertPaymentIntent(ctx, db, accountID, money.Amount{Value: money.Cents(amountCents)}, ttl, nil)
+}
 
-	const q = `
-INSERT INTO payment_intents (id, account_id, amount_cents, status)
-VALUES ($1, $2, $3, 'pending')
-`
-	if _, err := db.Exec(ctx, q, id, accountID, amountCents); err != nil {
+// CreatePaymentIntentInCurrency creates a pending intent for amount, which must be in the
+// account's currency (money.ErrCurrencyMismatch otherwise), carrying the client's metadata.
+func CreatePaymentIntentInCurrency(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	accountID uuid.UUID,
+	amount money.Amount,
+	ttl time.Duration,
+	metadata domain.Metadata,
+) (*PaymentIntent, error) {
+	return insertPaymentIntent(ctx, db, accountID, amount, ttl, metadata)
+}
+
+// insertPaymentIntent takes the currency from the account; a non-empty amount.Currency
+// must match it. A missing account is pgx.ErrNoRows.
+func insertPaymentIntent(
+	ctx context.Context,
+	db rowQuerier,
+	accountID uuid.UUID,
+	amount money.Amount,
+	ttl time.Duration,
+	metadata domain.Metadata,
+) (*PaymentIntent, error) {
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+
+	var accountCurrency money.Currency
+	if err := db.QueryRow(ctx,
+		`select currency from accounts where id = $1`,
+		accountID,
+	).Scan(&accountCurrency); err != nil {
 		return nil, err
 	}
+	if amount.Currency != "" && amount.Currency != accountCurrency {
+		return nil, money.ErrCurrencyMismatch
+	}
 
-	return &PaymentIntent{
-		ID:        id,
+	const q = `
+insert into payment_intents (id, account_id, amount_cents, currency, status, expires_at, metadata)
+values ($1, $2, $3, $4, 'pending', now() + $5::bigint * interval '1 microsecond', $6)
+returning created_at, expires_at
+`
+	pi := PaymentIntent{
+		ID:        uuid.New(),
 		AccountID: accountID,
-		Amount:    amountCents,
+		Amount:    int64(amount.Value),
+		Currency:  accountCurrency,
 		Status:    "pending",
-	}, nil
+		Metadata:  metadata.OrEmpty(),
+	}
+	if err := db.QueryRow(ctx, q, pi.ID, accountID, pi.Amount, pi.Currency, ttlMicros(ttl), pi.Metadata).Scan(&pi.CreatedAt, &pi.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &pi, nil
+}
+
+func GetPaymentIntentByID(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (*PaymentIntent, error) {
+	const q = `
+select id, account_id, amount_cents, currency, status, created_at, canceled_at, expires_at, metadata
+from payment_intents
+where id = $1
+`
+	var pi PaymentIntent
+	if err := db.QueryRow(ctx, q, id).Scan(
+		&pi.ID,
+		&pi.AccountID,
+		&pi.Amount,
+		&pi.Currency,
+		&pi.Status,
+		&pi.CreatedAt,
+		&pi.CanceledAt,
+		&pi.ExpiresAt,
+		&pi.Metadata,
+	); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// PaymentIntentFilter narrows ListPaymentIntents; zero fields match everything.
+type PaymentIntentFilter struct {
+	AccountID *uuid.UUID
+	Status    string
+	Metadata  domain.Metadata // every pair must be present
+	Limit     int
+}
+
+// ListPaymentIntents returns matching intents, newest first.
+func ListPaymentIntents(ctx context.Context, db *pgxpool.Pool, f PaymentIntentFilter) ([]PaymentIntent, error) {
+	if f.Limit <= 0 {
+		f.Limit = 100
+	}
+	rows, err := db.Query(ctx, `
+select id, account_id, amount_cents, currency, status, created_at, canceled_at, expires_at, metadata
+from payment_intents
+where ($1::uuid is null or account_id = $1)
+  and ($2 = '' or status = $2)
+  and metadata @> $3
+order by created_at desc, id
+limit $4
+`, f.AccountID, f.Status, f.Metadata.OrEmpty(), f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PaymentIntent
+	for rows.Next() {
+		var pi PaymentIntent
+		if err := rows.Scan(
+			&pi.ID,
+			&pi.AccountID,
+			&pi.Amount,
+			&pi.Currency,
+			&pi.Status,
+			&pi.CreatedAt,
+			&pi.CanceledAt,
+			&pi.ExpiresAt,
+			&pi.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, pi)
+	}
+	return out, rows.Err()
+}
+
+// GetPaymentIntentAccountTx reads the account an intent charges, and the amount, inside tx.
+func GetPaymentIntentAccountTx(ctx context.Context, tx pgx.Tx, id uuid.UUID) (uuid.UUID, int64, error) {
+	var (
+		accountID   uuid.UUID
+		amountCents int64
+	)
+	if err := tx.QueryRow(ctx,
+		`select account_id, amount_cents from payment_intents where id = $1`,
+		id,
+	).Scan(&accountID, &amountCents); err != nil {
+		return uuid.Nil, 0, err
+	}
+	return accountID, amountCents, nil
+}
+
+// GetPaymentIntentStatusTx reads the current status of an intent inside tx.
+func GetPaymentIntentStatusTx(ctx context.Context, tx pgx.Tx, id uuid.UUID) (string, error) {
+	var status string
+	if err := tx.QueryRow(ctx,
+		`select status from payment_intents where id = $1`,
+		id,
+	).Scan(&status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+func GetPaymentIntentCharges(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (*IntentCharges, error) {
+	const q = `
+select
+  coalesce(sum(amount_cents) filter (where entry_type = 'principal'), 0),
+  coalesce(sum(amount_cents) filter (where entry_type = 'interest'), 0),
+  coalesce(sum(amount_cents) filter (where entry_type = 'penalty'), 0)
+from ledger_entries
+where payment_intent_id = $1
+`
+	var c IntentCharges
+	if err := db.QueryRow(ctx, q, id).Scan(
+		&c.PrincipalCents,
+		&c.InterestCents,
+		&c.PenaltyCents,
+	); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CancelPaymentIntent moves a pending intent to canceled.
+// Canceling an already canceled intent is a no-op; any other status is rejected.
+func CancelPaymentIntent(ctx context.Context, db *pgxpool.Pool, id uuid.UUID) (*PaymentIntent, error) {
+	const q = `
+update payment_intents
+set status = 'canceled',
+    canceled_at = now()
+where id = $1
+  and status = 'pending'
+returning id, account_id, amount_cents, currency, status, created_at, canceled_at, expires_at, metadata
+`
+	var pi PaymentIntent
+	err := db.QueryRow(ctx, q, id).Scan(
+		&pi.ID,
+		&pi.AccountID,
+		&pi.Amount,
+		&pi.Currency,
+		&pi.Status,
+		&pi.CreatedAt,
+		&pi.CanceledAt,
+		&pi.ExpiresAt,
+		&pi.Metadata,
+	)
+	if err == nil {
+		return &pi, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// not pending (or missing) -> decide from current state
+	cur, err := GetPaymentIntentByID(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if cur.Status == "canceled" {
+		return cur, nil
+	}
+	return cur, ErrPaymentIntentNotCancelable
+}
+
+// ttlMicros is ttl for `now() + $n::bigint * interval '1 microsecond'`, so expiries come
+// from the DB clock the expiry checks compare against. A non-positive ttl is nil, which
+// makes the expiry null (never expires).
+func ttlMicros(ttl time.Duration) *int64 {
+	if ttl <= 0 {
+		return nil
+	}
+	us := ttl.Microseconds()
+	return &us
+}
+
+// ExpirePaymentIntents marks up to limit pending intents past their expires_at as expired.
+// Merchant pay intents are plain payment_intents rows, so they expire the same way.
+func ExpirePaymentIntents(ctx context.Context, db *pgxpool.Pool, limit int) (int64, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	ct, err := db.Exec(ctx, `
+UPDATE payment_intents
+SET status = 'expired'
+WHERE id IN (
+  SELECT id
+  FROM payment_intents
+  WHERE status = 'pending'
+    AND expires_at IS NOT NULL
+    AND expires_at <= now()
+  ORDER BY expires_at ASC
+  FOR UPDATE SKIP LOCKED
+  LIMIT $1
+)
+  AND status = 'pending'
+`, limit)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
 }
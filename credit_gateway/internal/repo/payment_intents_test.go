@@ -0,0 +1,283 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+)
+
+func TestCancelPaymentIntent_PendingCanceled_ConfirmIsNoop(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	canceled, err := CancelPaymentIntent(context.Background(), db, pi.ID)
+	if err != nil {
+		t.Fatalf("CancelPaymentIntent: %v", err)
+	}
+	if canceled.Status != "canceled" || canceled.CanceledAt == nil {
+		t.Fatalf("status=%q canceled_at=%v, want canceled with timestamp", canceled.Status, canceled.CanceledAt)
+	}
+
+	// second cancel is a no-op
+	if _, err := CancelPaymentIntent(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("second CancelPaymentIntent: %v", err)
+	}
+
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment on canceled intent should be no-op, got: %v", err)
+	}
+
+	if got := getIntentStatus(t, db, pi.ID); got != "canceled" {
+		t.Fatalf("intent status = %q, want canceled", got)
+	}
+	if got := countLedgerByIntent(t, db, pi.ID); got != 0 {
+		t.Fatalf("ledger rows = %d, want 0", got)
+	}
+	_, balance, _, attempts := getAccountState(t, db, accountID)
+	if balance != 0 || attempts != 0 {
+		t.Fatalf("account changed unexpectedly balance=%d attempts=%d", balance, attempts)
+	}
+}
+
+func TestCancelPaymentIntent_Succeeded_NotCancelable(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+
+	_, err = CancelPaymentIntent(context.Background(), db, pi.ID)
+	if !errors.Is(err, ErrPaymentIntentNotCancelable) {
+		t.Fatalf("err=%v, want ErrPaymentIntentNotCancelable", err)
+	}
+
+	charges, err := GetPaymentIntentCharges(context.Background(), db, pi.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	// attempt=1 => 101% => interest=floor(5*1.01)=5
+	if charges.PrincipalCents != 5 || charges.InterestCents != 5 || charges.PenaltyCents != 0 {
+		t.Fatalf("charges=%+v, want principal=5 interest=5 penalty=0", *charges)
+	}
+}
+
+func expireIntentNow(t *testing.T, db dbExecQuery, intentID uuid.UUID) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := db.Exec(ctx, `
+UPDATE payment_intents SET expires_at = now() - interval '1 second' WHERE id = $1
+`, intentID); err != nil {
+		t.Fatalf("expireIntentNow: %v", err)
+	}
+}
+
+func TestConfirmPayment_ExpiredIntent_RejectedAndMarkedExpired(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, time.Hour)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if pi.ExpiresAt == nil {
+		t.Fatalf("expires_at not set for ttl=1h")
+	}
+	expireIntentNow(t, db, pi.ID)
+
+	err = ConfirmPayment(context.Background(), db, pi.ID)
+	if !errors.Is(err, ErrPaymentIntentExpired) {
+		t.Fatalf("err=%v, want ErrPaymentIntentExpired", err)
+	}
+
+	if got := getIntentStatus(t, db, pi.ID); got != "expired" {
+		t.Fatalf("intent status = %q, want expired", got)
+	}
+	if got := countLedgerByIntent(t, db, pi.ID); got != 0 {
+		t.Fatalf("ledger rows = %d, want 0", got)
+	}
+	_, balance, _, attempts := getAccountState(t, db, accountID)
+	if balance != 0 || attempts != 0 {
+		t.Fatalf("account changed unexpectedly balance=%d attempts=%d", balance, attempts)
+	}
+}
+
+func TestExpirePaymentIntents_OnlyStalePending(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	stale, err := CreatePaymentIntent(context.Background(), db, accountID, 5, time.Hour)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	fresh, err := CreatePaymentIntent(context.Background(), db, accountID, 5, time.Hour)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	forever, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	expireIntentNow(t, db, stale.ID)
+
+	n, err := ExpirePaymentIntents(context.Background(), db, 10)
+	if err != nil {
+		t.Fatalf("ExpirePaymentIntents: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expired=%d want 1", n)
+	}
+
+	if got := getIntentStatus(t, db, stale.ID); got != "expired" {
+		t.Fatalf("stale status = %q, want expired", got)
+	}
+	if got := getIntentStatus(t, db, fresh.ID); got != "pending" {
+		t.Fatalf("fresh status = %q, want pending", got)
+	}
+	if got := getIntentStatus(t, db, forever.ID); got != "pending" {
+		t.Fatalf("no-ttl status = %q, want pending", got)
+	}
+}
+
+func TestCreatePaymentIntent_CurrencyMustMatchAccount(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+	if _, err := db.Exec(context.Background(),
+		`UPDATE accounts SET currency = 'JPY' WHERE id = $1`, accountID,
+	); err != nil {
+		t.Fatalf("set currency: %v", err)
+	}
+
+	_, err := CreatePaymentIntentInCurrency(context.Background(), db, accountID, money.NewAmount(5, money.USD), 0, nil)
+	if !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Fatalf("expected money.ErrCurrencyMismatch, got %v", err)
+	}
+
+	pi, err := CreatePaymentIntentInCurrency(context.Background(), db, accountID, money.NewAmount(5, "JPY"), 0, nil)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntentInCurrency: %v", err)
+	}
+	if pi.Currency != "JPY" {
+		t.Fatalf("intent currency=%s want JPY", pi.Currency)
+	}
+
+	// no currency => the account's
+	pi2, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if pi2.Currency != "JPY" {
+		t.Fatalf("default intent currency=%s want JPY", pi2.Currency)
+	}
+
+	if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	var n int64
+	if err := db.QueryRow(context.Background(),
+		`SELECT count(*) FROM ledger_entries WHERE payment_intent_id = $1 AND currency = 'JPY'`, pi.ID,
+	).Scan(&n); err != nil {
+		t.Fatalf("count ledger: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("JPY ledger rows=%d want 2", n)
+	}
+}
+
+func TestListPaymentIntents_MatchesMetadata(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	if _, err := CreatePaymentIntentInCurrency(ctx, db, accountID, money.NewAmount(5, ""), 0,
+		domain.Metadata{"order_id": "ord_1", "channel": "web"}); err != nil {
+		t.Fatalf("CreatePaymentIntentInCurrency: %v", err)
+	}
+	if _, err := CreatePaymentIntentInCurrency(ctx, db, accountID, money.NewAmount(5, ""), 0,
+		domain.Metadata{"order_id": "ord_2"}); err != nil {
+		t.Fatalf("CreatePaymentIntentInCurrency: %v", err)
+	}
+	if _, err := CreatePaymentIntentInCurrency(ctx, db, accountID, money.NewAmount(5, ""), 0,
+		domain.Metadata{"": "x"}); !errors.Is(err, domain.ErrInvalidMetadata) {
+		t.Fatalf("empty key err = %v, want ErrInvalidMetadata", err)
+	}
+
+	// a merchant pay intent carries its request's metadata
+	mr, err := CreateMerchantRequest(ctx, db, "merchant_meta", ptr("order-3"),
+		[]MerchantRequestPayer{{AccountID: accountID.String(), ShareCents: 10}}, 10, "", nil,
+		domain.Metadata{"order_id": "ord_3"})
+	if err != nil {
+		t.Fatalf("CreateMerchantRequest: %v", err)
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin tx: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	payIntent, err := CreateMerchantPayIntentTx(ctx, tx, mr.ID, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreateMerchantPayIntentTx: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	got, err := ListPaymentIntents(ctx, db, PaymentIntentFilter{Metadata: domain.Metadata{"order_id": "ord_1"}})
+	if err != nil {
+		t.Fatalf("ListPaymentIntents: %v", err)
+	}
+	if len(got) != 1 || got[0].Metadata["channel"] != "web" {
+		t.Fatalf("order ord_1 intents = %+v", got)
+	}
+	got, err = ListPaymentIntents(ctx, db, PaymentIntentFilter{Metadata: domain.Metadata{"order_id": "ord_3"}})
+	if err != nil {
+		t.Fatalf("ListPaymentIntents: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != payIntent.ID {
+		t.Fatalf("order ord_3 intents = %+v, want the merchant pay intent", got)
+	}
+	if all, err := ListPaymentIntents(ctx, db, PaymentIntentFilter{AccountID: &accountID}); err != nil || len(all) != 3 {
+		t.Fatalf("account intents = %d, %v; want 3", len(all), err)
+	}
+
+	mrs, err := ListMerchantRequests(ctx, db, MerchantRequestFilter{Metadata: domain.Metadata{"order_id": "ord_3"}})
+	if err != nil || len(mrs) != 1 || mrs[0].ID != mr.ID {
+		t.Fatalf("ListMerchantRequests = %+v, %v", mrs, err)
+	}
+}
@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrPaymentIntentNotPending = errors.New("payment intent not pending")
+
+var ErrQuoteNotFound = errors.New("quote not found")
+
+var ErrQuoteExpired = errors.New("quote expired")
+
+var ErrQuoteStale = errors.New("quote stale: attempt_count moved")
+
+// Quote previews what confirming an intent would cost right now.
+type Quote struct {
+	ID                 uuid.UUID
+	PaymentIntentID    uuid.UUID
+	AccountID          uuid.UUID
+	AttemptCount       int64
+	PolicyVersion      int64
+	PromotionID        *int64
+	RateBPS            int64
+	PrincipalCents     int64
+	InterestCents      int64
+	PenaltyCents       int64
+	TotalCents         int64
+	ExceedsCreditLimit bool
+	ExpiresAt          time.Time
+	CreatedAt          time.Time
+}
+
+// CreateQuote prices a pending intent at the attempt the next confirm would use
+// and stores the quote so it can lock that price for ttl.
+func CreateQuote(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	intentID uuid.UUID,
+	ttl time.Duration,
+) (*Quote, error) {
+	var (
+		accountID    uuid.UUID
+		amountCents  int64
+		status       string
+		expired      bool
+		attemptCount int64
+		lastAttempt  *time.Time
+		creditLimit  int64
+		balanceCents int64
+		heldCents    int64
+	)
+	if err := db.QueryRow(ctx, `
+select
+  pi.account_id, pi.amount_cents, pi.status,
+  (pi.expires_at is not null and pi.expires_at <= now()),
+  a.attempt_count, a.last_attempt_at, a.credit_limit_cents, a.balance_cents,
+  coalesce((select sum(h.amount_cents) from holds h where h.account_id = a.id and h.status = 'active'), 0)
+from payment_intents pi
+join accounts a on a.id = pi.account_id
+where pi.id = $1
+`, intentID).Scan(
+		&accountID,
+		&amountCents,
+		&status,
+		&expired,
+		&attemptCount,
+		&lastAttempt,
+		&creditLimit,
+		&balanceCents,
+		&heldCents,
+	); err != nil {
+		return nil, err
+	}
+
+	if status != "pending" {
+		return nil, ErrPaymentIntentNotPending
+	}
+	if expired {
+		return nil, ErrPaymentIntentExpired
+	}
+
+	policy, err := LoadAccountPolicy(ctx, db, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// confirm decays and increments attempt_count before pricing
+	now := time.Now()
+	next := policy.NextAttempt(attemptCount, lastAttempt, now)
+	promo, err := LoadActivePromotion(ctx, db, accountID, next)
+	if err != nil {
+		return nil, err
+	}
+	charge, err := policy.Price(money.Cents(amountCents), next, promo, now)
+	if err != nil {
+		return nil, err
+	}
+
+	q := Quote{
+		ID:              uuid.New(),
+		PaymentIntentID: intentID,
+		AccountID:       accountID,
+		AttemptCount:    attemptCount,
+		PolicyVersion:   policy.Version,
+		PromotionID:     charge.PromotionID,
+		RateBPS:         int64(charge.RateBPS),
+		PrincipalCents:  amountCents,
+		InterestCents:   int64(charge.Interest),
+	}
+	if q.TotalCents, err = money.Add(q.PrincipalCents, q.InterestCents); err != nil {
+		return nil, err
+	}
+
+	// invalid amount -> confirm would only post the fine
+	if amountCents < 1 || amountCents > 10 {
+		q.PrincipalCents = 0
+		q.InterestCents = 0
+		q.PenaltyCents = int64(policy.InvalidAmountFineCents)
+		q.TotalCents = q.PenaltyCents
+	}
+	q.ExceedsCreditLimit = !fitsCredit(creditLimit, balanceCents, heldCents, q.TotalCents)
+
+	if err := db.QueryRow(ctx, `
+insert into payment_quotes
+  (id, payment_intent_id, account_id, attempt_count, policy_version, promotion_id, rate_bps, principal_cents, interest_cents,
+   penalty_cents, total_cents, exceeds_credit_limit, expires_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, now() + $13::bigint * interval '1 microsecond')
+returning created_at, expires_at
+`, q.ID, q.PaymentIntentID, q.AccountID, q.AttemptCount, q.PolicyVersion, q.PromotionID, q.RateBPS, q.PrincipalCents, q.InterestCents,
+		q.PenaltyCents, q.TotalCents, q.ExceedsCreditLimit, ttl.Microseconds(),
+	).Scan(&q.CreatedAt, &q.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &q, nil
+}
+
+// lockQuoteTx loads a usable quote for intentID FOR UPDATE.
+func lockQuoteTx(ctx context.Context, tx pgx.Tx, quoteID uuid.UUID, intentID uuid.UUID) (*Quote, error) {
+	var (
+		q       Quote
+		used    bool
+		expired bool
+	)
+	err := tx.QueryRow(ctx, `
+select id, payment_intent_id, account_id, attempt_count, coalesce(policy_version, 0), promotion_id, rate_bps, principal_cents, interest_cents,
+       penalty_cents, total_cents, exceeds_credit_limit, expires_at, created_at,
+       used_at is not null, expires_at <= now()
+from payment_quotes
+where id = $1
+  and payment_intent_id = $2
+for update
+`, quoteID, intentID).Scan(
+		&q.ID,
+		&q.PaymentIntentID,
+		&q.AccountID,
+		&q.AttemptCount,
+		&q.PolicyVersion,
+		&q.PromotionID,
+		&q.RateBPS,
+		&q.PrincipalCents,
+		&q.InterestCents,
+		&q.PenaltyCents,
+		&q.TotalCents,
+		&q.ExceedsCreditLimit,
+		&q.ExpiresAt,
+		&q.CreatedAt,
+		&used,
+		&expired,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrQuoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a used quote belongs to an intent that is no longer pending; treat as stale
+	if used {
+		return nil, ErrQuoteStale
+	}
+	if expired {
+		return nil, ErrQuoteExpired
+	}
+	return &q, nil
+}
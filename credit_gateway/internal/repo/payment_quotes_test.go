@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestQuote_ConfirmAtQuotedPrice(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	q, err := CreateQuote(context.Background(), db, pi.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateQuote: %v", err)
+	}
+	// attempt=1 => 101% => interest=floor(10*1.01)=10
+	if q.RateBPS != 10100 || q.InterestCents != 10 || q.TotalCents != 20 || q.ExceedsCreditLimit {
+		t.Fatalf("quote=%+v, want rate=10100 interest=10 total=20 within limit", *q)
+	}
+
+	if err := ConfirmPaymentWithQuote(context.Background(), db, pi.ID, q.ID); err != nil {
+		t.Fatalf("ConfirmPaymentWithQuote: %v", err)
+	}
+
+	charges, err := GetPaymentIntentCharges(context.Background(), db, pi.ID)
+	if err != nil {
+		t.Fatalf("GetPaymentIntentCharges: %v", err)
+	}
+	if charges.InterestCents != q.InterestCents {
+		t.Fatalf("interest=%d, want quoted %d", charges.InterestCents, q.InterestCents)
+	}
+}
+
+func TestQuote_RejectedWhenAttemptCountMoved(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	quoted, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	other, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	q, err := CreateQuote(context.Background(), db, quoted.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateQuote: %v", err)
+	}
+
+	// another confirm moves attempt_count
+	if err := ConfirmPayment(context.Background(), db, other.ID); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+
+	err = ConfirmPaymentWithQuote(context.Background(), db, quoted.ID, q.ID)
+	if !errors.Is(err, ErrQuoteStale) {
+		t.Fatalf("err=%v, want ErrQuoteStale", err)
+	}
+	if got := getIntentStatus(t, db, quoted.ID); got != "pending" {
+		t.Fatalf("intent status = %q, want still pending", got)
+	}
+}
@@ -0,0 +1,410 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrPaymentRequiresReview = errors.New("payment requires manual review")
+	ErrReviewNotPending      = errors.New("review already decided")
+	ErrReviewerRequired      = errors.New("reviewer is required")
+	ErrReviewReasonRequired  = errors.New("a reason is required to decline")
+)
+
+// ReviewRequiredError is returned by confirm when a risk rule parked the intent for review.
+// It matches ErrPaymentRequiresReview.
+type ReviewRequiredError struct {
+	ReviewID int64
+}
+
+func (e *ReviewRequiredError) Error() string {
+	return fmt.Sprintf("payment requires manual review (review %d)", e.ReviewID)
+}
+
+func (e *ReviewRequiredError) Is(target error) bool { return target == ErrPaymentRequiresReview }
+
+type PaymentReview struct {
+	ID              int64
+	PaymentIntentID uuid.UUID
+	AccountID       uuid.UUID
+	RiskDecisionID  *int64
+	Status          string
+	AmountCents     int64
+	Currency        money.Currency
+
+	// flagged price
+	AttemptCount  int64
+	PolicyVersion int64
+	RateBPS       int64
+	InterestCents int64
+	PromotionID   *int64
+
+	Pricing              *domain.ReviewPricing
+	ChargedRateBPS       *int64
+	ChargedInterestCents *int64
+
+	DecidedBy      *string
+	DecisionReason *string
+	DecidedAt      *time.Time
+	CreatedAt      time.Time
+
+	Events []PaymentReviewEvent
+}
+
+type PaymentReviewEvent struct {
+	ID        int64
+	Event     string
+	Actor     string
+	Reason    *string
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// parkForReviewTx moves a priced, credit-checked intent to requires_review instead of
+// charging it, keeping the flagged price for approval.
+func parkForReviewTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	li *lockedIntent,
+	policyVersion int64,
+	charge domain.Charge,
+) error {
+	var riskDecisionID *int64
+	if li.Risk != nil {
+		riskDecisionID = &li.Risk.ID
+	}
+
+	var reviewID int64
+	if err := tx.QueryRow(ctx, `
+insert into payment_reviews
+  (payment_intent_id, account_id, risk_decision_id, attempt_count, policy_version, rate_bps, interest_cents, promotion_id)
+values ($1, $2, $3, $4, $5, $6, $7, $8)
+returning id
+`, li.IntentID, li.AccountID, riskDecisionID, li.AttemptCount, policyVersion,
+		int64(charge.RateBPS), int64(charge.Interest), charge.PromotionID,
+	).Scan(&reviewID); err != nil {
+		return err
+	}
+
+	details := map[string]any{
+		"attempt_count":  li.AttemptCount,
+		"rate_bps":       int64(charge.RateBPS),
+		"interest_cents": int64(charge.Interest),
+	}
+	if li.Risk != nil {
+		details["risk_decision_id"] = li.Risk.ID
+		details["hits"] = li.Risk.Hits
+	}
+	if err := insertReviewEventTx(ctx, tx, reviewID, "flagged", "risk_engine", nil, details); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`update payment_intents set status = 'requires_review' where id = $1 and status = 'pending'`,
+		li.IntentID,
+	); err != nil {
+		return err
+	}
+	return &ReviewRequiredError{ReviewID: reviewID}
+}
+
+func insertReviewEventTx(ctx context.Context, tx pgx.Tx, reviewID int64, event, actor string, reason *string, details map[string]any) error {
+	if details == nil {
+		details = map[string]any{}
+	}
+	b, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+insert into payment_review_events (review_id, event, actor, reason, details)
+values ($1, $2, $3, $4, $5::jsonb)
+`, reviewID, event, actor, reason, string(b))
+	return err
+}
+
+const reviewColumns = `r.id, r.payment_intent_id, r.account_id, r.risk_decision_id, r.status,
+  pi.amount_cents, pi.currency,
+  r.attempt_count, r.policy_version, r.rate_bps, r.interest_cents, r.promotion_id,
+  r.pricing, r.charged_rate_bps, r.charged_interest_cents,
+  r.decided_by, r.decision_reason, r.decided_at, r.created_at`
+
+func scanReview(row pgx.Row, r *PaymentReview) error {
+	return row.Scan(
+		&r.ID, &r.PaymentIntentID, &r.AccountID, &r.RiskDecisionID, &r.Status,
+		&r.AmountCents, &r.Currency,
+		&r.AttemptCount, &r.PolicyVersion, &r.RateBPS, &r.InterestCents, &r.PromotionID,
+		&r.Pricing, &r.ChargedRateBPS, &r.ChargedInterestCents,
+		&r.DecidedBy, &r.DecisionReason, &r.DecidedAt, &r.CreatedAt,
+	)
+}
+
+// ListReviews returns the queue for a status ("" = all), oldest first.
+func ListReviews(ctx context.Context, db *pgxpool.Pool, status string, limit int) ([]PaymentReview, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(ctx, `
+select `+reviewColumns+`
+from payment_reviews r
+join payment_intents pi on pi.id = r.payment_intent_id
+where $1 = '' or r.status = $1
+order by r.created_at asc, r.id asc
+limit $2
+`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PaymentReview
+	for rows.Next() {
+		var r PaymentReview
+		if err := scanReview(rows, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetReview returns a review with its audit trail.
+func GetReview(ctx context.Context, db *pgxpool.Pool, id int64) (*PaymentReview, error) {
+	var r PaymentReview
+	if err := scanReview(db.QueryRow(ctx, `
+select `+reviewColumns+`
+from payment_reviews r
+join payment_intents pi on pi.id = r.payment_intent_id
+where r.id = $1
+`, id), &r); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, `
+select id, event, actor, reason, details, created_at
+from payment_review_events
+where review_id = $1
+order by id
+`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e       PaymentReviewEvent
+			details []byte
+		)
+		if err := rows.Scan(&e.ID, &e.Event, &e.Actor, &e.Reason, &details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &e.Details); err != nil {
+			return nil, err
+		}
+		r.Events = append(r.Events, e)
+	}
+	return &r, rows.Err()
+}
+
+// lockReviewTx locks a review and its intent and account, in the same order as confirm.
+func lockReviewTx(ctx context.Context, tx pgx.Tx, id int64) (*PaymentReview, error) {
+	var r PaymentReview
+	if err := scanReview(tx.QueryRow(ctx, `
+select `+reviewColumns+`
+from payment_reviews r
+join payment_intents pi on pi.id = r.payment_intent_id
+where r.id = $1
+for update of r, pi
+`, id), &r); err != nil {
+		return nil, err
+	}
+	if r.Status != "pending" {
+		return nil, ErrReviewNotPending
+	}
+	return &r, nil
+}
+
+// ApproveReview completes the parked confirm: it books principal + interest at the flagged
+// price, or re-prices at the account's current policy, override and promotion (without
+// counting another attempt) when pricing is ReviewPricingApproval. Credit and the account
+// lock are checked again; a merchant payment also progresses its merchant request. When the
+// payer's share was paid down meanwhile (ErrInstallmentExceedsShare) nothing is booked and
+// the review stays pending, to be declined.
+func ApproveReview(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	id int64,
+	reviewer string,
+	pricing domain.ReviewPricing,
+) (*PaymentReview, error) {
+	if reviewer == "" {
+		return nil, ErrReviewerRequired
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	r, err := lockReviewTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		accountStatus              string
+		creditLimit, balance, held int64
+	)
+	if err := tx.QueryRow(ctx,
+		`select status, credit_limit_cents, balance_cents from accounts where id = $1 for update`,
+		r.AccountID,
+	).Scan(&accountStatus, &creditLimit, &balance); err != nil {
+		return nil, err
+	}
+	// summed after the lock, so holds placed by the transaction we waited on are seen
+	if err := tx.QueryRow(ctx,
+		`select coalesce(sum(amount_cents), 0) from holds where account_id = $1 and status = 'active'`,
+		r.AccountID,
+	).Scan(&held); err != nil {
+		return nil, err
+	}
+	if accountStatus != "active" {
+		return nil, ErrAccountLocked
+	}
+
+	principal := money.Cents(r.AmountCents)
+	charge := domain.Charge{
+		RateBPS:     money.RateBPS(r.RateBPS),
+		Interest:    money.Cents(r.InterestCents),
+		PromotionID: r.PromotionID,
+	}
+	policyVersion := r.PolicyVersion
+	if pricing == domain.ReviewPricingApproval {
+		policy, err := LoadAccountPolicy(ctx, tx, r.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		promo, err := LoadActivePromotion(ctx, tx, r.AccountID, r.AttemptCount)
+		if err != nil {
+			return nil, err
+		}
+		if charge, err = policy.Price(principal, r.AttemptCount, promo, time.Now()); err != nil {
+			return nil, err
+		}
+		policyVersion = policy.Version
+	}
+
+	total, err := money.Add(principal, charge.Interest)
+	if err != nil {
+		return nil, err
+	}
+	if !fitsCredit(creditLimit, balance, held, int64(total)) {
+		return nil, ErrInsufficientCredit
+	}
+
+	// postChargeTx marks the intent succeeded by id, so it completes a requires_review intent too
+	if err := postChargeTx(ctx, tx, r.AccountID, r.PaymentIntentID, principal, charge, policyVersion); err != nil {
+		return nil, err
+	}
+
+	if err := progressMerchantPayTx(ctx, tx, r.PaymentIntentID, r.AccountID, r.AmountCents); err != nil {
+		return nil, err
+	}
+
+	rate, interest := int64(charge.RateBPS), int64(charge.Interest)
+	if _, err := tx.Exec(ctx, `
+update payment_reviews
+   set status = 'approved', pricing = $2, charged_rate_bps = $3, charged_interest_cents = $4,
+       decided_by = $5, decided_at = now()
+ where id = $1
+`, id, string(pricing), rate, interest, reviewer); err != nil {
+		return nil, err
+	}
+	if err := insertReviewEventTx(ctx, tx, id, "approved", reviewer, nil, map[string]any{
+		"pricing":        string(pricing),
+		"policy_version": policyVersion,
+		"rate_bps":       rate,
+		"interest_cents": interest,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return GetReview(ctx, db, id)
+}
+
+// progressMerchantPayTx advances the merchant request an approved intent pays, if any,
+// toward the share of the intent's payer.
+func progressMerchantPayTx(ctx context.Context, tx pgx.Tx, intentID, payerAccountID uuid.UUID, amountCents int64) error {
+	mrID, err := GetMerchantRequestIDByPaymentIntentForUpdate(ctx, tx, intentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	first, err := TryMarkMerchantPayProgressedTx(ctx, tx, intentID)
+	if err != nil || !first {
+		return err
+	}
+	_, _, _, err = IncrementMerchantPayerProgress(ctx, tx, mrID, payerAccountID.String(), amountCents)
+	return err
+}
+
+// DeclineReview refuses the parked intent. Nothing is charged; the attempt stays counted.
+func DeclineReview(ctx context.Context, db *pgxpool.Pool, id int64, reviewer, reason string) (*PaymentReview, error) {
+	if reviewer == "" {
+		return nil, ErrReviewerRequired
+	}
+	if reason == "" {
+		return nil, ErrReviewReasonRequired
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	r, err := lockReviewTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`update payment_intents set status = 'refused' where id = $1 and status = 'requires_review'`,
+		r.PaymentIntentID,
+	); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+update payment_reviews
+   set status = 'declined', decided_by = $2, decision_reason = $3, decided_at = now()
+ where id = $1
+`, id, reviewer, reason); err != nil {
+		return nil, err
+	}
+	if err := insertReviewEventTx(ctx, tx, id, "declined", reviewer, &reason, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return GetReview(ctx, db, id)
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/risk"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestReview_FlaggedConfirmWaitsThenApproveOrDecline(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	if _, err := CreateRiskRule(context.Background(), db, risk.Rule{
+		Name: "test_review_all", Kind: "confirm_velocity", Window: time.Hour, Threshold: 0, Action: risk.Review, Enabled: true,
+	}); err != nil {
+		t.Fatalf("CreateRiskRule: %v", err)
+	}
+
+	flag := func() (uuid.UUID, int64) {
+		t.Helper()
+		pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+		if err != nil {
+			t.Fatalf("CreatePaymentIntent: %v", err)
+		}
+		err = ConfirmPayment(context.Background(), db, pi.ID)
+		var re *ReviewRequiredError
+		if !errors.Is(err, ErrPaymentRequiresReview) || !errors.As(err, &re) {
+			t.Fatalf("ConfirmPayment = %v, want review", err)
+		}
+		if st := getIntentStatus(t, db, pi.ID); st != "requires_review" {
+			t.Fatalf("intent status = %s", st)
+		}
+		return pi.ID, re.ReviewID
+	}
+
+	approvedIntent, reviewID := flag()
+	if _, balance, _, _ := getAccountState(t, db, accountID); balance != 0 {
+		t.Fatalf("balance while in review = %d, want 0", balance)
+	}
+
+	rv, err := ApproveReview(context.Background(), db, reviewID, "alice", domain.ReviewPricingFlagged)
+	if err != nil {
+		t.Fatalf("ApproveReview: %v", err)
+	}
+	if rv.Status != "approved" || rv.ChargedInterestCents == nil || *rv.ChargedInterestCents != rv.InterestCents || len(rv.Events) != 2 {
+		t.Fatalf("approved review = %+v", rv)
+	}
+	if st := getIntentStatus(t, db, approvedIntent); st != "succeeded" {
+		t.Fatalf("approved intent status = %s", st)
+	}
+	if _, balance, _, _ := getAccountState(t, db, accountID); balance != 5+rv.InterestCents {
+		t.Fatalf("balance after approval = %d, want %d", balance, 5+rv.InterestCents)
+	}
+	if _, err := ApproveReview(context.Background(), db, reviewID, "alice", domain.ReviewPricingFlagged); !errors.Is(err, ErrReviewNotPending) {
+		t.Fatalf("second approve = %v, want ErrReviewNotPending", err)
+	}
+
+	declinedIntent, reviewID := flag()
+	if _, err := DeclineReview(context.Background(), db, reviewID, "bob", ""); !errors.Is(err, ErrReviewReasonRequired) {
+		t.Fatalf("decline without reason = %v", err)
+	}
+	rv, err = DeclineReview(context.Background(), db, reviewID, "bob", "card reported stolen")
+	if err != nil {
+		t.Fatalf("DeclineReview: %v", err)
+	}
+	if rv.Status != "declined" || rv.Events[1].Event != "declined" || *rv.Events[1].Reason != "card reported stolen" {
+		t.Fatalf("declined review = %+v", rv)
+	}
+	if st := getIntentStatus(t, db, declinedIntent); st != "refused" {
+		t.Fatalf("declined intent status = %s", st)
+	}
+}
+
+func TestReview_FlaggedAuthorizePlacesNoHold(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	if _, err := CreateRiskRule(context.Background(), db, risk.Rule{
+		Name: "test_review_all", Kind: "confirm_velocity", Window: time.Hour, Threshold: 0, Action: risk.Review, Enabled: true,
+	}); err != nil {
+		t.Fatalf("CreateRiskRule: %v", err)
+	}
+
+	pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	err = AuthorizePayment(context.Background(), db, pi.ID, time.Hour)
+	var re *ReviewRequiredError
+	if !errors.Is(err, ErrPaymentRequiresReview) || !errors.As(err, &re) {
+		t.Fatalf("AuthorizePayment = %v, want review", err)
+	}
+	if st := getIntentStatus(t, db, pi.ID); st != "requires_review" {
+		t.Fatalf("intent status = %s", st)
+	}
+	if _, err := GetHoldByPaymentIntent(context.Background(), db, pi.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("hold of a flagged authorize: err = %v, want none", err)
+	}
+	if err := CapturePayment(context.Background(), db, pi.ID); !errors.Is(err, ErrPaymentIntentNotAuthorized) {
+		t.Fatalf("capture of a flagged authorize = %v, want ErrPaymentIntentNotAuthorized", err)
+	}
+
+	rv, err := ApproveReview(context.Background(), db, re.ReviewID, "alice", domain.ReviewPricingFlagged)
+	if err != nil {
+		t.Fatalf("ApproveReview: %v", err)
+	}
+	if st := getIntentStatus(t, db, pi.ID); st != "succeeded" {
+		t.Fatalf("approved intent status = %s", st)
+	}
+	if _, balance, _, _ := getAccountState(t, db, accountID); balance != 5+rv.InterestCents {
+		t.Fatalf("balance after approval = %d, want %d", balance, 5+rv.InterestCents)
+	}
+}
+
+func TestReview_ApproveRefusesInstallmentPastTheShare(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+	mrID := createMerchantRequestRow(t, db, "merchant_review", ptr("order_001"), accountID.String(), 15)
+
+	if _, err := CreateRiskRule(ctx, db, risk.Rule{
+		Name: "test_review_all", Kind: "confirm_velocity", Window: time.Hour, Threshold: 0, Action: risk.Review, Enabled: true,
+	}); err != nil {
+		t.Fatalf("CreateRiskRule: %v", err)
+	}
+
+	// two 10 cent intents against a 15 cent share, both parked before either is approved
+	var reviews []int64
+	for i := 0; i < 2; i++ {
+		tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			t.Fatalf("begin tx: %v", err)
+		}
+		pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 10, 0)
+		if err != nil {
+			t.Fatalf("CreateMerchantPayIntentTx: %v", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+		var re *ReviewRequiredError
+		if err := ConfirmPayment(ctx, db, pi.ID); !errors.As(err, &re) {
+			t.Fatalf("ConfirmPayment = %v, want review", err)
+		}
+		reviews = append(reviews, re.ReviewID)
+	}
+
+	if _, err := ApproveReview(ctx, db, reviews[0], "alice", domain.ReviewPricingFlagged); err != nil {
+		t.Fatalf("ApproveReview: %v", err)
+	}
+	_, balance, _, _ := getAccountState(t, db, accountID)
+
+	if _, err := ApproveReview(ctx, db, reviews[1], "alice", domain.ReviewPricingFlagged); !errors.Is(err, ErrInstallmentExceedsShare) {
+		t.Fatalf("second ApproveReview = %v, want ErrInstallmentExceedsShare", err)
+	}
+	if rv, err := GetReview(ctx, db, reviews[1]); err != nil || rv.Status != "pending" {
+		t.Fatalf("second review = %+v, %v; want pending", rv, err)
+	}
+	if _, after, _, _ := getAccountState(t, db, accountID); after != balance {
+		t.Fatalf("balance after refused approve = %d, want %d", after, balance)
+	}
+	if paid, _, st := getMerchantRequestState(t, db, mrID); paid != 10 || st != "pending" {
+		t.Fatalf("merchant request paid=%d status=%s", paid, st)
+	}
+}
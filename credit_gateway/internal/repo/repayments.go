@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidRepaymentAmount  = errors.New("repayment amount must be positive")
+	ErrRepaymentExceedsBalance = errors.New("repayment exceeds outstanding balance")
+)
+
+type Repayment struct {
+	AccountID    uuid.UUID
+	Currency     money.Currency
+	AmountCents  int64
+	BalanceCents int64 // after the repayment
+	AttemptCount int64 // after forgiveness
+	CreatedAt    time.Time
+}
+
+// RecordRepayment books a repayment as a negative 'repayment' ledger entry, lowers the
+// balance and forgives attempts per the account's interest policy. Paying more than the
+// outstanding balance is refused (no credit balances).
+func RecordRepayment(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, amountCents int64) (*Repayment, error) {
+	if amountCents <= 0 {
+		return nil, ErrInvalidRepaymentAmount
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	r := Repayment{AccountID: accountID, AmountCents: amountCents}
+	var attempts int64
+	if err := tx.QueryRow(ctx, `
+select currency, balance_cents, attempt_count
+from accounts
+where id = $1
+for update
+`, accountID).Scan(&r.Currency, &r.BalanceCents, &attempts); err != nil {
+		return nil, err
+	}
+	if amountCents > r.BalanceCents {
+		return nil, ErrRepaymentExceedsBalance
+	}
+
+	policy, err := LoadAccountPolicy(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	r.AttemptCount = policy.AfterRepayment(attempts)
+	r.BalanceCents -= amountCents
+
+	if err := insertLedger(ctx, tx, accountID, uuid.Nil, "repayment", money.Cents(-amountCents), ledgerMeta{}); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(ctx, `
+update accounts
+   set balance_cents = $2,
+       attempt_count = $3,
+       updated_at = now()
+ where id = $1
+returning updated_at
+`, accountID, r.BalanceCents, r.AttemptCount).Scan(&r.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &r, tx.Commit(ctx)
+}
@@ -0,0 +1,274 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gateway/internal/risk"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRiskDeclined          = errors.New("declined by risk rules")
+	ErrStepUpRequired        = errors.New("step-up required")
+	ErrRiskDecisionNotStepUp = errors.New("risk decision does not require a step-up")
+)
+
+// RiskDecisionError carries the recorded decision behind a declined or held confirm.
+// It matches ErrRiskDeclined or ErrStepUpRequired.
+type RiskDecisionError struct {
+	DecisionID int64
+	Action     risk.Action
+	Hits       []risk.Hit
+}
+
+func (e *RiskDecisionError) Error() string {
+	return fmt.Sprintf("risk decision %d: %s", e.DecisionID, e.Action)
+}
+
+func (e *RiskDecisionError) Is(target error) bool {
+	switch target {
+	case ErrRiskDeclined:
+		return e.Action == risk.Decline
+	case ErrStepUpRequired:
+		return e.Action == risk.StepUp
+	}
+	return false
+}
+
+type RiskDecision struct {
+	ID                int64
+	AccountID         uuid.UUID
+	PaymentIntentID   uuid.UUID
+	MerchantID        *string
+	AmountCents       int64
+	Action            risk.Action
+	Hits              []risk.Hit
+	StepUpCompletedAt *time.Time
+	CreatedAt         time.Time
+}
+
+const riskRuleColumns = `id, name, kind, window_seconds, threshold, action, enabled`
+
+func scanRiskRule(row pgx.Row, r *risk.Rule) error {
+	var windowSeconds int32
+	if err := row.Scan(&r.ID, &r.Name, &r.Kind, &windowSeconds, &r.Threshold, &r.Action, &r.Enabled); err != nil {
+		return err
+	}
+	r.Window = time.Duration(windowSeconds) * time.Second
+	return nil
+}
+
+func listRiskRules(ctx context.Context, db querier, enabledOnly bool) ([]risk.Rule, error) {
+	rows, err := db.Query(ctx, `
+select `+riskRuleColumns+`
+from risk_rules
+where enabled or not $1
+order by id
+`, enabledOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []risk.Rule
+	for rows.Next() {
+		var r risk.Rule
+		if err := scanRiskRule(rows, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func ListRiskRules(ctx context.Context, db *pgxpool.Pool) ([]risk.Rule, error) {
+	return listRiskRules(ctx, db, false)
+}
+
+func CreateRiskRule(ctx context.Context, db *pgxpool.Pool, r risk.Rule) (risk.Rule, error) {
+	if err := r.Validate(); err != nil {
+		return r, err
+	}
+	err := scanRiskRule(db.QueryRow(ctx, `
+insert into risk_rules (name, kind, window_seconds, threshold, action, enabled)
+values ($1, $2, $3, $4, $5, $6)
+returning `+riskRuleColumns,
+		r.Name, r.Kind, int32(r.Window/time.Second), r.Threshold, string(r.Action), r.Enabled,
+	), &r)
+	return r, err
+}
+
+// UpdateRiskRule replaces a rule's definition; the id stays the same.
+func UpdateRiskRule(ctx context.Context, db *pgxpool.Pool, r risk.Rule) (risk.Rule, error) {
+	if err := r.Validate(); err != nil {
+		return r, err
+	}
+	err := scanRiskRule(db.QueryRow(ctx, `
+update risk_rules
+   set name = $2, kind = $3, window_seconds = $4, threshold = $5, action = $6, enabled = $7,
+       updated_at = now()
+ where id = $1
+returning `+riskRuleColumns,
+		r.ID, r.Name, r.Kind, int32(r.Window/time.Second), r.Threshold, string(r.Action), r.Enabled,
+	), &r)
+	return r, err
+}
+
+// evaluateRiskTx scores a locked pending intent against the enabled rules and records the
+// decision. Every evaluated confirm is recorded, so decisions double as the velocity history.
+// A completed step-up for the same intent waives step-up hits. Decline refuses the intent.
+func evaluateRiskTx(ctx context.Context, tx pgx.Tx, li *lockedIntent, now time.Time) (*RiskDecision, error) {
+	rules, err := listRiskRules(ctx, tx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	current := risk.Event{At: now, AmountCents: li.AmountCents}
+	if li.MerchantID != nil {
+		current.MerchantID = *li.MerchantID
+	}
+
+	var history []risk.Event
+	if window := risk.MaxWindow(rules); window > 0 {
+		rows, err := tx.Query(ctx, `
+select created_at, coalesce(merchant_id, ''), amount_cents
+from risk_decisions
+where account_id = $1 and created_at > $2
+`, li.AccountID, now.Add(-window))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var e risk.Event
+			if err := rows.Scan(&e.At, &e.MerchantID, &e.AmountCents); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			history = append(history, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var steppedUp bool
+	if err := tx.QueryRow(ctx, `
+select exists (
+  select 1 from risk_decisions
+  where payment_intent_id = $1 and action = 'step_up' and step_up_completed_at is not null
+)`, li.IntentID).Scan(&steppedUp); err != nil {
+		return nil, err
+	}
+
+	d := risk.Evaluate(rules, history, current, steppedUp)
+	rd := RiskDecision{
+		AccountID:       li.AccountID,
+		PaymentIntentID: li.IntentID,
+		MerchantID:      li.MerchantID,
+		AmountCents:     li.AmountCents,
+		Action:          d.Action,
+		Hits:            d.Hits,
+	}
+	if rd.Hits == nil {
+		rd.Hits = []risk.Hit{}
+	}
+	hits, err := json.Marshal(rd.Hits)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(ctx, `
+insert into risk_decisions (account_id, payment_intent_id, merchant_id, amount_cents, action, hits, created_at)
+values ($1, $2, $3, $4, $5, $6::jsonb, $7)
+returning id, created_at
+`, rd.AccountID, rd.PaymentIntentID, rd.MerchantID, rd.AmountCents, string(rd.Action), string(hits), now,
+	).Scan(&rd.ID, &rd.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	switch rd.Action {
+	case risk.Decline:
+		if err := RefusePaymentIntentTx(ctx, tx, li.IntentID); err != nil {
+			return nil, err
+		}
+		return &rd, &RiskDecisionError{DecisionID: rd.ID, Action: rd.Action, Hits: rd.Hits}
+	case risk.StepUp:
+		// the intent stays pending until the owner completes the step-up
+		return &rd, &RiskDecisionError{DecisionID: rd.ID, Action: rd.Action, Hits: rd.Hits}
+	}
+	return &rd, nil
+}
+
+// CompleteStepUp marks a step_up decision as passed; re-confirming its intent then skips
+// step-up rules.
+func CompleteStepUp(ctx context.Context, db *pgxpool.Pool, decisionID int64) (*RiskDecision, error) {
+	var action risk.Action
+	if err := db.QueryRow(ctx,
+		`select action from risk_decisions where id = $1`, decisionID,
+	).Scan(&action); err != nil {
+		return nil, err
+	}
+	if action != risk.StepUp {
+		return nil, ErrRiskDecisionNotStepUp
+	}
+
+	rd, err := scanRiskDecision(db.QueryRow(ctx, `
+update risk_decisions
+   set step_up_completed_at = coalesce(step_up_completed_at, now())
+ where id = $1
+returning `+riskDecisionColumns, decisionID))
+	if err != nil {
+		return nil, err
+	}
+	return &rd, nil
+}
+
+const riskDecisionColumns = `id, account_id, payment_intent_id, merchant_id, amount_cents, action, hits, step_up_completed_at, created_at`
+
+func scanRiskDecision(row pgx.Row) (RiskDecision, error) {
+	var (
+		rd   RiskDecision
+		hits []byte
+	)
+	if err := row.Scan(&rd.ID, &rd.AccountID, &rd.PaymentIntentID, &rd.MerchantID, &rd.AmountCents,
+		&rd.Action, &hits, &rd.StepUpCompletedAt, &rd.CreatedAt); err != nil {
+		return rd, err
+	}
+	return rd, json.Unmarshal(hits, &rd.Hits)
+}
+
+// ListRiskDecisions returns an account's decisions, newest first; onlyHits skips clean allows.
+func ListRiskDecisions(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, onlyHits bool, limit int) ([]RiskDecision, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(ctx, `
+select `+riskDecisionColumns+`
+from risk_decisions
+where account_id = $1
+  and (not $2 or jsonb_array_length(hits) > 0)
+order by id desc
+limit $3
+`, accountID, onlyHits, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RiskDecision
+	for rows.Next() {
+		rd, err := scanRiskDecision(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rd)
+	}
+	return out, rows.Err()
+}
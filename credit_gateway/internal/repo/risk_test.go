@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gateway/internal/risk"
+
+	"github.com/google/uuid"
+)
+
+func TestRisk_StepUpHoldsIntentUntilCompleted(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	if _, err := CreateRiskRule(context.Background(), db, risk.Rule{
+		Name: "test_two_per_hour", Kind: "confirm_velocity", Window: time.Hour, Threshold: 2, Action: risk.StepUp, Enabled: true,
+	}); err != nil {
+		t.Fatalf("CreateRiskRule: %v", err)
+	}
+
+	confirm := func() (uuid.UUID, error) {
+		t.Helper()
+		pi, err := CreatePaymentIntent(context.Background(), db, accountID, 5, 0)
+		if err != nil {
+			t.Fatalf("CreatePaymentIntent: %v", err)
+		}
+		return pi.ID, ConfirmPayment(context.Background(), db, pi.ID)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := confirm(); err != nil {
+			t.Fatalf("confirm %d: %v", i, err)
+		}
+	}
+
+	intentID, err := confirm()
+	var de *RiskDecisionError
+	if !errors.Is(err, ErrStepUpRequired) || !errors.As(err, &de) || len(de.Hits) != 1 {
+		t.Fatalf("third confirm = %v, want step-up", err)
+	}
+	if st := getIntentStatus(t, db, intentID); st != "pending" {
+		t.Fatalf("held intent status = %s, want pending", st)
+	}
+	if _, _, _, attempts := getAccountState(t, db, accountID); attempts != 2 {
+		t.Fatalf("attempts = %d, want 2 (held confirm must not count)", attempts)
+	}
+
+	if _, err := CompleteStepUp(context.Background(), db, de.DecisionID); err != nil {
+		t.Fatalf("CompleteStepUp: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, intentID); err != nil {
+		t.Fatalf("confirm after step-up: %v", err)
+	}
+	if st := getIntentStatus(t, db, intentID); st != "succeeded" {
+		t.Fatalf("intent status after step-up = %s", st)
+	}
+
+	decisions, err := ListRiskDecisions(context.Background(), db, accountID, true, 10)
+	if err != nil {
+		t.Fatalf("ListRiskDecisions: %v", err)
+	}
+	if len(decisions) != 2 || decisions[0].Action != risk.Allow || decisions[1].Action != risk.StepUp {
+		t.Fatalf("decisions with hits = %+v", decisions)
+	}
+}
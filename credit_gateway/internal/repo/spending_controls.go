@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrSpendingLimitExceeded  = domain.ErrSpendingLimitExceeded
+	ErrMerchantBlocked        = domain.ErrMerchantBlocked
+	ErrInvalidSpendingControl = errors.New("spending limits must be non-negative and merchant access allow or block")
+)
+
+type querier interface {
+	rowQuerier
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// GetSpendingControls returns the account's controls (empty = unrestricted).
+func GetSpendingControls(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID) (domain.SpendingControls, error) {
+	return loadSpendingControls(ctx, db, accountID)
+}
+
+func loadSpendingControls(ctx context.Context, db querier, accountID uuid.UUID) (domain.SpendingControls, error) {
+	var c domain.SpendingControls
+	err := db.QueryRow(ctx, `
+select daily_limit_cents, merchant_daily_limit_cents, max_attempts
+from spending_controls
+where account_id = $1
+`, accountID).Scan(&c.DailyLimit, &c.MerchantDailyLimit, &c.MaxAttempts)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return c, err
+	}
+
+	rows, err := db.Query(ctx, `
+select merchant_id, access, daily_limit_cents
+from spending_merchant_rules
+where account_id = $1
+order by merchant_id
+`, accountID)
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r domain.MerchantRule
+		if err := rows.Scan(&r.MerchantID, &r.Access, &r.DailyLimit); err != nil {
+			return c, err
+		}
+		c.Merchants = append(c.Merchants, r)
+	}
+	return c, rows.Err()
+}
+
+// SetSpendingControls replaces the account's controls and merchant rules.
+func SetSpendingControls(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, c domain.SpendingControls) error {
+	if !validSpendingControls(c) {
+		return ErrInvalidSpendingControl
+	}
+
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+insert into spending_controls (account_id, daily_limit_cents, merchant_daily_limit_cents, max_attempts)
+values ($1, $2, $3, $4)
+on conflict (account_id) do update
+  set daily_limit_cents = excluded.daily_limit_cents,
+      merchant_daily_limit_cents = excluded.merchant_daily_limit_cents,
+      max_attempts = excluded.max_attempts,
+      updated_at = now()
+`, accountID, c.DailyLimit, c.MerchantDailyLimit, c.MaxAttempts); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `delete from spending_merchant_rules where account_id = $1`, accountID); err != nil {
+		return err
+	}
+	for _, r := range c.Merchants {
+		if _, err := tx.Exec(ctx, `
+insert into spending_merchant_rules (account_id, merchant_id, access, daily_limit_cents)
+values ($1, $2, $3, $4)
+`, accountID, r.MerchantID, string(r.Access), r.DailyLimit); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func validSpendingControls(c domain.SpendingControls) bool {
+	nonNegative := func(v *money.Cents) bool { return v == nil || *v >= 0 }
+	if !nonNegative(c.DailyLimit) || !nonNegative(c.MerchantDailyLimit) || (c.MaxAttempts != nil && *c.MaxAttempts < 0) {
+		return false
+	}
+	for _, r := range c.Merchants {
+		if r.MerchantID == "" || (r.Access != domain.MerchantAllow && r.Access != domain.MerchantBlock) || !nonNegative(r.DailyLimit) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSpendingControlsTx enforces the account's controls on a locked pending intent about to
+// become attempt number `attempt`. Today's spend is the principal booked since midnight UTC
+// plus principal held by today's active authorizations.
+func checkSpendingControlsTx(ctx context.Context, tx pgx.Tx, li *lockedIntent, attempt int64, now time.Time) error {
+	c, err := loadSpendingControls(ctx, tx, li.AccountID)
+	if err != nil {
+		return err
+	}
+	if c.DailyLimit == nil && c.MerchantDailyLimit == nil && c.MaxAttempts == nil && len(c.Merchants) == 0 {
+		return nil
+	}
+
+	s := domain.Spend{Amount: money.Cents(li.AmountCents), Attempt: attempt}
+	if li.MerchantID != nil {
+		s.MerchantID = *li.MerchantID
+	}
+
+	y, m, d := now.UTC().Date()
+	if err := tx.QueryRow(ctx, `
+with spends as (
+  select l.payment_intent_id, l.amount_cents
+  from ledger_entries l
+  where l.account_id = $1 and l.entry_type = 'principal' and l.created_at >= $2
+  union all
+  select h.payment_intent_id, h.principal_cents
+  from holds h
+  where h.account_id = $1 and h.status = 'active' and h.created_at >= $2
+)
+select coalesce(sum(s.amount_cents), 0),
+       coalesce(sum(s.amount_cents) filter (where mr.merchant_id = $3), 0)
+from spends s
+left join merchant_pay_intents mpi on mpi.payment_intent_id = s.payment_intent_id
+left join merchant_requests mr on mr.id = mpi.merchant_request_id
+`, li.AccountID, time.Date(y, m, d, 0, 0, 0, 0, time.UTC), s.MerchantID).Scan(&s.SpentToday, &s.SpentTodayAtMerchant); err != nil {
+		return err
+	}
+
+	return c.Check(s)
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestSpendingControls_DailyCapAndBlockedMerchantRefuseWithoutAttempt(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	daily := money.Cents(15)
+	if err := SetSpendingControls(context.Background(), db, accountID, domain.SpendingControls{
+		DailyLimit: &daily,
+		Merchants:  []domain.MerchantRule{{MerchantID: "casino", Access: domain.MerchantBlock}},
+	}); err != nil {
+		t.Fatalf("SetSpendingControls: %v", err)
+	}
+
+	first, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if err := ConfirmPayment(context.Background(), db, first.ID); err != nil {
+		t.Fatalf("ConfirmPayment first: %v", err)
+	}
+
+	second, err := CreatePaymentIntent(context.Background(), db, accountID, 10, 0)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	err = ConfirmPayment(context.Background(), db, second.ID)
+	var le *domain.SpendingLimitError
+	if !errors.Is(err, ErrSpendingLimitExceeded) || !errors.As(err, &le) || le.Limit != domain.LimitDaily || le.Attempted != 20 {
+		t.Fatalf("ConfirmPayment over daily cap = %v", err)
+	}
+	if st := getIntentStatus(t, db, second.ID); st != "refused" {
+		t.Fatalf("intent status = %s, want refused", st)
+	}
+	if _, _, _, attempts := getAccountState(t, db, accountID); attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (refusal must not count)", attempts)
+	}
+
+	mrID := createMerchantRequestRow(t, db, "casino", ptr("chips"), accountID.String(), 10)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		t.Fatalf("begin tx: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, accountID, 1, 0)
+	if err != nil {
+		t.Fatalf("CreateMerchantPayIntentTx: %v", err)
+	}
+	if err := ConfirmPaymentTx(ctx, tx, pi.ID); !errors.Is(err, ErrMerchantBlocked) {
+		t.Fatalf("ConfirmPaymentTx blocked merchant = %v", err)
+	}
+}
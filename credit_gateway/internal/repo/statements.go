@@ -0,0 +1,331 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrStatementPeriodNotOpen = errors.New("statement date is not after the previous statement")
+
+var ErrInvalidStatementDay = errors.New("statement_day must be between 1 and 28")
+
+type Statement struct {
+	ID                  int64
+	AccountID           uuid.UUID
+	Period              string
+	PeriodStart         time.Time
+	PeriodEnd           time.Time
+	Currency            money.Currency
+	OpeningBalanceCents int64
+	ClosingBalanceCents int64
+	MinimumPaymentCents int64
+	DueDate             time.Time
+	CreatedAt           time.Time
+
+	Entries []StatementEntry
+}
+
+type StatementEntry struct {
+	LedgerEntryID   uuid.UUID
+	PaymentIntentID *uuid.UUID
+	EntryType       string
+	AmountCents     int64
+	PostedAt        time.Time
+}
+
+// AccountBillingState is what the billing job needs to find an account's due statements.
+type AccountBillingState struct {
+	AccountID    uuid.UUID
+	StatementDay int
+	// OpenedOn: date the current cycle started (last statement's period_end, or account creation)
+	OpenedOn time.Time
+}
+
+// ListAccountBillingStates pages through accounts by id (keyset, after afterID).
+func ListAccountBillingStates(ctx context.Context, db *pgxpool.Pool, afterID uuid.UUID, limit int) ([]AccountBillingState, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := db.Query(ctx, `
+select a.id, a.statement_day,
+       coalesce((select max(s.period_end) from statements s where s.account_id = a.id),
+                (a.created_at at time zone 'UTC')::date)
+from accounts a
+where a.id > $1
+order by a.id
+limit $2
+`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AccountBillingState
+	for rows.Next() {
+		var (
+			s   AccountBillingState
+			day int16
+		)
+		if err := rows.Scan(&s.AccountID, &day, &s.OpenedOn); err != nil {
+			return nil, err
+		}
+		s.StatementDay = int(day)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GenerateStatement closes the account's open cycle on statementDate: it snapshots the
+// ledger entries since the previous statement, the opening and closing balances, and the
+// minimum payment and due date from terms. Generating an existing period returns it unchanged.
+func GenerateStatement(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	accountID uuid.UUID,
+	statementDate time.Time,
+	terms domain.BillingTerms,
+) (*Statement, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	st, err := GenerateStatementTx(ctx, tx, accountID, statementDate, terms)
+	if err != nil {
+		return nil, err
+	}
+	return st, tx.Commit(ctx)
+}
+
+func GenerateStatementTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	accountID uuid.UUID,
+	statementDate time.Time,
+	terms domain.BillingTerms,
+) (*Statement, error) {
+	y, m, d := statementDate.UTC().Date()
+	periodEnd := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	period := domain.StatementPeriod(periodEnd)
+
+	var (
+		currency  money.Currency
+		createdOn time.Time
+	)
+	if err := tx.QueryRow(ctx, `
+select currency, (created_at at time zone 'UTC')::date
+from accounts
+where id = $1
+for update
+`, accountID).Scan(&currency, &createdOn); err != nil {
+		return nil, err
+	}
+
+	if existing, err := getStatementTx(ctx, tx, accountID, period); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// the new period starts where the previous one ended
+	st := Statement{
+		AccountID:   accountID,
+		Period:      period,
+		PeriodStart: createdOn,
+		PeriodEnd:   periodEnd,
+		Currency:    currency,
+	}
+	var (
+		prevEnd     *time.Time
+		prevClosing *int64
+	)
+	if err := tx.QueryRow(ctx, `
+select period_end, closing_balance_cents
+from statements
+where account_id = $1
+order by period_end desc
+limit 1
+`, accountID).Scan(&prevEnd, &prevClosing); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if prevEnd != nil {
+		st.PeriodStart = *prevEnd
+		st.OpeningBalanceCents = *prevClosing
+	} else if err := tx.QueryRow(ctx, `
+select coalesce(sum(amount_cents), 0)
+from ledger_entries
+where account_id = $1
+  and created_at < ($2::date::timestamp at time zone 'UTC')
+`, accountID, st.PeriodStart).Scan(&st.OpeningBalanceCents); err != nil {
+		return nil, err
+	}
+	if !st.PeriodEnd.After(st.PeriodStart) {
+		return nil, ErrStatementPeriodNotOpen
+	}
+
+	rows, err := tx.Query(ctx, `
+select id, payment_intent_id, entry_type, amount_cents, created_at
+from ledger_entries
+where account_id = $1
+  and created_at >= ($2::date::timestamp at time zone 'UTC')
+  and created_at <  ($3::date::timestamp at time zone 'UTC')
+order by created_at asc, id asc
+`, accountID, st.PeriodStart, st.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	closing := money.Cents(st.OpeningBalanceCents)
+	for rows.Next() {
+		var e StatementEntry
+		if err := rows.Scan(&e.LedgerEntryID, &e.PaymentIntentID, &e.EntryType, &e.AmountCents, &e.PostedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if closing, err = money.Add(closing, money.Cents(e.AmountCents)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		st.Entries = append(st.Entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	minPayment, err := terms.MinimumPayment(closing)
+	if err != nil {
+		return nil, err
+	}
+	st.ClosingBalanceCents = int64(closing)
+	st.MinimumPaymentCents = int64(minPayment)
+	st.DueDate = terms.DueDate(st.PeriodEnd)
+
+	if err := tx.QueryRow(ctx, `
+insert into statements
+  (account_id, period, period_start, period_end, currency,
+   opening_balance_cents, closing_balance_cents, minimum_payment_cents, due_date)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+returning id, created_at
+`, st.AccountID, st.Period, st.PeriodStart, st.PeriodEnd, st.Currency,
+		st.OpeningBalanceCents, st.ClosingBalanceCents, st.MinimumPaymentCents, st.DueDate,
+	).Scan(&st.ID, &st.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	for _, e := range st.Entries {
+		if _, err := tx.Exec(ctx, `
+insert into statement_entries (statement_id, ledger_entry_id, payment_intent_id, entry_type, amount_cents, posted_at)
+values ($1, $2, $3, $4, $5, $6)
+`, st.ID, e.LedgerEntryID, e.PaymentIntentID, e.EntryType, e.AmountCents, e.PostedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return &st, nil
+}
+
+const statementColumns = `id, account_id, period, period_start, period_end, currency,
+  opening_balance_cents, closing_balance_cents, minimum_payment_cents, due_date, created_at`
+
+func scanStatement(row pgx.Row, st *Statement) error {
+	return row.Scan(
+		&st.ID, &st.AccountID, &st.Period, &st.PeriodStart, &st.PeriodEnd, &st.Currency,
+		&st.OpeningBalanceCents, &st.ClosingBalanceCents, &st.MinimumPaymentCents, &st.DueDate, &st.CreatedAt,
+	)
+}
+
+// ListStatements returns an account's statements, newest first, without entries.
+func ListStatements(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID) ([]Statement, error) {
+	rows, err := db.Query(ctx, `
+select `+statementColumns+`
+from statements
+where account_id = $1
+order by period_end desc
+`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Statement
+	for rows.Next() {
+		var st Statement
+		if err := scanStatement(rows, &st); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// GetStatement returns one period ("YYYY-MM") with its entries.
+func GetStatement(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, period string) (*Statement, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	return getStatementTx(ctx, tx, accountID, period)
+}
+
+func getStatementTx(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, period string) (*Statement, error) {
+	var st Statement
+	if err := scanStatement(tx.QueryRow(ctx, `
+select `+statementColumns+`
+from statements
+where account_id = $1 and period = $2
+`, accountID, period), &st); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+select ledger_entry_id, payment_intent_id, entry_type, amount_cents, posted_at
+from statement_entries
+where statement_id = $1
+order by posted_at asc, id asc
+`, st.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e StatementEntry
+		if err := rows.Scan(&e.LedgerEntryID, &e.PaymentIntentID, &e.EntryType, &e.AmountCents, &e.PostedAt); err != nil {
+			return nil, err
+		}
+		st.Entries = append(st.Entries, e)
+	}
+	return &st, rows.Err()
+}
+
+// SetStatementDay moves the account's cycle close to another day of the month (1..28).
+// Statements are keyed by month, so if the move would close two cycles in one month the
+// second close is skipped and its entries roll into the following statement.
+func SetStatementDay(ctx context.Context, db *pgxpool.Pool, accountID uuid.UUID, day int) error {
+	if day < 1 || day > domain.MaxStatementDay {
+		return ErrInvalidStatementDay
+	}
+	ct, err := db.Exec(ctx,
+		`update accounts set statement_day = $2, updated_at = now() where id = $1`,
+		accountID, day,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
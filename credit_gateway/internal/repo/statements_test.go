@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateStatement_SnapshotsPeriodAndChainsBalances(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	if _, err := db.Exec(context.Background(),
+		`UPDATE accounts SET created_at = $2, statement_day = 15 WHERE id = $1`, accountID, day(2026, 1, 2),
+	); err != nil {
+		t.Fatalf("backdate account: %v", err)
+	}
+
+	confirmAt := func(amount int64, at time.Time) {
+		t.Helper()
+		pi, err := CreatePaymentIntent(context.Background(), db, accountID, amount, 0)
+		if err != nil {
+			t.Fatalf("CreatePaymentIntent: %v", err)
+		}
+		if err := ConfirmPayment(context.Background(), db, pi.ID); err != nil {
+			t.Fatalf("ConfirmPayment: %v", err)
+		}
+		if _, err := db.Exec(context.Background(),
+			`UPDATE ledger_entries SET created_at = $2 WHERE payment_intent_id = $1`, pi.ID, at,
+		); err != nil {
+			t.Fatalf("backdate ledger: %v", err)
+		}
+	}
+	confirmAt(10, day(2026, 1, 10)) // 10 principal + 10 interest, first cycle
+	confirmAt(5, day(2026, 1, 20))  // second cycle
+
+	terms := domain.DefaultBillingTerms()
+	jan, err := GenerateStatement(context.Background(), db, accountID, day(2026, 1, 15), terms)
+	if err != nil {
+		t.Fatalf("GenerateStatement jan: %v", err)
+	}
+	if jan.Period != "2026-01" || jan.OpeningBalanceCents != 0 || jan.ClosingBalanceCents != 20 || len(jan.Entries) != 2 {
+		t.Fatalf("jan = %+v", jan)
+	}
+	if jan.MinimumPaymentCents != 20 || !jan.DueDate.Equal(day(2026, 2, 5)) {
+		t.Fatalf("jan min=%d due=%s", jan.MinimumPaymentCents, jan.DueDate)
+	}
+
+	// idempotent
+	again, err := GenerateStatement(context.Background(), db, accountID, day(2026, 1, 15), terms)
+	if err != nil || again.ID != jan.ID {
+		t.Fatalf("regenerate jan = %+v, %v", again, err)
+	}
+
+	feb, err := GenerateStatement(context.Background(), db, accountID, day(2026, 2, 15), terms)
+	if err != nil {
+		t.Fatalf("GenerateStatement feb: %v", err)
+	}
+	if !feb.PeriodStart.Equal(jan.PeriodEnd) || feb.OpeningBalanceCents != 20 || feb.ClosingBalanceCents != 30 || len(feb.Entries) != 2 {
+		t.Fatalf("feb = %+v", feb)
+	}
+
+	got, err := GetStatement(context.Background(), db, accountID, "2026-02")
+	if err != nil {
+		t.Fatalf("GetStatement: %v", err)
+	}
+	if got.ID != feb.ID || len(got.Entries) != 2 {
+		t.Fatalf("GetStatement = %+v", got)
+	}
+}
@@ -0,0 +1,584 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SubscriptionInstallmentCents is the most one auto-collected installment charges: confirm
+// refuses (and fines) anything over 10 cents, so a period is collected in installments.
+// Installments skip the risk velocity rules, which would otherwise decline long periods;
+// that is why a period's request cannot be paid through the public pay endpoints. They
+// are all priced at the payer's next attempt without counting it, so a period costs the
+// same rate whatever its size and leaves attempt_count alone.
+const SubscriptionInstallmentCents = 10
+
+var (
+	ErrInvalidSubscription     = errors.New("subscription needs merchant_id, a positive amount, a webhook_url and ends_at after starts_at")
+	ErrSubscriptionTransition  = errors.New("subscription status does not allow this")
+	ErrInstallmentNotSucceeded = errors.New("installment intent did not succeed")
+	ErrSubscriptionPeriod      = errors.New("subscription periods are collected by the gateway")
+)
+
+// Subscription statuses. past_due: the open period failed to collect and waits for a
+// dunning retry; canceled and ended are final.
+const (
+	SubscriptionActive   = "active"
+	SubscriptionPaused   = "paused"
+	SubscriptionPastDue  = "past_due"
+	SubscriptionCanceled = "canceled"
+	SubscriptionEnded    = "ended"
+)
+
+type Subscription struct {
+	ID             int64
+	MerchantID     string
+	PayerAccountID uuid.UUID
+	AmountCents    int64
+	Currency       money.Currency
+	Interval       domain.BillingInterval
+	StartsAt       time.Time
+	EndsAt         *time.Time
+	WebhookURL     string
+	Status         string
+
+	// Periods: periods opened (or skipped while paused) so far
+	Periods      int
+	NextPeriodAt time.Time
+
+	CurrentMerchantRequestID *int64
+	FailedAttempts           int
+	NextAttemptAt            *time.Time
+	LastError                *string
+
+	CanceledAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type CreateSubscriptionInput struct {
+	MerchantID     string
+	PayerAccountID uuid.UUID
+	AmountCents    int64
+	Currency       money.Currency // "" = the payer's currency
+	Interval       domain.BillingInterval
+	StartsAt       time.Time
+	EndsAt         *time.Time
+	WebhookURL     string
+}
+
+const subscriptionColumns = `id, merchant_id, payer_account_id, amount_cents, currency, billing_interval,
+  starts_at, ends_at, webhook_url, status, periods, next_period_at,
+  current_merchant_request_id, failed_attempts, next_attempt_at, last_error,
+  canceled_at, created_at, updated_at`
+
+func scanSubscription(row pgx.Row, s *Subscription) error {
+	return row.Scan(
+		&s.ID, &s.MerchantID, &s.PayerAccountID, &s.AmountCents, &s.Currency, &s.Interval,
+		&s.StartsAt, &s.EndsAt, &s.WebhookURL, &s.Status, &s.Periods, &s.NextPeriodAt,
+		&s.CurrentMerchantRequestID, &s.FailedAttempts, &s.NextAttemptAt, &s.LastError,
+		&s.CanceledAt, &s.CreatedAt, &s.UpdatedAt,
+	)
+}
+
+// CreateSubscription starts billing the payer every interval from StartsAt. The amount
+// is in the payer's currency, like merchant requests.
+func CreateSubscription(ctx context.Context, db *pgxpool.Pool, in CreateSubscriptionInput) (*Subscription, error) {
+	if in.MerchantID == "" || in.AmountCents <= 0 || in.WebhookURL == "" ||
+		(in.EndsAt != nil && !in.EndsAt.After(in.StartsAt)) {
+		return nil, ErrInvalidSubscription
+	}
+	if _, err := domain.ParseBillingInterval(string(in.Interval)); err != nil {
+		return nil, ErrInvalidSubscription
+	}
+
+	var payerCurrency money.Currency
+	if err := db.QueryRow(ctx,
+		`select currency from accounts where id = $1`, in.PayerAccountID,
+	).Scan(&payerCurrency); err != nil {
+		return nil, err
+	}
+	if in.Currency == "" {
+		in.Currency = payerCurrency
+	}
+	if in.Currency != payerCurrency {
+		return nil, money.ErrCurrencyMismatch
+	}
+
+	var s Subscription
+	if err := scanSubscription(db.QueryRow(ctx, `
+insert into subscriptions
+  (merchant_id, payer_account_id, amount_cents, currency, billing_interval, starts_at, ends_at, webhook_url, next_period_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $6)
+returning `+subscriptionColumns,
+		in.MerchantID, in.PayerAccountID, in.AmountCents, in.Currency, string(in.Interval),
+		in.StartsAt, in.EndsAt, in.WebhookURL,
+	), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func GetSubscription(ctx context.Context, db *pgxpool.Pool, id int64) (*Subscription, error) {
+	var s Subscription
+	if err := scanSubscription(db.QueryRow(ctx, `
+select `+subscriptionColumns+`
+from subscriptions
+where id = $1
+`, id), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListMerchantSubscriptions returns a merchant's subscriptions, oldest first.
+func ListMerchantSubscriptions(ctx context.Context, db *pgxpool.Pool, merchantID string) ([]Subscription, error) {
+	rows, err := db.Query(ctx, `
+select `+subscriptionColumns+`
+from subscriptions
+where merchant_id = $1
+order by id
+`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Subscription
+	for rows.Next() {
+		var s Subscription
+		if err := scanSubscription(rows, &s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func lockSubscriptionTx(ctx context.Context, tx pgx.Tx, id int64) (*Subscription, error) {
+	var s Subscription
+	if err := scanSubscription(tx.QueryRow(ctx, `
+select `+subscriptionColumns+`
+from subscriptions
+where id = $1
+for update
+`, id), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// saveSubscriptionTx writes back the mutable state of a locked subscription.
+func saveSubscriptionTx(ctx context.Context, tx pgx.Tx, s *Subscription) error {
+	return tx.QueryRow(ctx, `
+update subscriptions
+   set status = $2, periods = $3, next_period_at = $4,
+       current_merchant_request_id = $5, failed_attempts = $6, next_attempt_at = $7, last_error = $8,
+       canceled_at = $9, updated_at = now()
+ where id = $1
+returning updated_at
+`, s.ID, s.Status, s.Periods, s.NextPeriodAt,
+		s.CurrentMerchantRequestID, s.FailedAttempts, s.NextAttemptAt, s.LastError,
+		s.CanceledAt,
+	).Scan(&s.UpdatedAt)
+}
+
+// subscriptionEventTx enqueues a subscription.* webhook to the subscription's webhook_url.
+func subscriptionEventTx(ctx context.Context, tx pgx.Tx, s *Subscription, eventType string, extra map[string]any) error {
+	payload := map[string]any{
+		"subscription_id":     s.ID,
+		"merchant_id":         s.MerchantID,
+		"payer_account_id":    s.PayerAccountID.String(),
+		"status":              s.Status,
+		"amount_cents":        s.AmountCents,
+		"currency":            s.Currency,
+		"interval":            s.Interval,
+		"period":              s.Periods,
+		"merchant_request_id": s.CurrentMerchantRequestID,
+		"next_period_at":      s.NextPeriodAt,
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	_, err := InsertOutboxEventTx(ctx, tx, OutboxEvent{
+		EventType:     eventType,
+		AggregateType: "subscription",
+		AggregateID:   s.ID,
+		TargetURL:     s.WebhookURL,
+		Payload:       payload,
+	})
+	return err
+}
+
+// cancelOpenPeriodTx cancels the open period's merchant request if it is still unpaid.
+func cancelOpenPeriodTx(ctx context.Context, tx pgx.Tx, s *Subscription) error {
+	if s.CurrentMerchantRequestID == nil {
+		return nil
+	}
+	_, err := tx.Exec(ctx,
+		`update merchant_requests set status = 'canceled' where id = $1 and status = 'pending'`,
+		*s.CurrentMerchantRequestID,
+	)
+	return err
+}
+
+// transitionSubscription applies a pause / resume / cancel under the subscription lock.
+func transitionSubscription(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	id int64,
+	apply func(tx pgx.Tx, s *Subscription) (event string, err error),
+) (*Subscription, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockSubscriptionTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	event, err := apply(tx, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveSubscriptionTx(ctx, tx, s); err != nil {
+		return nil, err
+	}
+	if err := subscriptionEventTx(ctx, tx, s, event, nil); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// PauseSubscription stops opening periods and retrying the open one until resumed.
+func PauseSubscription(ctx context.Context, db *pgxpool.Pool, id int64) (*Subscription, error) {
+	return transitionSubscription(ctx, db, id, func(_ pgx.Tx, s *Subscription) (string, error) {
+		if s.Status != SubscriptionActive && s.Status != SubscriptionPastDue {
+			return "", ErrSubscriptionTransition
+		}
+		s.Status = SubscriptionPaused
+		return "subscription.paused", nil
+	})
+}
+
+// ResumeSubscription restarts a paused subscription. Periods that started while it was
+// paused are skipped, not billed; an open period is retried right away.
+func ResumeSubscription(ctx context.Context, db *pgxpool.Pool, id int64, now time.Time) (*Subscription, error) {
+	return transitionSubscription(ctx, db, id, func(_ pgx.Tx, s *Subscription) (string, error) {
+		if s.Status != SubscriptionPaused {
+			return "", ErrSubscriptionTransition
+		}
+		for !s.NextPeriodAt.After(now) {
+			s.Periods++
+			s.NextPeriodAt = s.Interval.PeriodStart(s.StartsAt, s.Periods)
+		}
+		s.Status = SubscriptionActive
+		if s.CurrentMerchantRequestID != nil {
+			s.NextAttemptAt = &now
+			if s.FailedAttempts > 0 {
+				s.Status = SubscriptionPastDue
+			}
+		}
+		return "subscription.resumed", nil
+	})
+}
+
+// CancelSubscription ends billing for good; an unpaid open period is canceled with it.
+func CancelSubscription(ctx context.Context, db *pgxpool.Pool, id int64, now time.Time) (*Subscription, error) {
+	return transitionSubscription(ctx, db, id, func(tx pgx.Tx, s *Subscription) (string, error) {
+		if s.Status == SubscriptionCanceled || s.Status == SubscriptionEnded {
+			return "", ErrSubscriptionTransition
+		}
+		if err := cancelOpenPeriodTx(ctx, tx, s); err != nil {
+			return "", err
+		}
+		s.Status = SubscriptionCanceled
+		s.CanceledAt = &now
+		s.NextAttemptAt = nil
+		return "subscription.canceled", nil
+	})
+}
+
+// ListDueSubscriptions returns ids (after afterID) with a period to open, an open period
+// to (re)collect, or an end date passed.
+func ListDueSubscriptions(ctx context.Context, db *pgxpool.Pool, now time.Time, afterID int64, limit int) ([]int64, error) {
+	rows, err := db.Query(ctx, `
+select id
+from subscriptions
+where status in ('active', 'past_due')
+  and id > $2
+  and ((current_merchant_request_id is null and (next_period_at <= $1 or ends_at <= $1))
+       or (current_merchant_request_id is not null and next_attempt_at <= $1))
+order by id
+limit $3
+`, now, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// ProcessSubscription opens the subscription's next period when it is due and collects
+// the open period from the payer. A collection refused for insufficient credit makes the
+// subscription past_due and is retried per dunning; once retries run out the subscription
+// is canceled. Any other refusal (locked account, spending controls, ...) cancels it at once.
+func ProcessSubscription(ctx context.Context, db *pgxpool.Pool, id int64, dunning domain.DunningPolicy, now time.Time) (*Subscription, error) {
+	s, collect, err := openDuePeriod(ctx, db, id, now)
+	if err != nil || !collect {
+		return s, err
+	}
+
+	var refused error
+	for {
+		done, err := collectInstallment(ctx, db, *s.CurrentMerchantRequestID, s.PayerAccountID)
+		if err != nil {
+			if !IsConfirmBusinessError(err) {
+				return nil, err
+			}
+			refused = err
+			break
+		}
+		if done {
+			break
+		}
+	}
+
+	return settleCollection(ctx, db, id, refused, dunning, now)
+}
+
+// openDuePeriod opens the next period if it is due (or ends the subscription) and reports
+// whether an open period is ready to be collected.
+func openDuePeriod(ctx context.Context, db *pgxpool.Pool, id int64, now time.Time) (*Subscription, bool, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockSubscriptionTx(ctx, tx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.Status != SubscriptionActive && s.Status != SubscriptionPastDue {
+		return s, false, nil
+	}
+
+	if s.CurrentMerchantRequestID == nil {
+		if s.EndsAt != nil && !s.NextPeriodAt.Before(*s.EndsAt) {
+			s.Status = SubscriptionEnded
+			if err := saveSubscriptionTx(ctx, tx, s); err != nil {
+				return nil, false, err
+			}
+			if err := subscriptionEventTx(ctx, tx, s, "subscription.ended", nil); err != nil {
+				return nil, false, err
+			}
+			return s, false, tx.Commit(ctx)
+		}
+		if s.NextPeriodAt.After(now) {
+			return s, false, nil
+		}
+
+		mrID, err := openPeriodRequestTx(ctx, tx, s)
+		if err != nil {
+			return nil, false, err
+		}
+		s.Periods++
+		s.NextPeriodAt = s.Interval.PeriodStart(s.StartsAt, s.Periods)
+		s.CurrentMerchantRequestID = &mrID
+		s.FailedAttempts = 0
+		s.NextAttemptAt = &now
+		s.LastError = nil
+		if err := saveSubscriptionTx(ctx, tx, s); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if s.NextAttemptAt != nil && s.NextAttemptAt.After(now) {
+		return s, false, nil
+	}
+	return s, true, tx.Commit(ctx)
+}
+
+// openPeriodRequestTx opens the merchant request for the subscription's next period.
+func openPeriodRequestTx(ctx context.Context, tx pgx.Tx, s *Subscription) (int64, error) {
+	ref := fmt.Sprintf("sub_%d_%d", s.ID, s.Periods+1)
+
+	var mrID int64
+	if err := tx.QueryRow(ctx, `
+insert into merchant_requests
+  (merchant_id, merchant_request_reference, payer_account_id, target_cents, currency, webhook_url, subscription_id)
+values ($1, $2, $3, $4, $5, $6, $7)
+returning id
+`, s.MerchantID, ref, s.PayerAccountID, s.AmountCents, s.Currency, s.WebhookURL, s.ID).Scan(&mrID); err != nil {
+		return 0, err
+	}
+	err := insertMerchantRequestPayersTx(ctx, tx, mrID, []MerchantRequestPayer{
+		{AccountID: s.PayerAccountID.String(), ShareCents: s.AmountCents},
+	})
+	return mrID, err
+}
+
+// collectInstallment charges the payer one installment of the open period through the
+// normal confirm path and reports whether the period is fully paid. A refused confirm is
+// committed (the attempt, refusal or account lock stick) and its error returned.
+func collectInstallment(ctx context.Context, db *pgxpool.Pool, mrID int64, payer uuid.UUID) (bool, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	mr, err := GetMerchantRequestByIDForUpdate(ctx, tx, mrID)
+	if err != nil {
+		return false, err
+	}
+	if mr.Status != "pending" {
+		return true, nil
+	}
+	p, err := mr.FindPayer(payer.String())
+	if err != nil {
+		return false, err
+	}
+	amount := min(p.Outstanding(), SubscriptionInstallmentCents)
+	if amount == 0 {
+		return true, nil
+	}
+
+	pi, err := CreateMerchantPayIntentTx(ctx, tx, mrID, payer, amount, 0)
+	if err != nil {
+		return false, err
+	}
+	if err := confirmInstallmentTx(ctx, tx, pi.ID); err != nil {
+		if IsConfirmBusinessError(err) {
+			if cerr := tx.Commit(ctx); cerr != nil {
+				return false, cerr
+			}
+		}
+		return false, err
+	}
+	status, err := GetPaymentIntentStatusTx(ctx, tx, pi.ID)
+	if err != nil {
+		return false, err
+	}
+	if status != "succeeded" {
+		return false, fmt.Errorf("%w: %s", ErrInstallmentNotSucceeded, status)
+	}
+
+	if _, err := TryMarkMerchantPayProgressedTx(ctx, tx, pi.ID); err != nil {
+		return false, err
+	}
+	_, _, completed, err := IncrementMerchantPayerProgress(ctx, tx, mrID, payer.String(), amount)
+	if err != nil {
+		return false, err
+	}
+	return completed, tx.Commit(ctx)
+}
+
+// settleCollection records the outcome of collecting the open period: renewed, or a
+// failed attempt handled by the dunning policy.
+func settleCollection(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	id int64,
+	refused error,
+	dunning domain.DunningPolicy,
+	now time.Time,
+) (*Subscription, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockSubscriptionTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	// canceled while collecting: nothing left to settle
+	if s.CurrentMerchantRequestID == nil || s.Status == SubscriptionCanceled || s.Status == SubscriptionEnded {
+		return s, nil
+	}
+
+	var (
+		event string
+		extra map[string]any
+	)
+	switch {
+	case refused == nil:
+		event = "subscription.renewed"
+		if s.Status == SubscriptionPastDue {
+			s.Status = SubscriptionActive
+		}
+		// the event names the period just paid
+		extra = map[string]any{"merchant_request_id": *s.CurrentMerchantRequestID}
+		s.CurrentMerchantRequestID = nil
+		s.FailedAttempts = 0
+		s.NextAttemptAt = nil
+		s.LastError = nil
+	default:
+		s.FailedAttempts++
+		msg := refused.Error()
+		s.LastError = &msg
+		extra = map[string]any{"failed_attempts": s.FailedAttempts, "error": msg}
+
+		next, retry := dunning.NextRetry(s.FailedAttempts, now)
+		switch {
+		case !errors.Is(refused, ErrInsufficientCredit):
+			retry = false
+			extra["reason"] = "payment_refused"
+		case !retry:
+			extra["reason"] = "dunning_exhausted"
+		}
+		if retry {
+			event = "subscription.past_due"
+			if s.Status == SubscriptionActive {
+				s.Status = SubscriptionPastDue
+			}
+			s.NextAttemptAt = &next
+			extra["next_attempt_at"] = next
+		} else {
+			event = "subscription.canceled"
+			if err := cancelOpenPeriodTx(ctx, tx, s); err != nil {
+				return nil, err
+			}
+			s.Status = SubscriptionCanceled
+			s.CanceledAt = &now
+			s.NextAttemptAt = nil
+		}
+	}
+
+	if err := saveSubscriptionTx(ctx, tx, s); err != nil {
+		return nil, err
+	}
+	if err := subscriptionEventTx(ctx, tx, s, event, extra); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
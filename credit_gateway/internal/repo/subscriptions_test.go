@@ -0,0 +1,183 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gateway/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestSubscription_RenewsThenDunsAndCancels(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 5000, "active")
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := CreateSubscription(ctx, db, CreateSubscriptionInput{
+		MerchantID:     "merchant_sub",
+		PayerAccountID: accountID,
+		AmountCents:    25,
+		Interval:       domain.IntervalDay,
+		StartsAt:       start,
+		WebhookURL:     "http://example.test/webhook",
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	dunning := domain.DunningPolicy{RetryAfter: []time.Duration{time.Hour}}
+
+	// first period: collected in installments of at most 10 cents
+	s, err = ProcessSubscription(ctx, db, s.ID, dunning, start)
+	if err != nil {
+		t.Fatalf("ProcessSubscription: %v", err)
+	}
+	if s.Status != SubscriptionActive || s.Periods != 1 || s.CurrentMerchantRequestID != nil ||
+		!s.NextPeriodAt.Equal(start.AddDate(0, 0, 1)) {
+		t.Fatalf("after first period = %+v", s)
+	}
+	var paid int64
+	var status string
+	if err := db.QueryRow(ctx,
+		`select paid_cents, status from merchant_requests where subscription_id = $1`, s.ID,
+	).Scan(&paid, &status); err != nil {
+		t.Fatalf("load period request: %v", err)
+	}
+	if paid != 25 || status != "completed" {
+		t.Fatalf("period request paid=%d status=%s, want 25 completed", paid, status)
+	}
+
+	// nothing due before the next period
+	if ids, err := ListDueSubscriptions(ctx, db, start.Add(time.Hour), 0, 10); err != nil || len(ids) != 0 {
+		t.Fatalf("due before next period = %v, %v", ids, err)
+	}
+
+	// no credit left: the second period goes past due, then the retry exhausts dunning
+	if _, err := db.Exec(ctx, `update accounts set credit_limit_cents = balance_cents where id = $1`, accountID); err != nil {
+		t.Fatalf("shrink credit: %v", err)
+	}
+	second := start.AddDate(0, 0, 1)
+	s, err = ProcessSubscription(ctx, db, s.ID, dunning, second)
+	if err != nil {
+		t.Fatalf("ProcessSubscription (second period): %v", err)
+	}
+	if s.Status != SubscriptionPastDue || s.FailedAttempts != 1 || s.NextAttemptAt == nil ||
+		!s.NextAttemptAt.Equal(second.Add(time.Hour)) {
+		t.Fatalf("after failed collection = %+v", s)
+	}
+
+	s, err = ProcessSubscription(ctx, db, s.ID, dunning, second.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ProcessSubscription (retry): %v", err)
+	}
+	if s.Status != SubscriptionCanceled || s.CanceledAt == nil {
+		t.Fatalf("after dunning exhausted = %+v", s)
+	}
+	if _, err := PauseSubscription(ctx, db, s.ID); !errors.Is(err, ErrSubscriptionTransition) {
+		t.Fatalf("pause canceled err = %v, want ErrSubscriptionTransition", err)
+	}
+
+	rows, err := db.Query(ctx, `
+SELECT event_type FROM webhook_outbox WHERE aggregate_type = 'subscription' AND aggregate_id = $1 ORDER BY id
+`, s.ID)
+	if err != nil {
+		t.Fatalf("list outbox: %v", err)
+	}
+	defer rows.Close()
+	var events []string
+	for rows.Next() {
+		var e string
+		if err := rows.Scan(&e); err != nil {
+			t.Fatalf("scan outbox: %v", err)
+		}
+		events = append(events, e)
+	}
+	want := []string{"subscription.renewed", "subscription.past_due", "subscription.canceled"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", events, want)
+		}
+	}
+}
+
+func TestSubscription_LargePeriodSkipsVelocityRules(t *testing.T) {
+	db := testPool(t)
+	resetDB(t, db)
+
+	ctx := context.Background()
+	accountID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	seedAccount(t, db, accountID, 100000, "active")
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 25 installments: more than burst_confirms allows in a minute
+	s, err := CreateSubscription(ctx, db, CreateSubscriptionInput{
+		MerchantID:     "merchant_sub",
+		PayerAccountID: accountID,
+		AmountCents:    250,
+		Interval:       domain.IntervalDay,
+		StartsAt:       start,
+		WebhookURL:     "http://example.test/webhook",
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	dunning := domain.DunningPolicy{RetryAfter: []time.Duration{time.Hour}}
+
+	s, err = ProcessSubscription(ctx, db, s.ID, dunning, start)
+	if err != nil {
+		t.Fatalf("ProcessSubscription: %v", err)
+	}
+	if s.Status != SubscriptionActive || s.CurrentMerchantRequestID != nil {
+		t.Fatalf("after first period = %+v", s)
+	}
+	var paid int64
+	if err := db.QueryRow(ctx,
+		`select paid_cents from merchant_requests where subscription_id = $1`, s.ID,
+	).Scan(&paid); err != nil {
+		t.Fatalf("load period request: %v", err)
+	}
+	if paid != 250 {
+		t.Fatalf("period request paid=%d, want 250", paid)
+	}
+	var decisions int
+	if err := db.QueryRow(ctx, `select count(*) from risk_decisions where account_id = $1`, accountID).Scan(&decisions); err != nil {
+		t.Fatalf("count risk decisions: %v", err)
+	}
+	if decisions != 0 {
+		t.Fatalf("risk decisions = %d, want 0", decisions)
+	}
+	// 25 installments are priced as one attempt and do not count it
+	if _, _, _, attempts := getAccountState(t, db, accountID); attempts != 0 {
+		t.Fatalf("attempt_count after a period = %d, want 0", attempts)
+	}
+	var rates, interests int
+	if err := db.QueryRow(ctx, `
+select count(distinct rate_bps), count(*) from ledger_entries where account_id = $1 and entry_type = 'interest'
+`, accountID).Scan(&rates, &interests); err != nil {
+		t.Fatalf("load interest entries: %v", err)
+	}
+	if rates != 1 || interests != 25 {
+		t.Fatalf("interest entries = %d at %d rates, want 25 at 1", interests, rates)
+	}
+
+	// a refusal other than insufficient credit is not retried
+	if _, err := db.Exec(ctx, `update accounts set status = 'locked' where id = $1`, accountID); err != nil {
+		t.Fatalf("lock account: %v", err)
+	}
+	s, err = ProcessSubscription(ctx, db, s.ID, dunning, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("ProcessSubscription (second period): %v", err)
+	}
+	if s.Status != SubscriptionCanceled || s.FailedAttempts != 1 {
+		t.Fatalf("after locked account = %+v", s)
+	}
+}
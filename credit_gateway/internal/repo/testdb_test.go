@@ -48,7 +48,30 @@ func resetDB(t *testing.T, db *pgxpool.Pool) {
 	_, err := db.Exec(ctx, `
 TRUNCATE TABLE
   webhook_outbox,
+  invoice_line_items,
+  invoices,
+  merchant_ledger_entries,
+  dispute_evidence,
+  disputes,
+  payouts,
+  merchant_balances,
+  merchant_fee_schedules,
+  delinquency_transitions,
+  payment_review_events,
+  payment_reviews,
+  risk_decisions,
+  spending_merchant_rules,
+  spending_controls,
+  statement_entries,
+  statements,
+  interest_accruals,
+  interest_promotions,
+  account_interest_overrides,
+  payment_quotes,
+  holds,
   merchant_pay_intents,
+  merchant_request_payers,
+  subscriptions,
   ledger_entries,
   payment_intents,
   merchant_requests,
@@ -59,4 +82,12 @@ CASCADE;
 	if err != nil {
 		t.Fatalf("resetDB truncate: %v", err)
 	}
+	// keep only the seeded version 1 policy
+	if _, err := db.Exec(ctx, `DELETE FROM interest_policies WHERE version > 1;`); err != nil {
+		t.Fatalf("resetDB interest_policies: %v", err)
+	}
+	// keep only the seeded risk rules
+	if _, err := db.Exec(ctx, `DELETE FROM risk_rules WHERE id > 3;`); err != nil {
+		t.Fatalf("resetDB risk_rules: %v", err)
+	}
 }
@@ -0,0 +1,169 @@
+// Package risk scores a confirm against configurable velocity rules. Rules are stored as
+// definitions (kind, window, threshold, action); each kind is a Metric over the account's
+// recent confirms, and new kinds plug in with Register.
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrUnknownRuleKind = errors.New("unknown risk rule kind")
+
+// Action is what a rule hit does to the confirm, from least to most severe.
+type Action string
+
+const (
+	Allow   Action = "allow"
+	Review  Action = "review"  // let it through, flag for review
+	StepUp  Action = "step_up" // hold until the owner completes a step-up challenge
+	Decline Action = "decline" // refuse the intent
+)
+
+var severity = map[Action]int{Allow: 0, Review: 1, StepUp: 2, Decline: 3}
+
+func (a Action) Valid() bool { _, ok := severity[a]; return ok && a != Allow }
+
+// Event is one confirm seen by the engine.
+type Event struct {
+	At          time.Time
+	MerchantID  string // "" = not a merchant payment
+	AmountCents int64
+}
+
+// Metric measures the current confirm against the account's confirms inside the rule's
+// window (oldest first, current excluded). The rule hits when the value exceeds its threshold.
+type Metric func(window []Event, current Event) int64
+
+var metrics = map[string]Metric{}
+
+// Register adds a rule kind. It panics on duplicates, like http.Handle.
+func Register(kind string, m Metric) {
+	if _, ok := metrics[kind]; ok {
+		panic("risk: duplicate rule kind " + kind)
+	}
+	metrics[kind] = m
+}
+
+// Kinds lists the registered rule kinds.
+func Kinds() []string {
+	out := make([]string, 0, len(metrics))
+	for k := range metrics {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func init() {
+	// confirms per window, this one included
+	Register("confirm_velocity", func(window []Event, _ Event) int64 {
+		return int64(len(window)) + 1
+	})
+	// distinct merchants paid per window, this one included
+	Register("distinct_merchants", func(window []Event, cur Event) int64 {
+		seen := map[string]bool{}
+		for _, e := range append(window, cur) {
+			if e.MerchantID != "" {
+				seen[e.MerchantID] = true
+			}
+		}
+		return int64(len(seen))
+	})
+	// confirms of exactly this amount per window, this one included (scripted card testing)
+	Register("repeated_amount", func(window []Event, cur Event) int64 {
+		n := int64(1)
+		for _, e := range window {
+			if e.AmountCents == cur.AmountCents {
+				n++
+			}
+		}
+		return n
+	})
+}
+
+// Rule is a stored rule definition.
+type Rule struct {
+	ID        int64
+	Name      string
+	Kind      string
+	Window    time.Duration
+	Threshold int64
+	Action    Action
+	Enabled   bool
+}
+
+func (r Rule) Validate() error {
+	if _, ok := metrics[r.Kind]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownRuleKind, r.Kind)
+	}
+	if r.Name == "" || r.Window <= 0 || r.Threshold < 0 || !r.Action.Valid() {
+		return errors.New("risk rule needs a name, a positive window, a non-negative threshold and an action of review, step_up or decline")
+	}
+	return nil
+}
+
+// Hit records one rule that fired.
+type Hit struct {
+	RuleID    int64  `json:"rule_id"`
+	Rule      string `json:"rule"`
+	Kind      string `json:"kind"`
+	Observed  int64  `json:"observed"`
+	Threshold int64  `json:"threshold"`
+	Action    Action `json:"action"`
+}
+
+type Decision struct {
+	Action Action
+	Hits   []Hit
+}
+
+// Evaluate runs every enabled rule; the decision is the most severe hit's action. history
+// holds the account's earlier confirms, any order. Step-up hits are ignored when
+// steppedUp (the owner already passed the challenge for this intent) but still recorded.
+func Evaluate(rules []Rule, history []Event, current Event, steppedUp bool) Decision {
+	sorted := append([]Event(nil), history...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+
+	d := Decision{Action: Allow}
+	for _, r := range rules {
+		m, ok := metrics[r.Kind]
+		if !r.Enabled || !ok {
+			continue
+		}
+		var window []Event
+		for _, e := range sorted {
+			if e.At.After(current.At.Add(-r.Window)) && !e.At.After(current.At) {
+				window = append(window, e)
+			}
+		}
+		observed := m(window, current)
+		if observed <= r.Threshold {
+			continue
+		}
+		d.Hits = append(d.Hits, Hit{
+			RuleID: r.ID, Rule: r.Name, Kind: r.Kind,
+			Observed: observed, Threshold: r.Threshold, Action: r.Action,
+		})
+		if r.Action == StepUp && steppedUp {
+			continue
+		}
+		if severity[r.Action] > severity[d.Action] {
+			d.Action = r.Action
+		}
+	}
+	return d
+}
+
+// MaxWindow is how much history Evaluate needs for rules.
+func MaxWindow(rules []Rule) time.Duration {
+	var max time.Duration
+	for _, r := range rules {
+		if r.Enabled && r.Window > max {
+			max = r.Window
+		}
+	}
+	return max
+}
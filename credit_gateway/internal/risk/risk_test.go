@@ -0,0 +1,60 @@
+package risk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluate_MostSevereHitWinsWithinWindow(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rules := []Rule{
+		{ID: 1, Name: "fast", Kind: "confirm_velocity", Window: time.Minute, Threshold: 3, Action: Decline, Enabled: true},
+		{ID: 2, Name: "spread", Kind: "distinct_merchants", Window: time.Hour, Threshold: 2, Action: Review, Enabled: true},
+		{ID: 3, Name: "same", Kind: "repeated_amount", Window: time.Hour, Threshold: 2, Action: StepUp, Enabled: true},
+		{ID: 4, Name: "off", Kind: "confirm_velocity", Window: time.Hour, Threshold: 0, Action: Decline, Enabled: false},
+	}
+	history := []Event{
+		{At: now.Add(-2 * time.Minute), MerchantID: "a", AmountCents: 10}, // outside the 1m window
+		{At: now.Add(-30 * time.Second), MerchantID: "b", AmountCents: 10},
+		{At: now.Add(-10 * time.Second), AmountCents: 5},
+	}
+
+	d := Evaluate(rules, history, Event{At: now, MerchantID: "c", AmountCents: 10}, false)
+	if d.Action != StepUp || len(d.Hits) != 2 {
+		t.Fatalf("decision = %+v, want step_up with 2 hits", d)
+	}
+	if d.Hits[0].Rule != "spread" || d.Hits[0].Observed != 3 || d.Hits[1].Rule != "same" || d.Hits[1].Observed != 3 {
+		t.Fatalf("hits = %+v", d.Hits)
+	}
+
+	// once stepped up, the step-up hit is kept but no longer decides
+	if d := Evaluate(rules, history, Event{At: now, MerchantID: "c", AmountCents: 10}, true); d.Action != Review || len(d.Hits) != 2 {
+		t.Fatalf("stepped-up decision = %+v", d)
+	}
+
+	// one more confirm inside the minute trips the velocity rule
+	history = append(history, Event{At: now.Add(-time.Second), AmountCents: 1})
+	if d := Evaluate(rules, history, Event{At: now, AmountCents: 2}, false); d.Action != Decline {
+		t.Fatalf("velocity decision = %+v, want decline", d)
+	}
+
+	if MaxWindow(rules) != time.Hour {
+		t.Fatalf("MaxWindow = %s", MaxWindow(rules))
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	ok := Rule{Name: "x", Kind: "confirm_velocity", Window: time.Minute, Threshold: 1, Action: Decline}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate = %v", err)
+	}
+	for _, r := range []Rule{
+		{Name: "x", Kind: "nope", Window: time.Minute, Action: Decline},
+		{Name: "x", Kind: "confirm_velocity", Window: 0, Action: Decline},
+		{Name: "x", Kind: "confirm_velocity", Window: time.Minute, Action: Allow},
+	} {
+		if r.Validate() == nil {
+			t.Errorf("Validate(%+v) = nil", r)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package subscription
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gateway/internal/domain"
+	"gateway/internal/repo"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job bills subscriptions: every run opens the periods that are due and collects them
+// (and retries past-due ones per Dunning).
+type Job struct {
+	DB      *pgxpool.Pool
+	Dunning domain.DunningPolicy
+
+	PollInterval time.Duration
+	BatchSize    int
+
+	now func() time.Time
+}
+
+func NewJob(db *pgxpool.Pool) *Job {
+	return &Job{
+		DB:           db,
+		Dunning:      domain.DefaultDunningPolicy(),
+		PollInterval: time.Minute,
+		BatchSize:    100,
+		now:          time.Now,
+	}
+}
+
+func (j *Job) Run(ctx context.Context) {
+	if err := j.RunOnce(ctx); err != nil {
+		log.Printf("subscription: %v", err)
+	}
+
+	t := time.NewTicker(j.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := j.RunOnce(ctx); err != nil {
+				log.Printf("subscription: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce processes every subscription due now. One failing subscription is logged and
+// does not hold up the others.
+func (j *Job) RunOnce(ctx context.Context) error {
+	now := j.now()
+
+	var after int64
+	for {
+		ids, err := repo.ListDueSubscriptions(ctx, j.DB, now, after, j.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			s, err := repo.ProcessSubscription(ctx, j.DB, id, j.Dunning, now)
+			if err != nil {
+				log.Printf("subscription %d: %v", id, err)
+			} else if s.Status != repo.SubscriptionActive {
+				log.Printf("subscription %d: %s", id, s.Status)
+			}
+			after = id
+		}
+		if len(ids) < j.BatchSize {
+			return nil
+		}
+	}
+}
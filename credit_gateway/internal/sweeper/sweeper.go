@@ -0,0 +1,64 @@
+package sweeper
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gateway/internal/repo"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Sweeper periodically moves pending payment intents past their expires_at to expired
+// and releases authorization holds that were never captured or voided.
+type Sweeper struct {
+	DB *pgxpool.Pool
+
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+func NewSweeper(db *pgxpool.Pool) *Sweeper {
+	return &Sweeper{
+		DB:           db,
+		PollInterval: 30 * time.Second,
+		BatchSize:    100,
+	}
+}
+
+func (s *Sweeper) Run(ctx context.Context) {
+	t := time.NewTicker(s.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.SweepOnce(ctx); err != nil {
+				log.Printf("sweeper: %v", err)
+			}
+		}
+	}
+}
+
+// SweepOnce expires batches until no stale pending intent or hold is left.
+func (s *Sweeper) SweepOnce(ctx context.Context) error {
+	if err := s.drain(ctx, repo.ExpirePaymentIntents); err != nil {
+		return err
+	}
+	return s.drain(ctx, repo.ReleaseExpiredHolds)
+}
+
+func (s *Sweeper) drain(ctx context.Context, step func(context.Context, *pgxpool.Pool, int) (int64, error)) error {
+	for {
+		n, err := step(ctx, s.DB, s.BatchSize)
+		if err != nil {
+			return err
+		}
+		if n == 0 || n < int64(s.BatchSize) {
+			return nil
+		}
+	}
+}
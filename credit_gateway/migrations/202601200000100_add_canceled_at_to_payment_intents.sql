@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE payment_intents
+  ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS canceled_at;
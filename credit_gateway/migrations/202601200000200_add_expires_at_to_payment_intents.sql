@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE payment_intents
+  ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_payment_intents_pending_expires_at
+  ON payment_intents (expires_at)
+  WHERE status = 'pending' AND expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_payment_intents_pending_expires_at;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE holds (
+  id                UUID PRIMARY KEY,
+  account_id        UUID NOT NULL REFERENCES accounts(id),
+  payment_intent_id UUID NOT NULL UNIQUE REFERENCES payment_intents(id),
+
+  -- price fixed at authorization; amount_cents = principal_cents + interest_cents
+  principal_cents   BIGINT NOT NULL CHECK (principal_cents >= 0),
+  interest_cents    BIGINT NOT NULL CHECK (interest_cents >= 0),
+  amount_cents      BIGINT NOT NULL CHECK (amount_cents >= 0),
+
+  status            TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'captured', 'voided', 'released')),
+
+  expires_at        TIMESTAMPTZ NOT NULL,
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_holds_account_active
+  ON holds (account_id)
+  WHERE status = 'active';
+
+CREATE INDEX idx_holds_active_expires_at
+  ON holds (expires_at)
+  WHERE status = 'active';
+
+-- +goose Down
+DROP TABLE IF EXISTS holds;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE payment_quotes (
+  id                   UUID PRIMARY KEY,
+  payment_intent_id    UUID NOT NULL REFERENCES payment_intents(id) ON DELETE CASCADE,
+  account_id           UUID NOT NULL REFERENCES accounts(id),
+
+  -- accounts.attempt_count when quoted; confirm is rejected if it moved
+  attempt_count        BIGINT NOT NULL CHECK (attempt_count >= 0),
+
+  rate_bps             BIGINT NOT NULL,
+  principal_cents      BIGINT NOT NULL,
+  interest_cents       BIGINT NOT NULL,
+  penalty_cents        BIGINT NOT NULL DEFAULT 0,
+  total_cents          BIGINT NOT NULL,
+  exceeds_credit_limit BOOLEAN NOT NULL,
+
+  expires_at           TIMESTAMPTZ NOT NULL,
+  used_at              TIMESTAMPTZ,
+  created_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payment_quotes_intent ON payment_quotes(payment_intent_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS payment_quotes;
@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE interest_policies (
+  version                   BIGSERIAL PRIMARY KEY,
+
+  base_rate_bps             BIGINT NOT NULL CHECK (base_rate_bps >= 0),
+  step_bps_per_attempt      BIGINT NOT NULL CHECK (step_bps_per_attempt >= 0),
+  invalid_amount_fine_cents BIGINT NOT NULL CHECK (invalid_amount_fine_cents >= 0),
+
+  -- accounts without a pinned version use the latest version already in effect
+  effective_from            TIMESTAMPTZ NOT NULL,
+  created_at                TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_interest_policies_effective_from
+  ON interest_policies (effective_from);
+
+-- version 1 = the original hard-coded rules (domain.DefaultPolicy)
+INSERT INTO interest_policies (base_rate_bps, step_bps_per_attempt, invalid_amount_fine_cents, effective_from)
+VALUES (10000, 100, 1000, 'epoch');
+
+-- NULL => follow the policy in effect; set => pinned to that version
+ALTER TABLE accounts
+  ADD COLUMN interest_policy_version BIGINT REFERENCES interest_policies(version);
+
+-- which rules produced each charge
+ALTER TABLE ledger_entries
+  ADD COLUMN policy_version BIGINT REFERENCES interest_policies(version);
+
+ALTER TABLE holds
+  ADD COLUMN policy_version BIGINT REFERENCES interest_policies(version);
+
+ALTER TABLE payment_quotes
+  ADD COLUMN policy_version BIGINT REFERENCES interest_policies(version);
+
+-- +goose Down
+ALTER TABLE payment_quotes DROP COLUMN IF EXISTS policy_version;
+ALTER TABLE holds DROP COLUMN IF EXISTS policy_version;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS policy_version;
+ALTER TABLE accounts DROP COLUMN IF EXISTS interest_policy_version;
+DROP TABLE IF EXISTS interest_policies;
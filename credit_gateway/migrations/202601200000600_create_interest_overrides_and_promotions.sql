@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE account_interest_overrides (
+  account_id           UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+
+  -- NULL => keep the policy version's value
+  base_rate_bps        BIGINT CHECK (base_rate_bps >= 0),
+  step_bps_per_attempt BIGINT CHECK (step_bps_per_attempt >= 0),
+
+  created_at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE interest_promotions (
+  id                BIGSERIAL PRIMARY KEY,
+  account_id        UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+
+  rate_bps          BIGINT NOT NULL DEFAULT 0 CHECK (rate_bps >= 0),
+
+  -- first N attempts and/or until a date
+  max_attempt_count BIGINT CHECK (max_attempt_count >= 0),
+  starts_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ends_at           TIMESTAMPTZ,
+
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CHECK (max_attempt_count IS NOT NULL OR ends_at IS NOT NULL)
+);
+
+CREATE INDEX idx_interest_promotions_account ON interest_promotions(account_id);
+
+-- effective rate and promotion behind each interest entry
+ALTER TABLE ledger_entries
+  ADD COLUMN rate_bps BIGINT,
+  ADD COLUMN promotion_id BIGINT REFERENCES interest_promotions(id);
+
+ALTER TABLE holds
+  ADD COLUMN rate_bps BIGINT,
+  ADD COLUMN promotion_id BIGINT REFERENCES interest_promotions(id);
+
+ALTER TABLE payment_quotes
+  ADD COLUMN promotion_id BIGINT REFERENCES interest_promotions(id);
+
+-- +goose Down
+ALTER TABLE payment_quotes DROP COLUMN IF EXISTS promotion_id;
+ALTER TABLE holds DROP COLUMN IF EXISTS promotion_id, DROP COLUMN IF EXISTS rate_bps;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS promotion_id, DROP COLUMN IF EXISTS rate_bps;
+DROP TABLE IF EXISTS interest_promotions;
+DROP TABLE IF EXISTS account_interest_overrides;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- 0 => uncapped / no decay / nothing forgiven (version 1 keeps the original rules)
+ALTER TABLE interest_policies
+  ADD COLUMN max_rate_bps                    BIGINT NOT NULL DEFAULT 0 CHECK (max_rate_bps >= 0),
+  ADD COLUMN attempt_decay_interval_seconds  BIGINT NOT NULL DEFAULT 0 CHECK (attempt_decay_interval_seconds >= 0),
+  ADD COLUMN attempt_decay_per_interval      BIGINT NOT NULL DEFAULT 0 CHECK (attempt_decay_per_interval >= 0),
+  ADD COLUMN attempts_forgiven_per_repayment BIGINT NOT NULL DEFAULT 0 CHECK (attempts_forgiven_per_repayment >= 0);
+
+-- decay is measured from the last confirm/authorize attempt
+ALTER TABLE accounts
+  ADD COLUMN last_attempt_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE accounts DROP COLUMN IF EXISTS last_attempt_at;
+ALTER TABLE interest_policies
+  DROP COLUMN IF EXISTS attempts_forgiven_per_repayment,
+  DROP COLUMN IF EXISTS attempt_decay_per_interval,
+  DROP COLUMN IF EXISTS attempt_decay_interval_seconds,
+  DROP COLUMN IF EXISTS max_rate_bps;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- floor => the original integer-division behaviour
+ALTER TABLE interest_policies
+  ADD COLUMN rounding_mode TEXT NOT NULL DEFAULT 'floor'
+    CHECK (rounding_mode IN ('floor', 'ceil', 'half_up', 'half_even'));
+
+-- +goose Down
+ALTER TABLE interest_policies DROP COLUMN IF EXISTS rounding_mode;
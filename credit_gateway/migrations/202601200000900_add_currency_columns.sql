@@ -0,0 +1,20 @@
+-- +goose Up
+-- ISO-4217 codes; every *_cents column is in the minor unit of its row's currency.
+-- Existing rows were all USD.
+ALTER TABLE accounts
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE payment_intents
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE ledger_entries
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE merchant_requests
+  ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' CHECK (currency ~ '^[A-Z]{3}$');
+
+-- +goose Down
+ALTER TABLE merchant_requests DROP COLUMN IF EXISTS currency;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS currency;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS currency;
+ALTER TABLE accounts DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- one row per account per calendar day (UTC), so re-running a day is a no-op
+CREATE TABLE interest_accruals (
+  id              BIGSERIAL PRIMARY KEY,
+  account_id      UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  accrual_date    DATE NOT NULL,
+
+  -- outstanding principal at the end of accrual_date
+  principal_cents BIGINT NOT NULL CHECK (principal_cents >= 0),
+  rate_bps        BIGINT NOT NULL CHECK (rate_bps >= 0),
+  interest_cents  BIGINT NOT NULL CHECK (interest_cents >= 0),
+
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  UNIQUE (account_id, accrual_date)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS interest_accruals;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- billing cycles close on this day every month (<= 28 so every month has it)
+ALTER TABLE accounts
+  ADD COLUMN statement_day SMALLINT NOT NULL DEFAULT 1 CHECK (statement_day BETWEEN 1 AND 28);
+
+CREATE TABLE statements (
+  id                    BIGSERIAL PRIMARY KEY,
+  account_id            UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+
+  -- "YYYY-MM" of period_end; covers ledger entries in [period_start, period_end) UTC
+  period                TEXT NOT NULL,
+  period_start          DATE NOT NULL,
+  period_end            DATE NOT NULL CHECK (period_end > period_start),
+  currency              CHAR(3) NOT NULL,
+
+  opening_balance_cents BIGINT NOT NULL,
+  closing_balance_cents BIGINT NOT NULL,
+  minimum_payment_cents BIGINT NOT NULL CHECK (minimum_payment_cents >= 0),
+  due_date              DATE NOT NULL,
+
+  created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  UNIQUE (account_id, period)
+);
+
+-- snapshot of the ledger entries a statement covers
+CREATE TABLE statement_entries (
+  id                BIGSERIAL PRIMARY KEY,
+  statement_id      BIGINT NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+  ledger_entry_id   UUID NOT NULL REFERENCES ledger_entries(id),
+  payment_intent_id UUID,
+  entry_type        TEXT NOT NULL,
+  amount_cents      BIGINT NOT NULL,
+  posted_at         TIMESTAMPTZ NOT NULL,
+
+  UNIQUE (statement_id, ledger_entry_id)
+);
+
+CREATE INDEX idx_ledger_account_created_at ON ledger_entries (account_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ledger_account_created_at;
+DROP TABLE IF EXISTS statement_entries;
+DROP TABLE IF EXISTS statements;
+ALTER TABLE accounts DROP COLUMN IF EXISTS statement_day;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- current -> late -> delinquent -> charged_off, driven by missed statement minimums
+ALTER TABLE accounts
+  ADD COLUMN delinquency_status TEXT NOT NULL DEFAULT 'current'
+    CHECK (delinquency_status IN ('current', 'late', 'delinquent', 'charged_off')),
+  ADD COLUMN past_due_since DATE;
+
+-- the late fee is posted at most once per statement
+ALTER TABLE statements
+  ADD COLUMN late_fee_posted_at TIMESTAMPTZ;
+
+CREATE TABLE delinquency_transitions (
+  id            BIGSERIAL PRIMARY KEY,
+  account_id    UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  statement_id  BIGINT REFERENCES statements(id) ON DELETE SET NULL,
+  from_status   TEXT NOT NULL,
+  to_status     TEXT NOT NULL,
+  days_past_due INT NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_delinquency_transitions_account ON delinquency_transitions (account_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS delinquency_transitions;
+ALTER TABLE statements DROP COLUMN IF EXISTS late_fee_posted_at;
+ALTER TABLE accounts
+  DROP COLUMN IF EXISTS past_due_since,
+  DROP COLUMN IF EXISTS delinquency_status;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- limits an account owner sets on their own spending; NULL = no limit
+CREATE TABLE spending_controls (
+  account_id                 UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+  daily_limit_cents          BIGINT CHECK (daily_limit_cents >= 0),
+  merchant_daily_limit_cents BIGINT CHECK (merchant_daily_limit_cents >= 0),
+  max_attempts               BIGINT CHECK (max_attempts >= 0),
+  updated_at                 TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- per-merchant allow/block list (any 'allow' row turns it into an allowlist)
+CREATE TABLE spending_merchant_rules (
+  account_id        UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  merchant_id       TEXT NOT NULL,
+  access            TEXT NOT NULL CHECK (access IN ('allow', 'block')),
+  daily_limit_cents BIGINT CHECK (daily_limit_cents >= 0),
+
+  PRIMARY KEY (account_id, merchant_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS spending_merchant_rules;
+DROP TABLE IF EXISTS spending_controls;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- velocity rules evaluated on every confirm; kind names a metric registered in internal/risk
+CREATE TABLE risk_rules (
+  id             BIGSERIAL PRIMARY KEY,
+  name           TEXT NOT NULL UNIQUE,
+  kind           TEXT NOT NULL,
+  window_seconds INT NOT NULL CHECK (window_seconds > 0),
+  threshold      BIGINT NOT NULL CHECK (threshold >= 0),
+  action         TEXT NOT NULL CHECK (action IN ('review', 'step_up', 'decline')),
+  enabled        BOOLEAN NOT NULL DEFAULT true,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO risk_rules (name, kind, window_seconds, threshold, action) VALUES
+  ('burst_confirms',        'confirm_velocity',   60,   20, 'decline'),
+  ('many_merchants',        'distinct_merchants', 3600, 5,  'review'),
+  ('scripted_same_amount',  'repeated_amount',    600,  50, 'step_up');
+
+-- one row per evaluated confirm (also the velocity history)
+CREATE TABLE risk_decisions (
+  id                   BIGSERIAL PRIMARY KEY,
+  account_id           UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  payment_intent_id    UUID NOT NULL REFERENCES payment_intents(id) ON DELETE CASCADE,
+  merchant_id          TEXT,
+  amount_cents         BIGINT NOT NULL,
+  action               TEXT NOT NULL CHECK (action IN ('allow', 'review', 'step_up', 'decline')),
+  hits                 JSONB NOT NULL DEFAULT '[]'::jsonb,
+  step_up_completed_at TIMESTAMPTZ,
+  created_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_risk_decisions_account_created_at ON risk_decisions (account_id, created_at);
+CREATE INDEX idx_risk_decisions_intent ON risk_decisions (payment_intent_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS risk_decisions;
+DROP TABLE IF EXISTS risk_rules;
@@ -0,0 +1,47 @@
+-- +goose Up
+-- confirms flagged by a 'review' risk rule wait here (payment_intents.status = 'requires_review')
+CREATE TABLE payment_reviews (
+  id                     BIGSERIAL PRIMARY KEY,
+  payment_intent_id      UUID NOT NULL UNIQUE REFERENCES payment_intents(id) ON DELETE CASCADE,
+  account_id             UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  risk_decision_id       BIGINT REFERENCES risk_decisions(id) ON DELETE SET NULL,
+
+  status                 TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'approved', 'declined')),
+
+  -- price at the time of flagging (attempt already counted)
+  attempt_count          BIGINT NOT NULL,
+  policy_version         BIGINT NOT NULL,
+  rate_bps               BIGINT NOT NULL,
+  interest_cents         BIGINT NOT NULL,
+  promotion_id           BIGINT,
+
+  -- what approval actually charged
+  pricing                TEXT CHECK (pricing IN ('flagged', 'approval')),
+  charged_rate_bps       BIGINT,
+  charged_interest_cents BIGINT,
+
+  decided_by             TEXT,
+  decision_reason        TEXT,
+  decided_at             TIMESTAMPTZ,
+  created_at             TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payment_reviews_pending ON payment_reviews (created_at) WHERE status = 'pending';
+
+-- append-only audit trail of everything that happened to a review
+CREATE TABLE payment_review_events (
+  id         BIGSERIAL PRIMARY KEY,
+  review_id  BIGINT NOT NULL REFERENCES payment_reviews(id) ON DELETE CASCADE,
+  event      TEXT NOT NULL CHECK (event IN ('flagged', 'approved', 'declined')),
+  actor      TEXT NOT NULL,
+  reason     TEXT,
+  details    JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payment_review_events_review ON payment_review_events (review_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS payment_review_events;
+DROP TABLE IF EXISTS payment_reviews;
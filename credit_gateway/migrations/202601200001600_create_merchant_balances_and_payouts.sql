@@ -0,0 +1,41 @@
+-- +goose Up
+-- what the gateway owes each merchant, per currency
+CREATE TABLE merchant_balances (
+  merchant_id   TEXT NOT NULL,
+  currency      CHAR(3) NOT NULL,
+  balance_cents BIGINT NOT NULL DEFAULT 0,
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  PRIMARY KEY (merchant_id, currency)
+);
+
+CREATE TABLE payouts (
+  id             BIGSERIAL PRIMARY KEY,
+  merchant_id    TEXT NOT NULL,
+  currency       CHAR(3) NOT NULL,
+  amount_cents   BIGINT NOT NULL CHECK (amount_cents > 0),
+  -- balance kept back (reserve / holdback) when this payout was cut
+  retained_cents BIGINT NOT NULL,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payouts_merchant ON payouts (merchant_id, id);
+
+-- every movement of a merchant balance: +payment installments, -payouts
+CREATE TABLE merchant_ledger_entries (
+  id                  BIGSERIAL PRIMARY KEY,
+  merchant_id         TEXT NOT NULL,
+  currency            CHAR(3) NOT NULL,
+  entry_type          TEXT NOT NULL CHECK (entry_type IN ('payment', 'payout')),
+  amount_cents        BIGINT NOT NULL,
+  merchant_request_id BIGINT REFERENCES merchant_requests(id),
+  payout_id           BIGINT REFERENCES payouts(id),
+  created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_merchant_ledger_merchant_created_at ON merchant_ledger_entries (merchant_id, currency, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS merchant_ledger_entries;
+DROP TABLE IF EXISTS payouts;
+DROP TABLE IF EXISTS merchant_balances;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- fee kept from each installment: flat + bps of the installment, at least min_fee (no row = no fee)
+CREATE TABLE merchant_fee_schedules (
+  merchant_id   TEXT PRIMARY KEY,
+  flat_cents    BIGINT NOT NULL DEFAULT 0 CHECK (flat_cents >= 0),
+  rate_bps      BIGINT NOT NULL DEFAULT 0 CHECK (rate_bps >= 0),
+  min_fee_cents BIGINT NOT NULL DEFAULT 0 CHECK (min_fee_cents >= 0),
+  rounding_mode TEXT NOT NULL DEFAULT 'half_up'
+    CHECK (rounding_mode IN ('floor', 'ceil', 'half_up', 'half_even')),
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE merchant_ledger_entries DROP CONSTRAINT merchant_ledger_entries_entry_type_check;
+ALTER TABLE merchant_ledger_entries
+  ADD CONSTRAINT merchant_ledger_entries_entry_type_check CHECK (entry_type IN ('payment', 'fee', 'payout'));
+
+-- fees kept so far; paid_cents stays the gross amount
+ALTER TABLE merchant_requests
+  ADD COLUMN fee_cents BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE merchant_requests DROP COLUMN IF EXISTS fee_cents;
+DELETE FROM merchant_ledger_entries WHERE entry_type = 'fee';
+ALTER TABLE merchant_ledger_entries DROP CONSTRAINT merchant_ledger_entries_entry_type_check;
+ALTER TABLE merchant_ledger_entries
+  ADD CONSTRAINT merchant_ledger_entries_entry_type_check CHECK (entry_type IN ('payment', 'payout'));
+DROP TABLE IF EXISTS merchant_fee_schedules;
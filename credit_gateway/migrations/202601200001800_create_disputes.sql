@@ -0,0 +1,53 @@
+-- +goose Up
+-- a payer's dispute of a merchant request, or of one pay intent of it.
+-- won / lost are from the merchant's side, as with card chargebacks.
+CREATE TABLE disputes (
+  id                  BIGSERIAL PRIMARY KEY,
+  merchant_request_id BIGINT NOT NULL REFERENCES merchant_requests(id) ON DELETE CASCADE,
+  payment_intent_id   UUID REFERENCES payment_intents(id) ON DELETE CASCADE,
+  account_id          UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  merchant_id         TEXT NOT NULL,
+  currency            CHAR(3) NOT NULL,
+
+  amount_cents        BIGINT NOT NULL CHECK (amount_cents > 0),
+  -- provisional credit actually applied to the payer's balance (never below zero)
+  credited_cents      BIGINT NOT NULL CHECK (credited_cents >= 0),
+
+  status              TEXT NOT NULL DEFAULT 'opened'
+    CHECK (status IN ('opened', 'evidence_required', 'won', 'lost')),
+  reason              TEXT NOT NULL,
+
+  resolved_by         TEXT,
+  resolved_at         TIMESTAMPTZ,
+  created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_disputes_merchant_request ON disputes (merchant_request_id, id);
+CREATE INDEX idx_disputes_payment_intent ON disputes (payment_intent_id) WHERE payment_intent_id IS NOT NULL;
+
+CREATE TABLE dispute_evidence (
+  id           BIGSERIAL PRIMARY KEY,
+  dispute_id   BIGINT NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+  submitted_by TEXT NOT NULL CHECK (submitted_by IN ('payer', 'merchant')),
+  description  TEXT NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_dispute_evidence_dispute ON dispute_evidence (dispute_id, id);
+
+ALTER TABLE merchant_ledger_entries
+  ADD COLUMN dispute_id BIGINT REFERENCES disputes(id);
+ALTER TABLE merchant_ledger_entries DROP CONSTRAINT merchant_ledger_entries_entry_type_check;
+ALTER TABLE merchant_ledger_entries
+  ADD CONSTRAINT merchant_ledger_entries_entry_type_check
+  CHECK (entry_type IN ('payment', 'fee', 'payout', 'dispute', 'dispute_reversal'));
+
+-- +goose Down
+DELETE FROM merchant_ledger_entries WHERE entry_type IN ('dispute', 'dispute_reversal');
+ALTER TABLE merchant_ledger_entries DROP CONSTRAINT merchant_ledger_entries_entry_type_check;
+ALTER TABLE merchant_ledger_entries
+  ADD CONSTRAINT merchant_ledger_entries_entry_type_check CHECK (entry_type IN ('payment', 'fee', 'payout'));
+ALTER TABLE merchant_ledger_entries DROP COLUMN IF EXISTS dispute_id;
+DROP TABLE IF EXISTS dispute_evidence;
+DROP TABLE IF EXISTS disputes;
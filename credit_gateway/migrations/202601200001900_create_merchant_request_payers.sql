@@ -0,0 +1,21 @@
+-- +goose Up
+-- who owes what on a merchant request; merchant_requests.payer_account_id stays the first
+-- payer. A request without rows here is owed in full by payer_account_id.
+CREATE TABLE merchant_request_payers (
+  merchant_request_id BIGINT NOT NULL REFERENCES merchant_requests(id) ON DELETE CASCADE,
+  account_id          UUID NOT NULL REFERENCES accounts(id),
+  position            INT NOT NULL,
+  share_cents         BIGINT NOT NULL CHECK (share_cents > 0),
+  paid_cents          BIGINT NOT NULL DEFAULT 0 CHECK (paid_cents >= 0),
+
+  PRIMARY KEY (merchant_request_id, account_id)
+);
+
+CREATE INDEX idx_merchant_request_payers_account ON merchant_request_payers (account_id);
+
+INSERT INTO merchant_request_payers (merchant_request_id, account_id, position, share_cents, paid_cents)
+SELECT id, payer_account_id, 0, target_cents, paid_cents
+FROM merchant_requests;
+
+-- +goose Down
+DROP TABLE IF EXISTS merchant_request_payers;
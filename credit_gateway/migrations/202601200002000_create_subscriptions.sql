@@ -0,0 +1,44 @@
+-- +goose Up
+-- bills a payer for a merchant every interval: each period opens a merchant request
+-- (reference sub_<id>_<period>) that the subscription job collects
+CREATE TABLE subscriptions (
+  id                          BIGSERIAL PRIMARY KEY,
+  merchant_id                 TEXT NOT NULL,
+  payer_account_id            UUID NOT NULL REFERENCES accounts(id),
+  amount_cents                BIGINT NOT NULL CHECK (amount_cents > 0),
+  currency                    CHAR(3) NOT NULL,
+  billing_interval            TEXT NOT NULL CHECK (billing_interval IN ('day', 'week', 'month')),
+  starts_at                   TIMESTAMPTZ NOT NULL,
+  ends_at                     TIMESTAMPTZ,
+  webhook_url                 TEXT NOT NULL,
+
+  status                      TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'paused', 'past_due', 'canceled', 'ended')),
+
+  -- periods opened so far, and when the next one opens
+  periods                     INT NOT NULL DEFAULT 0,
+  next_period_at              TIMESTAMPTZ NOT NULL,
+
+  -- the open period being collected (null between periods)
+  current_merchant_request_id BIGINT REFERENCES merchant_requests(id),
+  -- failed collections of the open period, and when to try again
+  failed_attempts             INT NOT NULL DEFAULT 0,
+  next_attempt_at             TIMESTAMPTZ,
+  last_error                  TEXT,
+
+  canceled_at                 TIMESTAMPTZ,
+  created_at                  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at                  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_subscriptions_due ON subscriptions (next_period_at) WHERE status IN ('active', 'past_due');
+CREATE INDEX idx_subscriptions_merchant ON subscriptions (merchant_id, id);
+
+ALTER TABLE merchant_requests
+  ADD COLUMN subscription_id BIGINT REFERENCES subscriptions(id);
+
+-- +goose Down
+ALTER TABLE merchant_requests DROP COLUMN IF EXISTS subscription_id;
+DROP TABLE IF EXISTS subscriptions;
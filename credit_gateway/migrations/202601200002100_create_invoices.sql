@@ -0,0 +1,34 @@
+-- +goose Up
+-- an itemized bill; creating one opens the merchant request (target = total_cents) that collects it
+CREATE TABLE invoices (
+  id                  BIGSERIAL PRIMARY KEY,
+  merchant_request_id BIGINT NOT NULL UNIQUE REFERENCES merchant_requests(id),
+  merchant_id         TEXT NOT NULL,
+  currency            CHAR(3) NOT NULL,
+  memo                TEXT,
+  subtotal_cents      BIGINT NOT NULL CHECK (subtotal_cents >= 0),
+  tax_cents           BIGINT NOT NULL CHECK (tax_cents >= 0),
+  total_cents         BIGINT NOT NULL CHECK (total_cents > 0),
+  created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CHECK (total_cents = subtotal_cents + tax_cents)
+);
+
+-- subtotal = quantity * unit_cents; tax is rounded half-up per line
+CREATE TABLE invoice_line_items (
+  invoice_id     BIGINT NOT NULL REFERENCES invoices(id),
+  position       INT NOT NULL,
+  description    TEXT NOT NULL,
+  quantity       BIGINT NOT NULL CHECK (quantity > 0),
+  unit_cents     BIGINT NOT NULL CHECK (unit_cents >= 0),
+  tax_bps        BIGINT NOT NULL DEFAULT 0 CHECK (tax_bps >= 0),
+  subtotal_cents BIGINT NOT NULL,
+  tax_cents      BIGINT NOT NULL,
+  total_cents    BIGINT NOT NULL,
+
+  PRIMARY KEY (invoice_id, position)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS invoice_line_items;
+DROP TABLE IF EXISTS invoices;
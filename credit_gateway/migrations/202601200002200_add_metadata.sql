@@ -0,0 +1,15 @@
+-- +goose Up
+-- client key/value data (see domain.Metadata for the limits); searchable with @>
+ALTER TABLE payment_intents
+  ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE merchant_requests
+  ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_payment_intents_metadata ON payment_intents USING gin (metadata jsonb_path_ops);
+CREATE INDEX idx_merchant_requests_metadata ON merchant_requests USING gin (metadata jsonb_path_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_merchant_requests_metadata;
+DROP INDEX IF EXISTS idx_payment_intents_metadata;
+ALTER TABLE merchant_requests DROP COLUMN IF EXISTS metadata;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- NULL expires_at: the hold never expires (HOLD_TTL=0), like payment intents
+ALTER TABLE holds ALTER COLUMN expires_at DROP NOT NULL;
+
+-- +goose Down
+UPDATE holds SET expires_at = 'infinity' WHERE expires_at IS NULL;
+ALTER TABLE holds ALTER COLUMN expires_at SET NOT NULL;